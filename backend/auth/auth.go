@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// ErrNotConfigured is returned when JWT_SECRET is not set or too weak
+	ErrNotConfigured = errors.New("authentication is not configured")
+	// ErrInvalidToken is returned for malformed, forged or expired tokens
+	ErrInvalidToken = errors.New("invalid or expired session token")
+)
+
+// minSecretLength is the shortest JWT_SECRET accepted, in bytes: an HS256
+// key shorter than its 32-byte output is easier to brute-force
+const minSecretLength = 32
+
+// placeholderSecret is the JWT_SECRET the sample .env files used to ship.
+// Anyone can sign tokens with it, so it is refused like a short one.
+const placeholderSecret = "your-super-secret-jwt-key-change-this-in-production"
+
+// claims are the JWT claims this API reads
+type claims struct {
+	Subject   string `json:"sub"`
+	Email     string `json:"email"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// secret returns the key sessions are signed with
+func secret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// CheckSecret reports whether JWT_SECRET is unusable: the sample
+// placeholder, or shorter than minSecretLength bytes. An unset secret is
+// fine, it leaves authentication disabled. Called at startup.
+func CheckSecret() error {
+	key := string(secret())
+	switch {
+	case key == "":
+		return nil
+	case key == placeholderSecret:
+		return errors.New("JWT_SECRET is the sample placeholder; set a random secret of at least 32 bytes")
+	case len(key) < minSecretLength:
+		return fmt.Errorf("JWT_SECRET is %d bytes; it must be at least %d", len(key), minSecretLength)
+	}
+	return nil
+}
+
+// Enabled reports whether session tokens can be verified. A weak secret
+// never enables authentication, see CheckSecret.
+func Enabled() bool {
+	return len(secret()) > 0 && CheckSecret() == nil
+}
+
+// Verify checks an HS256 session token and returns the user it was issued to.
+// The token must carry a subject and an expiry that hasn't passed.
+func Verify(token string) (*models.AuthenticatedUser, error) {
+	if !Enabled() {
+		return nil, ErrNotConfigured
+	}
+	key := secret()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidToken
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil || c.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+	// Tokens without an expiry would be valid forever, so exp is required
+	if c.ExpiresAt == 0 || time.Now().Unix() >= c.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+
+	return &models.AuthenticatedUser{ID: c.Subject, Email: c.Email}, nil
+}
+
+// FromRequest returns the user of the request's bearer token, or nil when the
+// request carries no token
+func FromRequest(c *fiber.Ctx) (*models.AuthenticatedUser, error) {
+	header := c.Get("Authorization")
+	if header == "" {
+		return nil, nil
+	}
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return nil, ErrInvalidToken
+	}
+	return Verify(strings.TrimSpace(token))
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
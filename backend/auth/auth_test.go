@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// sign builds an HS256 token over claims
+func sign(t *testing.T, key string, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// testSecret is a JWT_SECRET long enough for CheckSecret
+const testSecret = "test-secret-0123456789abcdef0123456789"
+
+func TestVerify(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	hour := time.Hour
+	future, past := time.Now().Add(hour).Unix(), time.Now().Add(-hour).Unix()
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", sign(t, testSecret, map[string]interface{}{"sub": "user-1", "exp": future}), false},
+		{"no expiry", sign(t, testSecret, map[string]interface{}{"sub": "user-1"}), true},
+		{"zero expiry", sign(t, testSecret, map[string]interface{}{"sub": "user-1", "exp": 0}), true},
+		{"expired", sign(t, testSecret, map[string]interface{}{"sub": "user-1", "exp": past}), true},
+		{"no subject", sign(t, testSecret, map[string]interface{}{"exp": future}), true},
+		{"other key", sign(t, "other-secret", map[string]interface{}{"sub": "user-1", "exp": future}), true},
+		{"malformed", "not-a-token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && user.ID != "user-1" {
+				t.Errorf("Verify() user ID = %q, want %q", user.ID, "user-1")
+			}
+		})
+	}
+}
+
+func TestCheckSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+		enabled bool
+	}{
+		{"unset", "", false, false},
+		{"placeholder", placeholderSecret, true, false},
+		{"short", "test-secret", true, false},
+		{"31 bytes", "0123456789abcdef0123456789abcde", true, false},
+		{"32 bytes", "0123456789abcdef0123456789abcdef", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", tt.secret)
+			if err := CheckSecret(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := Enabled(); got != tt.enabled {
+				t.Errorf("Enabled() = %v, want %v", got, tt.enabled)
+			}
+		})
+	}
+}
+
+func TestVerifyWeakSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", placeholderSecret)
+	token := sign(t, placeholderSecret, map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := Verify(token); err != ErrNotConfigured {
+		t.Errorf("Verify() error = %v, want %v", err, ErrNotConfigured)
+	}
+}
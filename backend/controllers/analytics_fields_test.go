@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"form-builder-api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestFieldsInFormOrder(t *testing.T) {
+	fields := []models.FormField{
+		{ID: "c", Order: 2},
+		{ID: "a", Order: 0},
+		{ID: "b1", Order: 1},
+		{ID: "b2", Order: 1},
+	}
+	got := fieldsInFormOrder(fields)
+
+	want := []string{"a", "b1", "b2", "c"}
+	for i, field := range got {
+		if field.ID != want[i] {
+			t.Errorf("fieldsInFormOrder()[%d] = %q, want %q", i, field.ID, want[i])
+		}
+	}
+	if fields[0].ID != "c" {
+		t.Errorf("fieldsInFormOrder() reordered its argument")
+	}
+}
+
+// unreachableCollection returns a collection whose every operation fails,
+// standing in for an aggregation error
+func unreachableCollection(t *testing.T) *mongo.Collection {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://127.0.0.1:1").
+		SetServerSelectionTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("mongo.Connect() error = %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	return client.Database("test").Collection("responses")
+}
+
+func TestFieldAnalyticsEntryError(t *testing.T) {
+	rc := &ResponseController{responseCollection: unreachableCollection(t)}
+
+	tests := []struct {
+		name  string
+		field models.FormField
+	}{
+		{"choice field", models.FormField{ID: "color", Label: "Color", Type: models.FieldTypeMultipleChoice}},
+		{"deleted field", models.FormField{ID: "old", Label: deletedFieldLabel}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := rc.fieldAnalyticsEntry(context.Background(), primitive.NewObjectID(), tt.field, 10, analyticsOptions{})
+			if entry["field_id"] != tt.field.ID || entry["field_label"] != tt.field.Label || entry["field_type"] != tt.field.Type {
+				t.Errorf("fieldAnalyticsEntry() = %v, want an entry for field %q", entry, tt.field.ID)
+			}
+			if entry["error"] == nil {
+				t.Errorf("fieldAnalyticsEntry() = %v, want an error marker", entry)
+			}
+		})
+	}
+}
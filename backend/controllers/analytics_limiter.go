@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Defaults for the analytics concurrency limit; ANALYTICS_MAX_CONCURRENT and
+// ANALYTICS_QUEUE_TIMEOUT override them
+const (
+	defaultAnalyticsConcurrency  = 4
+	defaultAnalyticsQueueTimeout = 5 * time.Second
+)
+
+// errAnalyticsBusy is returned when every analytics slot stayed taken for
+// the whole queue timeout
+var errAnalyticsBusy = errors.New("too many analytics calculations running")
+
+// analyticsLimiter caps how many analytics calculations run at once across
+// all forms, so a traffic spike can't saturate the database with aggregations
+type analyticsLimiter struct {
+	slots chan struct{}
+	wait  time.Duration
+}
+
+// newAnalyticsLimiter creates a limiter from the configured concurrency and
+// queue timeout
+func newAnalyticsLimiter() *analyticsLimiter {
+	concurrency, err := strconv.Atoi(os.Getenv("ANALYTICS_MAX_CONCURRENT"))
+	if err != nil || concurrency <= 0 {
+		concurrency = defaultAnalyticsConcurrency
+	}
+	wait, err := time.ParseDuration(os.Getenv("ANALYTICS_QUEUE_TIMEOUT"))
+	if err != nil || wait < 0 {
+		wait = defaultAnalyticsQueueTimeout
+	}
+	return &analyticsLimiter{slots: make(chan struct{}, concurrency), wait: wait}
+}
+
+// acquire takes a slot, queuing for up to the queue timeout, and reports
+// whether it got one. Callers that did must release it.
+func (l *analyticsLimiter) acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// release frees a slot taken with acquire
+func (l *analyticsLimiter) release() {
+	<-l.slots
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"form-builder-api/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRequiredFieldIDs(t *testing.T) {
+	fields := []models.FormField{
+		{ID: "name", Required: true},
+		{ID: "nickname"},
+		{ID: "reason", RequiredIf: []models.Condition{{FieldID: "name", Operator: "equals", Value: "x"}}},
+		{ID: "email", Required: true, RequiredUnless: []models.Condition{{FieldID: "name", Operator: "equals", Value: "x"}}},
+		{ID: "age", Required: true},
+	}
+	got := requiredFieldIDs(fields)
+	want := []string{"name", "age"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("requiredFieldIDs() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyticsOptionsUncounted(t *testing.T) {
+	incomplete := missingAnswers([]string{"name"})
+	tests := []struct {
+		name string
+		opts analyticsOptions
+		want bson.A
+	}{
+		{"including incomplete", analyticsOptions{IncludeIncomplete: true, RequiredFields: []string{"name"}}, uncountedResponses},
+		{"nothing required", analyticsOptions{}, uncountedResponses},
+		{"excluding incomplete", analyticsOptions{RequiredFields: []string{"name"}}, append(append(bson.A{}, uncountedResponses...), incomplete)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.uncounted(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("uncounted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	want := bson.M{"$or": bson.A{bson.M{"responses.name": bson.M{"$in": bson.A{nil, "", bson.A{}}}}}}
+	if !reflect.DeepEqual(incomplete, want) {
+		t.Errorf("missingAnswers() = %v, want %v", incomplete, want)
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultAnalyticsDebounce is how long submissions to a form are collected
+// before its analytics are recomputed, unless ANALYTICS_DEBOUNCE overrides it
+const defaultAnalyticsDebounce = 5 * time.Second
+
+// analyticsScheduler coalesces recompute requests per form so a burst of
+// submissions triggers at most one recompute per interval instead of one each
+type analyticsScheduler struct {
+	mu       sync.Mutex
+	pending  map[primitive.ObjectID]struct{}
+	interval time.Duration
+	run      func(primitive.ObjectID)
+}
+
+// newAnalyticsScheduler creates a scheduler that calls run for a form once
+// interval has passed since the first of its pending requests
+func newAnalyticsScheduler(interval time.Duration, run func(primitive.ObjectID)) *analyticsScheduler {
+	return &analyticsScheduler{
+		pending:  make(map[primitive.ObjectID]struct{}),
+		interval: interval,
+		run:      run,
+	}
+}
+
+// analyticsDebounce returns the configured recompute interval
+func analyticsDebounce() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("ANALYTICS_DEBOUNCE"))
+	if err != nil || interval <= 0 {
+		return defaultAnalyticsDebounce
+	}
+	return interval
+}
+
+// Schedule requests a recompute for the form. Requests arriving while one is
+// already pending are absorbed into it.
+func (s *analyticsScheduler) Schedule(formID primitive.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.pending[formID]; ok {
+		return
+	}
+	s.pending[formID] = struct{}{}
+
+	time.AfterFunc(s.interval, func() {
+		// Clear the entry first so submissions during the recompute schedule
+		// a fresh one rather than being lost
+		s.mu.Lock()
+		delete(s.pending, formID)
+		s.mu.Unlock()
+
+		s.run(formID)
+	})
+}
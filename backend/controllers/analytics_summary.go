@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxSummaryForms caps the forms one analytics summary request covers
+const maxSummaryForms = 100
+
+// summaryTrendDays is the number of days, today included, in summary trends
+const summaryTrendDays = 7
+
+// GetAnalyticsSummary returns a small slice of analytics for several forms
+// (?ids=a,b,c) for list views: the total responses, the responses of the
+// last 24 hours and daily counts for the last 7 days. All forms are counted
+// in one aggregation; IDs without responses report zeros.
+func (rc *ResponseController) GetAnalyticsSummary(c *fiber.Ctx) error {
+	var ids []primitive.ObjectID
+	seen := make(map[primitive.ObjectID]bool)
+	for _, id := range strings.Split(c.Query("ids"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID '" + id + "'"})
+		}
+		if !seen[objectID] {
+			seen[objectID] = true
+			ids = append(ids, objectID)
+		}
+	}
+	if len(ids) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ids must list at least one form ID"})
+	}
+	if len(ids) > maxSummaryForms {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("At most %d forms can be summarized at once", maxSummaryForms)})
+	}
+
+	// Days follow the same local-time boundaries as the full analytics trends
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	days := make([]time.Time, summaryTrendDays)
+	for i := range days {
+		days[i] = today.AddDate(0, 0, i-(summaryTrendDays-1))
+	}
+
+	group := bson.M{
+		"_id":   "$form_id",
+		"total": bson.M{"$sum": 1},
+		"last_24h": bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{"$created_at", now.Add(-24 * time.Hour)}}, 1, 0,
+		}}},
+	}
+	for i, day := range days {
+		group["day_"+strconv.Itoa(i)] = bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{
+				bson.M{"$gte": bson.A{"$created_at", day}},
+				bson.M{"$lt": bson.A{"$created_at", day.Add(24 * time.Hour)}},
+			}}, 1, 0,
+		}}}
+	}
+
+	ctx := context.Background()
+	uncounted, err := rc.summaryUncounted(ctx, ids)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to summarize analytics"})
+	}
+	cursor, err := rc.responseCollection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"form_id": bson.M{"$in": ids}, "$nor": uncounted}},
+		{"$group": group},
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to summarize analytics"})
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.M
+	if err := cursor.All(ctx, &results); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode analytics summary"})
+	}
+	byForm := make(map[primitive.ObjectID]bson.M, len(results))
+	for _, result := range results {
+		if formID, ok := result["_id"].(primitive.ObjectID); ok {
+			byForm[formID] = result
+		}
+	}
+
+	summaries := make([]fiber.Map, 0, len(ids))
+	for _, id := range ids {
+		result := byForm[id]
+		count := func(key string) int64 {
+			value, _ := models.AsFloat(result[key])
+			return int64(value)
+		}
+		trends := make([]fiber.Map, 0, len(days))
+		for i, day := range days {
+			trends = append(trends, fiber.Map{
+				"date":  day.Format("2006-01-02"),
+				"count": count("day_" + strconv.Itoa(i)),
+			})
+		}
+		summaries = append(summaries, fiber.Map{
+			"form_id":            id.Hex(),
+			"total_responses":    count("total"),
+			"responses_last_24h": count("last_24h"),
+			"response_trends":    trends,
+		})
+	}
+
+	return c.JSON(fiber.Map{"forms": summaries})
+}
+
+// summaryUncounted returns the "$nor" filters for responses the summary leaves
+// out, following each form's choice on counting incomplete responses like
+// the full analytics do
+func (rc *ResponseController) summaryUncounted(ctx context.Context, ids []primitive.ObjectID) (bson.A, error) {
+	cursor, err := rc.formCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"analytics_include_incomplete": 1, "fields": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var forms []models.Form
+	if err := cursor.All(ctx, &forms); err != nil {
+		return nil, err
+	}
+	uncounted := append(bson.A{}, uncountedResponses...)
+	for _, form := range forms {
+		opts := defaultAnalyticsOptions(form)
+		if opts.IncludeIncomplete || len(opts.RequiredFields) == 0 {
+			continue
+		}
+		incomplete := missingAnswers(opts.RequiredFields)
+		incomplete["form_id"] = form.ID
+		uncounted = append(uncounted, incomplete)
+	}
+	return uncounted, nil
+}
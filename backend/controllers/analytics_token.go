@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// embedRequestsPerMinute caps requests per analytics token
+const embedRequestsPerMinute = 60
+
+// embedLimiter counts requests per token in fixed one-minute windows. All
+// counts are dropped when a window ends, so memory stays bounded by the
+// tokens used within a minute.
+type embedLimiter struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+var analyticsEmbedLimiter = &embedLimiter{counts: make(map[string]int)}
+
+// Allow counts a request for key and reports whether it is within the limit
+func (l *embedLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.windowStart) >= time.Minute {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+	l.counts[key]++
+	return l.counts[key] <= embedRequestsPerMinute
+}
+
+// RotateAnalyticsToken issues a new read-only analytics token for the form,
+// replacing any previous one. The token is only returned here.
+func (fc *FormController) RotateAnalyticsToken(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	token := generateShareToken()
+	now := time.Now()
+	result, err := fc.collection.UpdateOne(context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{
+			"analytics_token_hash":       hashToken(token),
+			"analytics_token_created_at": now,
+		}},
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to rotate analytics token"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	recordAudit(c, "analytics_token_rotated", objectID, nil, nil)
+
+	return c.JSON(fiber.Map{
+		"analytics_token": token,
+		"created_at":      now,
+	})
+}
+
+// RevokeAnalyticsToken disables the form's analytics token
+func (fc *FormController) RevokeAnalyticsToken(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	result, err := fc.collection.UpdateOne(context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$unset": bson.M{
+			"analytics_token_hash":       "",
+			"analytics_token_created_at": "",
+		}},
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to revoke analytics token"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	recordAudit(c, "analytics_token_revoked", objectID, nil, nil)
+
+	return c.JSON(fiber.Map{"message": "Analytics token revoked"})
+}
+
+// GetEmbeddedAnalytics serves a form's analytics to holders of its analytics
+// token. It is read-only and prefers the cached analytics.
+func (rc *ResponseController) GetEmbeddedAnalytics(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if len(token) < 16 {
+		return c.Status(404).JSON(fiber.Map{"error": "Analytics not found"})
+	}
+	hash := hashToken(token)
+
+	if !analyticsEmbedLimiter.Allow(hash) {
+		return c.Status(429).JSON(fiber.Map{"error": "Too many requests for this analytics token"})
+	}
+
+	var form models.Form
+	err := rc.formCollection.FindOne(context.Background(), bson.M{"analytics_token_hash": hash}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Analytics not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	analytics, err := rc.cachedAnalytics(form)
+	if err != nil {
+		return sendAnalyticsError(c, err)
+	}
+
+	embeddable := make(map[string]bool, len(form.Fields))
+	for _, field := range form.Fields {
+		if isEmbeddableField(field) {
+			embeddable[field.ID] = true
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"form_id":    form.ID,
+		"title":      form.Title,
+		"updated_at": analytics.UpdatedAt,
+		"analytics":  publicAnalytics(analytics.FieldAnalytics, embeddable),
+	})
+}
+
+// isEmbeddableField reports whether a field's analytics may be shown on
+// embedded dashboards. Besides private fields, this leaves out fields whose
+// answers are written or picked freely by respondents, whose top values
+// would put individual answers on a third-party page.
+func isEmbeddableField(field models.FormField) bool {
+	if isPrivateField(field) {
+		return false
+	}
+	switch field.Type {
+	case models.FieldTypeText, models.FieldTypeTextarea, models.FieldTypeEmail,
+		models.FieldTypeLocation, models.FieldTypeFile:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"testing"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestIsEmbeddableField(t *testing.T) {
+	tests := []struct {
+		field models.FormField
+		want  bool
+	}{
+		{models.FormField{Type: models.FieldTypeMultipleChoice}, true},
+		{models.FormField{Type: models.FieldTypeRating}, true},
+		{models.FormField{Type: models.FieldTypeNumber}, true},
+		{models.FormField{Type: models.FieldTypeRating, Encrypted: true}, false},
+		{models.FormField{Type: models.FieldTypeHidden}, false},
+		{models.FormField{Type: models.FieldTypeText}, false},
+		{models.FormField{Type: models.FieldTypeTextarea}, false},
+		{models.FormField{Type: models.FieldTypeEmail}, false},
+		{models.FormField{Type: models.FieldTypeFile}, false},
+	}
+	for _, tt := range tests {
+		if got := isEmbeddableField(tt.field); got != tt.want {
+			t.Errorf("isEmbeddableField(%s, encrypted=%v) = %v, want %v", tt.field.Type, tt.field.Encrypted, got, tt.want)
+		}
+	}
+}
+
+func TestPublicAnalytics(t *testing.T) {
+	analytics := map[string]interface{}{
+		"total_responses": 3,
+		"field_analytics": []fiber.Map{
+			{"field_id": "rating", "average": 4.5},
+			{"field_id": "comment", "top_values": []string{"call me on 555-0100"}},
+		},
+		"field_drop_off": fiber.Map{
+			"fields": []fiber.Map{{"field_id": "rating"}, {"field_id": "comment"}},
+		},
+	}
+
+	result := publicAnalytics(analytics, map[string]bool{"rating": true})
+
+	if result["total_responses"] != 3 {
+		t.Errorf("total_responses = %v, want 3", result["total_responses"])
+	}
+	fields := result["field_analytics"].([]map[string]interface{})
+	if len(fields) != 1 || fields[0]["field_id"] != "rating" {
+		t.Errorf("field_analytics = %v, want only rating", fields)
+	}
+	dropOff := result["field_drop_off"].(fiber.Map)["fields"].([]map[string]interface{})
+	if len(dropOff) != 1 || dropOff[0]["field_id"] != "rating" {
+		t.Errorf("field_drop_off.fields = %v, want only rating", dropOff)
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// uncountedResponses matches responses left out of analytics and counts:
+// partial responses saved to finish later, and responses awaiting approval
+// or rejected by a moderator. Filters use it as "$nor": uncountedResponses.
+var uncountedResponses = bson.A{
+	bson.M{"incomplete": true},
+	bson.M{"approval": bson.M{"$in": bson.A{models.ApprovalPending, models.ApprovalRejected}}},
+}
+
+// initialApproval is the approval state a new response to the form starts in
+func initialApproval(form models.Form) models.ApprovalState {
+	if form.RequireApproval {
+		return models.ApprovalPending
+	}
+	return ""
+}
+
+// announcePending tells moderators a response is waiting for approval: over
+// WebSocket, through the form's webhook and to its notification targets
+func (rc *ResponseController) announcePending(form models.Form, response models.FormResponse) {
+	id := form.ID.Hex()
+	rc.hub.BroadcastToForm(id, "response_pending", fiber.Map{
+		"form_id":  id,
+		"response": redactEncrypted(form, response),
+	})
+	dispatchWebhook(form, "response_pending", &response.ID, fiber.Map{"response": redactEncrypted(form, response)})
+	notifySubmission(form, response, "response_pending")
+}
+
+// ApproveResponse approves or rejects a response to a form that requires
+// approval. Approved responses start counting in analytics; rejected ones
+// are kept but stay out of them. A decision can be revised later.
+func (rc *ResponseController) ApproveResponse(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var req models.ApprovalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	state := models.ApprovalApproved
+	if req.Decision == "reject" {
+		state = models.ApprovalRejected
+	}
+	now := time.Now()
+	set := bson.M{"approval": state, "approval_decided_at": now}
+	update := bson.M{"$set": set}
+	if req.Reason != "" {
+		set["approval_reason"] = sanitizeText(req.Reason)
+	} else {
+		update["$unset"] = bson.M{"approval_reason": ""}
+	}
+
+	// Only responses that went through moderation can be decided on
+	var response models.FormResponse
+	err = rc.responseCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": responseID, "form_id": objectID, "approval": bson.M{"$exists": true}},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found or not awaiting approval"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update response"})
+	}
+	decryptResponses(response.Responses)
+
+	event := "response_" + string(state)
+	recordAudit(c, event, objectID, &responseID, []string{"approval"})
+	rc.hub.BroadcastToForm(id, event, fiber.Map{
+		"form_id":  id,
+		"response": redactEncrypted(form, response),
+	})
+	dispatchWebhook(form, event, &responseID, fiber.Map{"response": redactEncrypted(form, response)})
+
+	// Approval changes what analytics count
+	rc.analytics.Schedule(objectID)
+
+	return c.JSON(response)
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// auditWriteAttempts bounds retries for a single audit entry
+const auditWriteAttempts = 3
+
+// auditIP returns the part of ip an audit entry for the form keeps, under the
+// form's IP storage mode like its responses (see storedIP). If the form's
+// mode can't be read, no address is kept.
+func auditIP(formID primitive.ObjectID, ip string) string {
+	if ip == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var form models.Form
+	err := database.GetCollection("forms").FindOne(ctx, bson.M{"_id": formID},
+		options.FindOne().SetProjection(bson.M{"ip_storage": 1})).Decode(&form)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return ""
+	}
+	mode := form.IPStorage
+	if mode == "" {
+		mode = serverIPStorage()
+	}
+	return applyIPStorage(mode, ip)
+}
+
+// recordAudit writes an audit entry in the background. Failures are retried
+// and logged but never block or fail the operation being audited.
+func recordAudit(c *fiber.Ctx, action string, formID primitive.ObjectID, responseID *primitive.ObjectID, changes []string) {
+	entry := models.AuditEntry{
+		ID:         primitive.NewObjectID(),
+		FormID:     formID,
+		ResponseID: responseID,
+		Action:     action,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}
+	if c != nil {
+		entry.IPAddress = c.IP()
+	}
+
+	go func() {
+		entry.IPAddress = auditIP(formID, entry.IPAddress)
+
+		collection := database.GetCollection("audit_log")
+		var err error
+		for attempt := 1; attempt <= auditWriteAttempts; attempt++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			_, err = collection.InsertOne(ctx, entry)
+			cancel()
+			if err == nil {
+				return
+			}
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		log.Printf("Failed to write audit entry %s for form %s: %v", action, formID.Hex(), err)
+	}()
+}
+
+// AuditController handles audit log access
+type AuditController struct {
+	collection *mongo.Collection
+}
+
+// NewAuditController creates a new audit controller
+func NewAuditController() *AuditController {
+	return &AuditController{
+		collection: database.GetCollection("audit_log"),
+	}
+}
+
+// GetAuditLog gets the audit trail for a form, newest first
+func (ac *AuditController) GetAuditLog(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	filter := bson.M{"form_id": objectID}
+
+	total, err := ac.collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to count audit entries"})
+	}
+
+	cursor, err := ac.collection.Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)).
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}),
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch audit entries"})
+	}
+	defer cursor.Close(context.Background())
+
+	var entries []models.AuditEntry
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode audit entries"})
+	}
+
+	if entries == nil {
+		entries = []models.AuditEntry{}
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
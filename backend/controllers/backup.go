@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backupFormat and backupVersion identify account backup archives in their
+// manifest; bump the version when the archive layout or the form and
+// response documents change incompatibly. Version 2 added the omitted list.
+const (
+	backupFormat  = "form-builder-backup"
+	backupVersion = 2
+)
+
+// backupOmitted lists what a backup archive leaves out, so an importer knows
+// the archive is lossy: write-only secrets, the contents of uploaded files
+// (answers keep only their metadata) and response data the API never returns
+var backupOmitted = []string{
+	"webhook_secret",
+	"analytics_token",
+	"uploaded_files",
+	"response_notes",
+	"response_history",
+	"original_answers",
+}
+
+// backupManifest describes the contents of a backup archive
+type backupManifest struct {
+	Format           string            `json:"format"`
+	Version          int               `json:"version"`
+	ExportedAt       time.Time         `json:"exported_at"`
+	IncludeResponses bool              `json:"include_responses"`
+	Omitted          []string          `json:"omitted"`
+	Forms            []backupFormEntry `json:"forms"`
+}
+
+// backupFormEntry lists one form of a backup archive and where its files are
+type backupFormEntry struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	File          string `json:"file"`
+	ResponsesFile string `json:"responses_file,omitempty"`
+	Responses     *int64 `json:"responses,omitempty"`
+}
+
+// ExportAllForms streams a ZIP archive of one owner's forms (?owner_slug=,
+// required), for backup or moving to another instance. Each form is stored
+// as forms/<id>.json and, with ?include_responses=true, its responses
+// (decrypted, as in NDJSON exports) as responses/<id>.ndjson. manifest.json
+// comes last and lists the forms with their response counts, and what the
+// archive leaves out (see backupOmitted).
+func (ec *ExportController) ExportAllForms(c *fiber.Ctx) error {
+	ownerSlug := c.Query("owner_slug")
+	if ownerSlug == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "owner_slug is required"})
+	}
+	includeResponses := c.QueryBool("include_responses")
+	filter := bson.M{"owner_slug": ownerSlug}
+
+	// Surface query errors before the status line is committed
+	cursor, err := ec.formCollection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch forms"})
+	}
+
+	exportedAt := time.Now().UTC()
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="forms-backup-`+exportedAt.Format("20060102-150405")+`.zip"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+		defer cursor.Close(ctx)
+
+		manifest := backupManifest{
+			Format:           backupFormat,
+			Version:          backupVersion,
+			ExportedAt:       exportedAt,
+			IncludeResponses: includeResponses,
+			Omitted:          backupOmitted,
+			Forms:            make([]backupFormEntry, 0),
+		}
+		archive := zip.NewWriter(w)
+		for cursor.Next(ctx) {
+			var form models.Form
+			if err := cursor.Decode(&form); err != nil {
+				log.Printf("Backup export aborted: %v", err)
+				return
+			}
+			entry, err := ec.writeBackupForm(ctx, archive, form, includeResponses)
+			if err != nil {
+				log.Printf("Backup export aborted at form %s: %v", form.ID.Hex(), err)
+				return
+			}
+			manifest.Forms = append(manifest.Forms, entry)
+		}
+		if err := cursor.Err(); err != nil {
+			log.Printf("Backup export aborted: %v", err)
+			return
+		}
+
+		file, err := archive.Create("manifest.json")
+		if err == nil {
+			err = writeIndentedJSON(file, manifest)
+		}
+		if err == nil {
+			err = archive.Close()
+		}
+		if err != nil {
+			log.Printf("Backup export aborted: %v", err)
+		}
+	})
+	return nil
+}
+
+// writeBackupForm adds a form, and its responses if asked, to a backup archive
+func (ec *ExportController) writeBackupForm(ctx context.Context, archive *zip.Writer, form models.Form, includeResponses bool) (backupFormEntry, error) {
+	entry := backupFormEntry{
+		ID:    form.ID.Hex(),
+		Title: form.Title,
+		File:  "forms/" + form.ID.Hex() + ".json",
+	}
+
+	file, err := archive.Create(entry.File)
+	if err != nil {
+		return entry, err
+	}
+	if err := writeIndentedJSON(file, form); err != nil {
+		return entry, err
+	}
+
+	if !includeResponses {
+		return entry, nil
+	}
+
+	entry.ResponsesFile = "responses/" + form.ID.Hex() + ".ndjson"
+	file, err = archive.Create(entry.ResponsesFile)
+	if err != nil {
+		return entry, err
+	}
+	cursor, err := ec.exportCursor(ctx, bson.M{"form_id": form.ID})
+	if err != nil {
+		return entry, err
+	}
+	defer cursor.Close(ctx)
+	rows, err := writeExport(ctx, file, cursor, form, exportFormatNDJSON)
+	entry.Responses = &rows
+	return entry, err
+}
+
+// writeIndentedJSON writes v as indented JSON, for archive files people may
+// read by hand
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"context"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BulkUpdateResponses sets the status and/or adds and removes tags on every
+// response matching a filter, in a single update
+func (rc *ResponseController) BulkUpdateResponses(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var req models.BulkUpdateResponsesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	update := req.Update
+	if update.Status == "" && len(update.AddTags) == 0 && len(update.RemoveTags) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Nothing to update: set status, add_tags or remove_tags"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	filter, err := buildResponseFilter(form, req.Filter.Answers, req.Filter.From, req.Filter.To)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if status := req.Filter.Status; status != "" {
+		if status == models.ResponseStatusNew {
+			filter["status"] = bson.M{"$in": bson.A{nil, status}}
+		} else {
+			filter["status"] = status
+		}
+	}
+
+	// An aggregation-pipeline update lets tags be added and removed in one pass
+	set := bson.M{}
+	changes := make([]string, 0, 2)
+	if update.Status != "" {
+		set["status"] = update.Status
+		changes = append(changes, "status")
+	}
+	if len(update.AddTags) > 0 || len(update.RemoveTags) > 0 {
+		addTags := update.AddTags
+		if addTags == nil {
+			addTags = []string{}
+		}
+		removeTags := update.RemoveTags
+		if removeTags == nil {
+			removeTags = []string{}
+		}
+		set["tags"] = bson.M{"$setDifference": bson.A{
+			bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$tags", bson.A{}}}, addTags}},
+			removeTags,
+		}}
+		changes = append(changes, "tags")
+	}
+
+	result, err := rc.responseCollection.UpdateMany(context.Background(), filter, bson.A{bson.M{"$set": set}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update responses"})
+	}
+
+	recordAudit(c, "responses_bulk_updated", objectID, nil, changes)
+
+	rc.hub.BroadcastToForm(id, "responses_updated", fiber.Map{
+		"form_id":        id,
+		"matched_count":  result.MatchedCount,
+		"modified_count": result.ModifiedCount,
+		"update":         update,
+	})
+
+	return c.JSON(fiber.Map{
+		"matched_count":  result.MatchedCount,
+		"modified_count": result.ModifiedCount,
+	})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Duplicate report limits: clusters per request and response IDs per cluster
+const (
+	defaultDuplicateClusters = 50
+	maxDuplicateClusters     = 200
+	maxClusterResponseIDs    = 20
+)
+
+// isRecentDuplicate reports whether a response with the same answer
+// fingerprint as response was submitted from its IP address (and User-Agent,
+// if the form asks) within the form's duplicate window. Addresses are
+// compared as stored (see storedIP), so truncated ones match any respondent
+// on the same network. Without a stored address there is nothing to tell
+// respondents apart by, so no response counts as a duplicate.
+func (rc *ResponseController) isRecentDuplicate(form models.Form, response models.FormResponse) (bool, error) {
+	if response.IPAddress == "" {
+		return false, nil
+	}
+
+	since := time.Now().Add(-time.Duration(form.DuplicateWindowMinutes) * time.Minute)
+	filter := bson.M{
+		"form_id":     form.ID,
+		"fingerprint": response.Fingerprint,
+		"incomplete":  completeResponses,
+		"created_at":  bson.M{"$gte": since},
+		"ip_address":  response.IPAddress,
+	}
+	if form.DuplicateMatchUserAgent {
+		// An empty User-Agent isn't stored, so match responses without one
+		if response.UserAgent == "" {
+			filter["user_agent"] = bson.M{"$in": bson.A{nil, ""}}
+		} else {
+			filter["user_agent"] = response.UserAgent
+		}
+	}
+	count, err := rc.responseCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetDuplicateResponses groups a form's responses with identical answers
+// (after normalization, see Form.AnswerFingerprint) and lists the groups with
+// more than one response, largest first. Responses stored before
+// fingerprints existed aren't matched.
+func (rc *ResponseController) GetDuplicateResponses(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultDuplicateClusters)))
+	if err != nil || limit < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid limit parameter"})
+	}
+	if limit > maxDuplicateClusters {
+		limit = maxDuplicateClusters
+	}
+
+	ctx := context.Background()
+	pipeline := []bson.M{
+		{"$match": bson.M{"form_id": objectID, "fingerprint": bson.M{"$type": "string"}}},
+		{"$sort": bson.M{"created_at": 1}},
+		{"$group": bson.M{
+			"_id":          "$fingerprint",
+			"count":        bson.M{"$sum": 1},
+			"response_ids": bson.M{"$push": "$_id"},
+			"ip_addresses": bson.M{"$addToSet": "$ip_address"},
+			"first_at":     bson.M{"$min": "$created_at"},
+			"last_at":      bson.M{"$max": "$created_at"},
+		}},
+		{"$match": bson.M{"count": bson.M{"$gt": 1}}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "last_at", Value: -1}}},
+		{"$limit": limit},
+		{"$project": bson.M{
+			"_id":          0,
+			"count":        1,
+			"response_ids": bson.M{"$slice": bson.A{"$response_ids", maxClusterResponseIDs}},
+			"distinct_ips": bson.M{"$size": "$ip_addresses"},
+			"first_at":     1,
+			"last_at":      1,
+		}},
+	}
+
+	cursor, err := rc.responseCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to find duplicate responses"})
+	}
+	defer cursor.Close(ctx)
+
+	clusters := []bson.M{}
+	if err := cursor.All(ctx, &clusters); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode duplicate responses"})
+	}
+
+	return c.JSON(fiber.Map{
+		"form_id":  id,
+		"clusters": clusters,
+	})
+}
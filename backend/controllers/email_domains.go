@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultDisposableDomains is used when DISPOSABLE_EMAIL_DOMAINS_FILE is not set
+var defaultDisposableDomains = []string{
+	"10minutemail.com",
+	"discard.email",
+	"dispostable.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"mintemail.com",
+	"sharklasers.com",
+	"temp-mail.org",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+var (
+	disposableDomains     map[string]bool
+	disposableDomainsMu   sync.RWMutex
+	disposableDomainsOnce sync.Once
+)
+
+// LoadDisposableDomains (re)loads the disposable email domain list from the
+// file named by DISPOSABLE_EMAIL_DOMAINS_FILE (one domain per line, '#' for
+// comments), falling back to the bundled list. It returns the number loaded.
+func LoadDisposableDomains() (int, error) {
+	domains := defaultDisposableDomains
+	if path := os.Getenv("DISPOSABLE_EMAIL_DOMAINS_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+
+		domains = nil
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				domains = append(domains, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	set := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		set[normalizeDomain(domain)] = true
+	}
+
+	disposableDomainsMu.Lock()
+	disposableDomains = set
+	disposableDomainsMu.Unlock()
+
+	return len(set), nil
+}
+
+// isDisposableDomain reports whether domain or a parent of it is disposable
+func isDisposableDomain(domain string) bool {
+	disposableDomainsOnce.Do(func() {
+		if _, err := LoadDisposableDomains(); err != nil {
+			log.Println("Error loading disposable email domains, using bundled list:", err)
+			set := make(map[string]bool, len(defaultDisposableDomains))
+			for _, d := range defaultDisposableDomains {
+				set[d] = true
+			}
+			disposableDomainsMu.Lock()
+			disposableDomains = set
+			disposableDomainsMu.Unlock()
+		}
+	})
+
+	disposableDomainsMu.RLock()
+	defer disposableDomainsMu.RUnlock()
+	for _, candidate := range domainAndParents(domain) {
+		if disposableDomains[candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDomain lowercases a domain and strips a leading "@" or "."
+func normalizeDomain(domain string) string {
+	return strings.TrimLeft(strings.ToLower(strings.TrimSpace(domain)), "@.")
+}
+
+// domainAndParents returns the domain followed by each parent domain, so
+// "a.b.example.com" yields itself, "b.example.com" and "example.com"
+func domainAndParents(domain string) []string {
+	domain = normalizeDomain(domain)
+	candidates := []string{domain}
+	for {
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+		if strings.Contains(domain, ".") {
+			candidates = append(candidates, domain)
+		}
+	}
+	return candidates
+}
+
+// domainInList reports whether domain, or a parent of it, is in list
+func domainInList(domain string, list []string) bool {
+	for _, candidate := range domainAndParents(domain) {
+		for _, entry := range list {
+			if candidate == normalizeDomain(entry) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkEmailDomain enforces an email field's domain allow and block lists
+func checkEmailDomain(email string, field models.FormField) error {
+	rules := field.Validation
+	if len(rules.AllowedEmailDomains) == 0 && len(rules.BlockedEmailDomains) == 0 && !rules.BlockDisposableEmails {
+		return nil
+	}
+
+	domain := email[strings.LastIndexByte(email, '@')+1:]
+
+	if len(rules.AllowedEmailDomains) > 0 && !domainInList(domain, rules.AllowedEmailDomains) {
+		return fiber.NewError(400, "Field '"+field.Label+"' only accepts addresses from: "+strings.Join(rules.AllowedEmailDomains, ", "))
+	}
+	if domainInList(domain, rules.BlockedEmailDomains) {
+		return fiber.NewError(400, "Email domain '"+domain+"' is not accepted for field '"+field.Label+"'")
+	}
+	if rules.BlockDisposableEmails && isDisposableDomain(domain) {
+		return fiber.NewError(400, "Disposable email addresses are not accepted for field '"+field.Label+"'")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"form-builder-api/models"
+)
+
+// encryptedValuePrefix marks answers stored as ciphertext
+const encryptedValuePrefix = "enc:v1:"
+
+var (
+	fieldCipher     cipher.AEAD
+	fieldCipherOnce sync.Once
+
+	errEncryptionUnavailable = errors.New("field encryption is not configured")
+)
+
+// getFieldCipher returns the AES-GCM cipher built from FIELD_ENCRYPTION_KEY
+// (base64-encoded 32-byte key), or nil when encryption is not configured
+func getFieldCipher() cipher.AEAD {
+	fieldCipherOnce.Do(func() {
+		encoded := os.Getenv("FIELD_ENCRYPTION_KEY")
+		if encoded == "" {
+			return
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != 32 {
+			log.Println("FIELD_ENCRYPTION_KEY must be a base64-encoded 32-byte key; field encryption disabled")
+			return
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			log.Println("Error initializing field encryption:", err)
+			return
+		}
+
+		fieldCipher, err = cipher.NewGCM(block)
+		if err != nil {
+			log.Println("Error initializing field encryption:", err)
+		}
+	})
+	return fieldCipher
+}
+
+// encryptValue encrypts a single answer into a prefixed base64 string
+func encryptValue(value interface{}) (string, error) {
+	aead := getFieldCipher()
+	if aead == nil {
+		return "", errEncryptionUnavailable
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue reverses encryptValue
+func decryptValue(value string) (interface{}, error) {
+	aead := getFieldCipher()
+	if aead == nil {
+		return nil, errEncryptionUnavailable
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedValuePrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(plaintext, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// encryptResponses returns a copy of the answers with every encrypted field's
+// value replaced by its ciphertext
+func encryptResponses(responses map[string]interface{}, fields []models.FormField) (map[string]interface{}, error) {
+	encrypted := make(map[string]interface{}, len(responses))
+	for key, value := range responses {
+		encrypted[key] = value
+	}
+
+	for _, field := range fields {
+		value, exists := encrypted[field.ID]
+		if !field.Encrypted || !exists || value == nil {
+			continue
+		}
+
+		ciphertext, err := encryptValue(value)
+		if err != nil {
+			return nil, err
+		}
+		encrypted[field.ID] = ciphertext
+	}
+
+	return encrypted, nil
+}
+
+// decryptResponses decrypts any encrypted answers in place. Values that can't
+// be decrypted (e.g. the key is not configured) are left as ciphertext.
+func decryptResponses(responses map[string]interface{}) {
+	for key, value := range responses {
+		str, ok := value.(string)
+		if !ok || !strings.HasPrefix(str, encryptedValuePrefix) {
+			continue
+		}
+		if decrypted, err := decryptValue(str); err == nil {
+			responses[key] = decrypted
+		}
+	}
+}
+
+// redactedAnswer stands in for answers to encrypted fields in anything that
+// leaves the server, such as webhook payloads and notifications
+const redactedAnswer = "[encrypted]"
+
+// redactEncrypted returns a copy of the response whose answers to encrypted
+// fields are replaced with redactedAnswer; the response itself is unchanged
+func redactEncrypted(form models.Form, response models.FormResponse) models.FormResponse {
+	redacted := make(map[string]interface{}, len(response.Responses))
+	for key, value := range response.Responses {
+		redacted[key] = value
+	}
+	for _, field := range form.Fields {
+		if _, exists := redacted[field.ID]; field.Encrypted && exists {
+			redacted[field.ID] = redactedAnswer
+		}
+	}
+	response.Responses = redacted
+	return response
+}
@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+	"form-builder-api/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	// exportAsyncThreshold is the response count above which storage exports
+	// run in the background instead of holding the request open
+	exportAsyncThreshold = 5000
+	// exportLinkTTL is how long presigned download links stay valid
+	exportLinkTTL = 24 * time.Hour
+)
+
+// exportReservedParams are export query parameters that aren't answer filters
+var exportReservedParams = []string{"format", "destination", "from", "to"}
+
+// ExportController handles response exports
+type ExportController struct {
+	responseCollection *mongo.Collection
+	formCollection     *mongo.Collection
+	jobCollection      *mongo.Collection
+	storage            storage.Storage
+}
+
+// NewExportController creates a new export controller
+func NewExportController() *ExportController {
+	return &ExportController{
+		responseCollection: database.GetCollection("responses"),
+		formCollection:     database.GetCollection("forms"),
+		jobCollection:      database.GetCollection("export_jobs"),
+		storage:            storage.Default(),
+	}
+}
+
+// ExportResponses exports a form's responses as CSV or NDJSON. By default the
+// file is streamed back; with destination=storage it is written to storage and
+// a presigned download URL is returned instead, in the background for big forms.
+func (ec *ExportController) ExportResponses(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	format := c.Query("format", exportFormatCSV)
+	if format != exportFormatCSV && format != exportFormatNDJSON {
+		return c.Status(400).JSON(fiber.Map{"error": "Format must be csv or ndjson"})
+	}
+
+	destination := c.Query("destination")
+	if destination != "" && destination != "storage" {
+		return c.Status(400).JSON(fiber.Map{"error": "Destination must be storage"})
+	}
+
+	var form models.Form
+	err = ec.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	answers := c.Queries()
+	from, to := answers["from"], answers["to"]
+	for _, param := range exportReservedParams {
+		delete(answers, param)
+	}
+
+	filter, err := buildResponseFilter(form, answers, from, to)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if destination == "" {
+		return ec.streamExport(c, form, filter, format)
+	}
+
+	if ec.storage == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Export storage is not configured"})
+	}
+
+	count, err := ec.responseCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to count responses"})
+	}
+
+	job := models.ExportJob{
+		ID:        primitive.NewObjectID(),
+		FormID:    objectID,
+		Format:    format,
+		Filters:   answers,
+		From:      from,
+		To:        to,
+		Status:    models.ExportJobPending,
+		CreatedAt: time.Now(),
+	}
+
+	if count > exportAsyncThreshold {
+		return ec.enqueueExportJob(c, job)
+	}
+
+	// Small exports run inline; the job is inserted as running so no worker claims it
+	started := job.CreatedAt
+	job.Status = models.ExportJobRunning
+	job.StartedAt = &started
+	job.HeartbeatAt = &started
+	if _, err := ec.jobCollection.InsertOne(context.Background(), job); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create export job"})
+	}
+
+	job = ec.runExportJob(job, form, filter)
+	if job.Status == models.ExportJobFailed {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to export responses", "job": job})
+	}
+	ec.presignJob(&job)
+
+	return c.JSON(fiber.Map{"job": job})
+}
+
+// streamExport writes the export directly into the response body
+func (ec *ExportController) streamExport(c *fiber.Ctx, form models.Form, filter bson.M, format string) error {
+	// Surface query errors before the status line is committed
+	cursor, err := ec.exportCursor(context.Background(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch responses"})
+	}
+
+	contentType := "text/csv"
+	if format == exportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+exportFilename(form.ID, format)+`"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cursor.Close(context.Background())
+		if _, err := writeExport(context.Background(), w, cursor, form, format); err != nil {
+			log.Printf("Export of form %s aborted: %v", form.ID.Hex(), err)
+		}
+	})
+	return nil
+}
+
+// runExportJob writes the export of a running job to storage and records the
+// outcome on the job
+func (ec *ExportController) runExportJob(job models.ExportJob, form models.Form, filter bson.M) models.ExportJob {
+	ctx := context.Background()
+
+	stop := ec.keepExportJobAlive(job.ID)
+	key := "export-" + job.ID.Hex() + "." + job.Format
+	rows, size, err := ec.exportToStorage(ctx, key, form, filter, job.Format)
+	stop()
+
+	completed := time.Now()
+	job.CompletedAt = &completed
+	update := bson.M{"completed_at": completed}
+	if err != nil {
+		log.Printf("Export job %s failed: %v", job.ID.Hex(), err)
+		job.Status = models.ExportJobFailed
+		job.Error = "Export failed"
+		update["error"] = job.Error
+	} else {
+		job.Status = models.ExportJobDone
+		job.StorageKey = key
+		job.Rows = rows
+		job.Size = size
+		update["storage_key"] = key
+		update["rows"] = rows
+		update["size"] = size
+	}
+	update["status"] = job.Status
+
+	if _, err := ec.jobCollection.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": update}); err != nil {
+		log.Printf("Failed to record outcome of export job %s: %v", job.ID.Hex(), err)
+	}
+	return job
+}
+
+// exportToStorage pipes the export straight into storage without buffering it
+func (ec *ExportController) exportToStorage(ctx context.Context, key string, form models.Form, filter bson.M, format string) (int64, int64, error) {
+	cursor, err := ec.exportCursor(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reader, writer := io.Pipe()
+	rowsCh := make(chan int64, 1)
+	go func() {
+		buffered := bufio.NewWriter(writer)
+		rows, err := writeExport(ctx, buffered, cursor, form, format)
+		if err == nil {
+			err = buffered.Flush()
+		}
+		rowsCh <- rows
+		writer.CloseWithError(err)
+	}()
+
+	size, err := ec.storage.Put(ctx, key, reader)
+	reader.CloseWithError(err)
+	rows := <-rowsCh
+	return rows, size, err
+}
+
+// exportCursor iterates matching responses oldest first
+func (ec *ExportController) exportCursor(ctx context.Context, filter bson.M) (*mongo.Cursor, error) {
+	return ec.responseCollection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+// writeExport writes every response from cursor to w and returns the row count
+func writeExport(ctx context.Context, w io.Writer, cursor *mongo.Cursor, form models.Form, format string) (int64, error) {
+	var rows int64
+
+	if format == exportFormatNDJSON {
+		encoder := json.NewEncoder(w)
+		for cursor.Next(ctx) {
+			var response models.FormResponse
+			if err := cursor.Decode(&response); err != nil {
+				return rows, err
+			}
+			decryptResponses(response.Responses)
+			if err := encoder.Encode(response); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+		return rows, cursor.Err()
+	}
+
+	writer := csv.NewWriter(w)
+	header := []string{"response_id", "submitted_at", "receipt_code"}
+	for _, field := range form.Fields {
+		// Location answers get a column per coordinate so spreadsheets can map them
+		if field.Type == models.FieldTypeLocation {
+			header = append(header, field.Label+" (lat)", field.Label+" (lng)", field.Label+" (address)")
+			continue
+		}
+		header = append(header, field.Label)
+	}
+	if err := writer.Write(header); err != nil {
+		return rows, err
+	}
+
+	for cursor.Next(ctx) {
+		var response models.FormResponse
+		if err := cursor.Decode(&response); err != nil {
+			return rows, err
+		}
+		decryptResponses(response.Responses)
+
+		record := []string{response.ID.Hex(), response.CreatedAt.UTC().Format(time.RFC3339), response.ReceiptCode}
+		for _, field := range form.Fields {
+			if field.Type == models.FieldTypeLocation {
+				record = append(record, formatLocationColumns(response.Responses[field.ID])...)
+				continue
+			}
+			record = append(record, formatExportValue(response.Responses[field.ID]))
+		}
+		if err := writer.Write(record); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	if err := cursor.Err(); err != nil {
+		return rows, err
+	}
+
+	writer.Flush()
+	return rows, writer.Error()
+}
+
+// formatExportValue renders an answer as a single CSV cell
+func formatExportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}, primitive.A:
+		values, _ := models.AsStringSlice(v)
+		return strings.Join(values, "; ")
+	default:
+		if location, ok := models.AsLocation(v); ok {
+			return strconv.FormatFloat(location.Lat(), 'f', -1, 64) + "," + strconv.FormatFloat(location.Lng(), 'f', -1, 64)
+		}
+		if file, ok := models.AsFileAnswer(v); ok {
+			return file.Name
+		}
+		return fmt.Sprint(v)
+	}
+}
+
+// formatLocationColumns renders a location answer as latitude, longitude and
+// address cells
+func formatLocationColumns(value interface{}) []string {
+	location, ok := models.AsLocation(value)
+	if !ok {
+		return []string{"", "", ""}
+	}
+	return []string{
+		strconv.FormatFloat(location.Lat(), 'f', -1, 64),
+		strconv.FormatFloat(location.Lng(), 'f', -1, 64),
+		location.Address,
+	}
+}
+
+// exportFilename names an export file for the form
+func exportFilename(formID primitive.ObjectID, format string) string {
+	return "responses-" + formID.Hex() + "." + format
+}
+
+// presignJob fills in the download link of a finished job
+func (ec *ExportController) presignJob(job *models.ExportJob) {
+	if job.Status != models.ExportJobDone || job.StorageKey == "" || ec.storage == nil {
+		return
+	}
+	url, expiresAt, err := ec.storage.PresignURL(job.StorageKey, exportLinkTTL)
+	if err != nil {
+		log.Printf("Failed to presign export %s: %v", job.ID.Hex(), err)
+		return
+	}
+	job.DownloadURL = url
+	job.ExpiresAt = &expiresAt
+}
+
+// GetExportJob gets the status of an export job, with a download link once done
+func (ec *ExportController) GetExportJob(c *fiber.Ctx) error {
+	formID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+	jobID, err := primitive.ObjectIDFromHex(c.Params("jobId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid export job ID"})
+	}
+
+	var job models.ExportJob
+	err = ec.jobCollection.FindOne(context.Background(), bson.M{"_id": jobID, "form_id": formID}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Export job not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch export job"})
+	}
+	ec.presignJob(&job)
+
+	return c.JSON(job)
+}
+
+// DownloadExport serves a stored export through a presigned link
+func (ec *ExportController) DownloadExport(c *fiber.Ctx) error {
+	local, ok := ec.storage.(*storage.LocalStorage)
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "Not found"})
+	}
+
+	key := c.Params("key")
+	if err := local.Verify(key, c.Query("expires"), c.Query("signature")); err != nil {
+		return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	file, err := local.Open(key)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Export not found"})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+key+`"`)
+	return c.SendStream(file)
+}
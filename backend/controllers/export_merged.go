@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mergedExport is a validated cross-form export: the column names, which of
+// them hold location answers and, per form, which field fills each column
+type mergedExport struct {
+	columns   []string
+	locations []bool
+	fields    map[primitive.ObjectID][]string
+}
+
+// ExportMergedResponses streams the responses of several forms as one CSV or
+// NDJSON file. Columns come from the request's mapping or, without one, from
+// fields that share a label across the forms.
+func (ec *ExportController) ExportMergedResponses(c *fiber.Ctx) error {
+	var req models.MergedExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	format := req.Format
+	if format == "" {
+		format = exportFormatCSV
+	}
+
+	formIDs := make([]primitive.ObjectID, 0, len(req.FormIDs))
+	seen := make(map[primitive.ObjectID]bool, len(req.FormIDs))
+	for _, id := range req.FormIDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID '" + id + "'"})
+		}
+		if !seen[objectID] {
+			seen[objectID] = true
+			formIDs = append(formIDs, objectID)
+		}
+	}
+
+	forms, err := ec.fetchForms(formIDs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch forms"})
+	}
+	for _, id := range formIDs {
+		if _, ok := forms[id]; !ok {
+			return c.Status(404).JSON(fiber.Map{"error": "Form '" + id.Hex() + "' not found"})
+		}
+	}
+
+	export, err := buildMergedExport(formIDs, forms, req.Columns)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	cursor, err := ec.exportCursor(context.Background(), bson.M{"form_id": bson.M{"$in": formIDs}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch responses"})
+	}
+
+	contentType := "text/csv"
+	if format == exportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="responses-merged.`+format+`"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cursor.Close(context.Background())
+		if _, err := writeMergedExport(context.Background(), w, cursor, export, format); err != nil {
+			log.Printf("Merged export aborted: %v", err)
+		}
+	})
+	return nil
+}
+
+// fetchForms loads the given forms by ID
+func (ec *ExportController) fetchForms(ids []primitive.ObjectID) (map[primitive.ObjectID]models.Form, error) {
+	ctx := context.Background()
+	cursor, err := ec.formCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var list []models.Form
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+
+	forms := make(map[primitive.ObjectID]models.Form, len(list))
+	for _, form := range list {
+		forms[form.ID] = form
+	}
+	return forms, nil
+}
+
+// buildMergedExport resolves the export columns. An explicit mapping must
+// only reference listed forms and their fields; forms a column doesn't map
+// leave it empty. Without one, a form's fields must have distinct labels.
+func buildMergedExport(formIDs []primitive.ObjectID, forms map[primitive.ObjectID]models.Form, columns []models.MergedExportColumn) (*mergedExport, error) {
+	export := &mergedExport{fields: make(map[primitive.ObjectID][]string, len(formIDs))}
+
+	if len(columns) == 0 {
+		// Align fields by label, in the order they first appear
+		index := make(map[string]int)
+		for _, id := range formIDs {
+			for _, field := range forms[id].Fields {
+				if _, ok := index[field.Label]; !ok {
+					index[field.Label] = len(export.columns)
+					export.columns = append(export.columns, field.Label)
+				}
+			}
+		}
+		for _, id := range formIDs {
+			mapped := make([]string, len(export.columns))
+			for _, field := range forms[id].Fields {
+				i := index[field.Label]
+				if mapped[i] != "" {
+					return nil, fiber.NewError(400, "Form '"+id.Hex()+"' has more than one field labelled '"+field.Label+"'; map the columns explicitly")
+				}
+				mapped[i] = field.ID
+			}
+			export.fields[id] = mapped
+		}
+		return export, markLocationColumns(export, forms)
+	}
+
+	for _, id := range formIDs {
+		export.fields[id] = make([]string, len(columns))
+	}
+	names := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		if names[column.Name] {
+			return nil, fiber.NewError(400, "Duplicate column '"+column.Name+"'")
+		}
+		names[column.Name] = true
+		export.columns = append(export.columns, column.Name)
+
+		for formID, fieldID := range column.Fields {
+			objectID, err := primitive.ObjectIDFromHex(formID)
+			if err != nil {
+				return nil, fiber.NewError(400, "Column '"+column.Name+"' maps invalid form ID '"+formID+"'")
+			}
+			mapped, ok := export.fields[objectID]
+			if !ok {
+				return nil, fiber.NewError(400, "Column '"+column.Name+"' maps form '"+formID+"', which is not part of the export")
+			}
+			if !hasField(forms[objectID].Fields, fieldID) {
+				return nil, fiber.NewError(400, "Column '"+column.Name+"' maps unknown field '"+fieldID+"' of form '"+formID+"'")
+			}
+			mapped[i] = fieldID
+		}
+	}
+
+	return export, markLocationColumns(export, forms)
+}
+
+// markLocationColumns flags the columns filled by location fields, which are
+// written as latitude, longitude and address cells like single-form exports.
+// A column can't mix location fields with other fields.
+func markLocationColumns(export *mergedExport, forms map[primitive.ObjectID]models.Form) error {
+	export.locations = make([]bool, len(export.columns))
+	for i, column := range export.columns {
+		var location, other bool
+		for formID, mapped := range export.fields {
+			if mapped[i] == "" {
+				continue
+			}
+			for _, field := range forms[formID].Fields {
+				if field.ID == mapped[i] {
+					location = location || field.Type == models.FieldTypeLocation
+					other = other || field.Type != models.FieldTypeLocation
+				}
+			}
+		}
+		if location && other {
+			return fiber.NewError(400, "Column '"+column+"' mixes location fields with other fields")
+		}
+		export.locations[i] = location
+	}
+	return nil
+}
+
+// hasField reports whether fields contains a field with the given ID
+func hasField(fields []models.FormField, fieldID string) bool {
+	for _, field := range fields {
+		if field.ID == fieldID {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMergedExport writes every response from cursor under the merged
+// columns and returns the row count
+func writeMergedExport(ctx context.Context, w io.Writer, cursor *mongo.Cursor, export *mergedExport, format string) (int64, error) {
+	var rows int64
+
+	var encoder *json.Encoder
+	var writer *csv.Writer
+	if format == exportFormatNDJSON {
+		encoder = json.NewEncoder(w)
+	} else {
+		writer = csv.NewWriter(w)
+		header := []string{"form_id", "response_id", "submitted_at", "receipt_code"}
+		for i, column := range export.columns {
+			if export.locations[i] {
+				header = append(header, column+" (lat)", column+" (lng)", column+" (address)")
+				continue
+			}
+			header = append(header, column)
+		}
+		if err := writer.Write(header); err != nil {
+			return rows, err
+		}
+	}
+
+	for cursor.Next(ctx) {
+		var response models.FormResponse
+		if err := cursor.Decode(&response); err != nil {
+			return rows, err
+		}
+		decryptResponses(response.Responses)
+		mapped := export.fields[response.FormID]
+
+		if encoder != nil {
+			answers := make(map[string]interface{}, len(export.columns))
+			for i, column := range export.columns {
+				if mapped[i] != "" {
+					answers[column] = response.Responses[mapped[i]]
+				}
+			}
+			err := encoder.Encode(fiber.Map{
+				"form_id":      response.FormID,
+				"response_id":  response.ID,
+				"submitted_at": response.CreatedAt,
+				"receipt_code": response.ReceiptCode,
+				"answers":      answers,
+			})
+			if err != nil {
+				return rows, err
+			}
+		} else {
+			record := []string{response.FormID.Hex(), response.ID.Hex(), response.CreatedAt.UTC().Format(time.RFC3339), response.ReceiptCode}
+			for i := range export.columns {
+				var value interface{}
+				if mapped[i] != "" {
+					value = response.Responses[mapped[i]]
+				}
+				if export.locations[i] {
+					record = append(record, formatLocationColumns(value)...)
+					continue
+				}
+				record = append(record, formatExportValue(value))
+			}
+			if err := writer.Write(record); err != nil {
+				return rows, err
+			}
+		}
+		rows++
+	}
+	if err := cursor.Err(); err != nil {
+		return rows, err
+	}
+
+	if writer != nil {
+		writer.Flush()
+		return rows, writer.Error()
+	}
+	return rows, nil
+}
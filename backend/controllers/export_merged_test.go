@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	"form-builder-api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBuildMergedExport(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	location := func(id, label string) models.FormField {
+		return models.FormField{ID: id, Label: label, Type: models.FieldTypeLocation}
+	}
+	text := func(id, label string) models.FormField {
+		return models.FormField{ID: id, Label: label, Type: models.FieldTypeText}
+	}
+
+	tests := []struct {
+		name          string
+		fields        [2][]models.FormField
+		columns       []models.MergedExportColumn
+		wantColumns   []string
+		wantLocations []bool
+		wantErr       bool
+	}{
+		{
+			name:          "aligned by label",
+			fields:        [2][]models.FormField{{text("name", "Name"), location("where", "Where")}, {location("place", "Where")}},
+			wantColumns:   []string{"Name", "Where"},
+			wantLocations: []bool{false, true},
+		},
+		{
+			name:    "duplicate label in one form",
+			fields:  [2][]models.FormField{{text("first", "Name"), text("second", "Name")}, {text("name", "Name")}},
+			wantErr: true,
+		},
+		{
+			name:    "label mixing location and text",
+			fields:  [2][]models.FormField{{location("where", "Where")}, {text("where", "Where")}},
+			wantErr: true,
+		},
+		{
+			name:   "explicit location column",
+			fields: [2][]models.FormField{{location("where", "Where")}, {location("place", "Place")}},
+			columns: []models.MergedExportColumn{
+				{Name: "Location", Fields: map[string]string{a.Hex(): "where", b.Hex(): "place"}},
+			},
+			wantColumns:   []string{"Location"},
+			wantLocations: []bool{true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forms := map[primitive.ObjectID]models.Form{
+				a: {ID: a, Fields: tt.fields[0]},
+				b: {ID: b, Fields: tt.fields[1]},
+			}
+			export, err := buildMergedExport([]primitive.ObjectID{a, b}, forms, tt.columns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildMergedExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(export.columns, tt.wantColumns) {
+				t.Errorf("buildMergedExport() columns = %v, want %v", export.columns, tt.wantColumns)
+			}
+			if !reflect.DeepEqual(export.locations, tt.wantLocations) {
+				t.Errorf("buildMergedExport() locations = %v, want %v", export.locations, tt.wantLocations)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultExportWorkers caps concurrently running export jobs unless
+	// EXPORT_WORKERS says otherwise
+	defaultExportWorkers = 2
+	// exportPollInterval is how often idle workers look for jobs enqueued by
+	// other instances or left pending across a restart
+	exportPollInterval = 5 * time.Second
+	// exportHeartbeatInterval is how often a running job records it is alive
+	exportHeartbeatInterval = 30 * time.Second
+	// exportJobStaleAfter is how long a running job may go without a
+	// heartbeat before it is taken for abandoned and requeued
+	exportJobStaleAfter = 4 * exportHeartbeatInterval
+)
+
+// exportWake nudges idle workers when a job is enqueued in this process
+var exportWake = make(chan struct{}, 1)
+
+// ExportWorkers returns the configured number of export workers
+func ExportWorkers() int {
+	workers, err := strconv.Atoi(os.Getenv("EXPORT_WORKERS"))
+	if err != nil || workers < 1 {
+		return defaultExportWorkers
+	}
+	return workers
+}
+
+// CreateExport enqueues an export job for a form's responses
+func (ec *ExportController) CreateExport(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var req models.CreateExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Format == "" {
+		req.Format = exportFormatCSV
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if ec.storage == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Export storage is not configured"})
+	}
+
+	var form models.Form
+	err = ec.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	// Reject bad filters now rather than failing the job later
+	if _, err := buildResponseFilter(form, req.Filters, req.From, req.To); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ec.enqueueExportJob(c, models.ExportJob{
+		ID:        primitive.NewObjectID(),
+		FormID:    objectID,
+		Format:    req.Format,
+		Filters:   req.Filters,
+		From:      req.From,
+		To:        req.To,
+		Status:    models.ExportJobPending,
+		CreatedAt: time.Now(),
+	})
+}
+
+// enqueueExportJob persists a pending job for the workers and answers 202
+func (ec *ExportController) enqueueExportJob(c *fiber.Ctx, job models.ExportJob) error {
+	if _, err := ec.jobCollection.InsertOne(context.Background(), job); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create export job"})
+	}
+
+	select {
+	case exportWake <- struct{}{}:
+	default:
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"job":        job,
+		"status_url": "/api/v1/forms/" + job.FormID.Hex() + "/exports/" + job.ID.Hex(),
+	})
+}
+
+// StartExportWorkers starts workers goroutines that process pending export
+// jobs one at a time each, and requeues running jobs whose instance stopped
+// sending heartbeats, e.g. because it was restarted or crashed
+func (ec *ExportController) StartExportWorkers(workers int) {
+	go func() {
+		ticker := time.NewTicker(exportJobStaleAfter / 2)
+		defer ticker.Stop()
+		for {
+			ec.requeueStaleExportJobs()
+			<-ticker.C
+		}
+	}()
+
+	for i := 0; i < workers; i++ {
+		go ec.exportWorker()
+	}
+}
+
+// requeueStaleExportJobs puts running jobs that stopped sending heartbeats
+// back to pending. Jobs another instance is still running keep theirs fresh
+// and are left alone.
+func (ec *ExportController) requeueStaleExportJobs() {
+	cutoff := time.Now().Add(-exportJobStaleAfter)
+	result, err := ec.jobCollection.UpdateMany(context.Background(),
+		bson.M{
+			"status": models.ExportJobRunning,
+			"$or": bson.A{
+				bson.M{"heartbeat_at": bson.M{"$lt": cutoff}},
+				// Jobs started before heartbeats were recorded
+				bson.M{"heartbeat_at": bson.M{"$exists": false}, "started_at": bson.M{"$lt": cutoff}},
+			},
+		},
+		bson.M{
+			"$set":   bson.M{"status": models.ExportJobPending},
+			"$unset": bson.M{"started_at": "", "heartbeat_at": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Failed to requeue abandoned export jobs: %v", err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Requeued %d abandoned export jobs", result.ModifiedCount)
+		select {
+		case exportWake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// keepExportJobAlive refreshes a running job's heartbeat until the returned
+// function is called
+func (ec *ExportController) keepExportJobAlive(jobID primitive.ObjectID) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(exportHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				_, err := ec.jobCollection.UpdateOne(context.Background(),
+					bson.M{"_id": jobID, "status": models.ExportJobRunning},
+					bson.M{"$set": bson.M{"heartbeat_at": now}})
+				if err != nil {
+					log.Printf("Failed to record heartbeat of export job %s: %v", jobID.Hex(), err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// exportWorker processes pending jobs until none are left, then waits to be
+// woken or for the next poll
+func (ec *ExportController) exportWorker() {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			job, err := ec.claimExportJob()
+			if err != nil {
+				if err != mongo.ErrNoDocuments {
+					log.Printf("Failed to claim export job: %v", err)
+				}
+				break
+			}
+			ec.processExportJob(job)
+		}
+
+		select {
+		case <-exportWake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimExportJob atomically marks the oldest pending job as running
+func (ec *ExportController) claimExportJob() (models.ExportJob, error) {
+	var job models.ExportJob
+	now := time.Now()
+	err := ec.jobCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"status": models.ExportJobPending},
+		bson.M{"$set": bson.M{"status": models.ExportJobRunning, "started_at": now, "heartbeat_at": now}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	return job, err
+}
+
+// processExportJob rebuilds the job's filter from the current form and runs it
+func (ec *ExportController) processExportJob(job models.ExportJob) {
+	var form models.Form
+	err := ec.formCollection.FindOne(context.Background(), bson.M{"_id": job.FormID}).Decode(&form)
+	if err == nil {
+		var filter bson.M
+		filter, err = buildResponseFilter(form, job.Filters, job.From, job.To)
+		if err == nil {
+			ec.runExportJob(job, form, filter)
+			return
+		}
+	}
+
+	log.Printf("Export job %s failed: %v", job.ID.Hex(), err)
+	ec.jobCollection.UpdateOne(context.Background(), bson.M{"_id": job.ID}, bson.M{"$set": bson.M{
+		"status":       models.ExportJobFailed,
+		"error":        "Export failed",
+		"completed_at": time.Now(),
+	}})
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Field timings outside this range are treated as noise: sub-second values
+// are tab-throughs, and very long ones are abandoned or backgrounded tabs.
+const (
+	minFieldTiming = 500 * time.Millisecond
+	maxFieldTiming = 30 * time.Minute
+)
+
+// maxFieldTimingSamples caps the stored samples per field; only the most
+// recent ones are kept
+const maxFieldTimingSamples = 1000
+
+// RecordFieldTiming stores a focus-to-blur duration reported by a respondent.
+// Only published forms and fields they define are accepted.
+func (rc *ResponseController) RecordFieldTiming(formID, fieldID string, duration time.Duration) {
+	if duration < minFieldTiming || duration > maxFieldTiming {
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(formID)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := rc.formCollection.CountDocuments(ctx, bson.M{
+		"_id":          objectID,
+		"is_published": true,
+		"fields.id":    fieldID,
+	})
+	if err != nil || count == 0 {
+		return
+	}
+
+	_, err = rc.timingCollection.UpdateOne(ctx,
+		bson.M{"form_id": objectID, "field_id": fieldID},
+		bson.M{
+			"$push": bson.M{"samples": bson.M{
+				"$each":  []int64{duration.Milliseconds()},
+				"$slice": -maxFieldTimingSamples,
+			}},
+			"$inc": bson.M{"count": 1},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Failed to record field timing for form %s field %s: %v", formID, fieldID, err)
+	}
+}
+
+// fieldTimings summarizes the stored timing samples of a form by field ID
+func (rc *ResponseController) fieldTimings(ctx context.Context, formID primitive.ObjectID) (map[string]fiber.Map, error) {
+	cursor, err := rc.timingCollection.Find(ctx, bson.M{"form_id": formID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var docs []models.FieldTiming
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	timings := make(map[string]fiber.Map, len(docs))
+	for _, doc := range docs {
+		if len(doc.Samples) == 0 {
+			continue
+		}
+
+		samples := append([]int64(nil), doc.Samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		var sum int64
+		for _, sample := range samples {
+			sum += sample
+		}
+
+		median := float64(samples[len(samples)/2])
+		if len(samples)%2 == 0 {
+			median = float64(samples[len(samples)/2-1]+samples[len(samples)/2]) / 2
+		}
+
+		timings[doc.FieldID] = fiber.Map{
+			"average_ms": float64(sum) / float64(len(samples)),
+			"median_ms":  median,
+			"samples":    len(samples),
+			"events":     doc.Count,
+		}
+	}
+
+	return timings, nil
+}
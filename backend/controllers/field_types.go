@@ -0,0 +1,409 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// FieldTypeHandler implements what is specific to a field type: checking
+// answers and adding type-specific analytics. Either function may be nil.
+type FieldTypeHandler struct {
+	// Validate checks a submitted answer, which is never nil. It returns the
+	// value to store, which may be a normalized form of the answer. required
+	// says whether the field must be answered in this submission.
+	Validate func(field models.FormField, value interface{}, required bool) (interface{}, error)
+	// Analytics adds metrics to the field's analytics entry, which already
+	// holds the response and skip rates
+	Analytics func(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error
+	// ObjectAnswers lets answers be objects rather than single values or
+	// lists of values; Validate must then check their contents
+	ObjectAnswers bool
+}
+
+// FieldAnalyticsContext is what a field type's analytics are computed from
+type FieldAnalyticsContext struct {
+	// Responses is the responses collection; answers to the field are stored
+	// under responses.<field id>
+	Responses *mongo.Collection
+	FormID    primitive.ObjectID
+	Field     models.FormField
+	// TotalResponses counts the form's responses, Answered those answering the field
+	TotalResponses int
+	Answered       int64
+	// TopN is the number of most common answers requested, or 0 for the default
+	TopN int
+	// Uncounted matches responses the analytics leave out; queries over the
+	// field's answers filter with "$nor": Uncounted
+	Uncounted bson.A
+
+	rc   *ResponseController
+	opts analyticsOptions
+}
+
+// topNOr returns the requested number of common answers, or def
+func (a FieldAnalyticsContext) topNOr(def int) int {
+	if a.TopN > 0 {
+		return a.TopN
+	}
+	return def
+}
+
+var (
+	fieldTypesMu sync.RWMutex
+	fieldTypes   map[models.FieldType]FieldTypeHandler
+)
+
+// The built-in types are registered in init because their analytics look
+// handlers up again (see answeredPredicate)
+func init() {
+	fieldTypes = map[models.FieldType]FieldTypeHandler{
+		models.FieldTypeText:           {Validate: validateTextAnswer, Analytics: commonTextAnalytics},
+		models.FieldTypeTextarea:       {Validate: validateTextAnswer, Analytics: commonTextAnalytics},
+		models.FieldTypeEmail:          {Validate: validateEmailAnswer, Analytics: commonTextAnalytics},
+		models.FieldTypeNumber:         {Validate: validateNumberAnswer},
+		models.FieldTypeMultipleChoice: {Analytics: choiceAnalytics},
+		models.FieldTypeCheckbox:       {Analytics: choiceAnalytics},
+		models.FieldTypeRating:         {Validate: validateRatingAnswer, Analytics: ratingAnalytics},
+		models.FieldTypeDate:           {},
+		models.FieldTypeHidden:         {Validate: validateHiddenAnswer, Analytics: commonTextAnalytics},
+		models.FieldTypeConsent:        {Validate: validateConsentAnswer, Analytics: consentAnalytics},
+		models.FieldTypeLocation:       {Validate: validateLocationAnswer, Analytics: locationFieldAnalytics, ObjectAnswers: true},
+		models.FieldTypeFile:           {Validate: validateFileAnswer, ObjectAnswers: true},
+	}
+}
+
+// RegisterFieldType adds a custom field type, or replaces how a built-in one
+// is validated and analyzed. Register types at startup, before the server
+// accepts requests. Other behavior (exports, schemas, display hints) treats
+// custom types like plain values.
+func RegisterFieldType(fieldType models.FieldType, handler FieldTypeHandler) {
+	fieldTypesMu.Lock()
+	defer fieldTypesMu.Unlock()
+	fieldTypes[fieldType] = handler
+}
+
+// fieldTypeHandler returns the handler registered for a field type
+func fieldTypeHandler(fieldType models.FieldType) (FieldTypeHandler, bool) {
+	fieldTypesMu.RLock()
+	defer fieldTypesMu.RUnlock()
+	handler, ok := fieldTypes[fieldType]
+	return handler, ok
+}
+
+// answeredPredicate matches the stored answers to a field that count as
+// answered in analytics. Missing, null and empty string answers don't, nor do
+// empty lists (a checkbox with nothing selected) or, for types that take
+// objects, empty objects. 0 and false are answers like any other value.
+func answeredPredicate(field models.FormField) bson.M {
+	empty := bson.A{nil, "", bson.A{}}
+	if handler, ok := fieldTypeHandler(field.Type); ok && handler.ObjectAnswers {
+		empty = append(empty, bson.M{})
+	}
+	return bson.M{"$exists": true, "$nin": empty}
+}
+
+func validateEmailAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	if str, ok := value.(string); ok && str != "" {
+		// Basic email validation
+		if !isValidEmail(str) {
+			return nil, fiber.NewError(400, "Invalid email format for field '"+field.Label+"'")
+		}
+		if err := checkEmailDomain(str, field); err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
+
+func validateNumberAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	if num, ok := value.(float64); ok {
+		if math.IsNaN(num) || math.IsInf(num, 0) {
+			return nil, fiber.NewError(400, "Value for field '"+field.Label+"' must be a number")
+		}
+		if field.Validation.Min != 0 && num < field.Validation.Min {
+			return nil, fiber.NewError(400, "Value too low for field '"+field.Label+"'")
+		}
+		if field.Validation.Max != 0 && num > field.Validation.Max {
+			return nil, fiber.NewError(400, "Value too high for field '"+field.Label+"'")
+		}
+	}
+	return value, nil
+}
+
+func validateTextAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	if str, ok := value.(string); ok {
+		// Lengths are counted in characters, matching the client-side counter
+		length := utf8.RuneCountInString(str)
+		if field.Validation.MinLength > 0 && length < field.Validation.MinLength {
+			return nil, fiber.NewError(400, "Text too short for field '"+field.Label+"'")
+		}
+		if maxLength := field.EffectiveMaxLength(); maxLength > 0 && length > maxLength {
+			return nil, fiber.NewError(400, "Text too long for field '"+field.Label+"'")
+		}
+	}
+	return value, nil
+}
+
+func validateRatingAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	// Ratings sent as strings or integers are checked like numbers
+	parsed := value
+	if str, ok := value.(string); ok {
+		if parsed = strings.TrimSpace(str); parsed == "" {
+			return value, nil
+		}
+	}
+	num, ok := models.AsFloat(parsed)
+	if !ok || math.IsNaN(num) || math.IsInf(num, 0) {
+		return nil, fiber.NewError(400, "Rating must be a number for field '"+field.Label+"'")
+	}
+	if num < 1 || num > 5 {
+		return nil, fiber.NewError(400, "Rating must be between 1 and 5 for field '"+field.Label+"'")
+	}
+	// Stored as a number so the rating average and distribution count it
+	return num, nil
+}
+
+func validateConsentAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	accepted, ok := value.(bool)
+	if !ok {
+		return nil, fiber.NewError(400, "Value for consent field '"+field.Label+"' must be true or false")
+	}
+	if required && !accepted {
+		return nil, fiber.NewError(400, "You must accept '"+field.Label+"' to submit this form")
+	}
+	return value, nil
+}
+
+func validateLocationAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	location, ok := models.AsLocation(value)
+	if !ok {
+		return nil, fiber.NewError(400, "Value for location field '"+field.Label+"' must have a numeric lat and lng")
+	}
+	if location.Lat() < -90 || location.Lat() > 90 || location.Lng() < -180 || location.Lng() > 180 {
+		return nil, fiber.NewError(400, "Coordinates out of range for location field '"+field.Label+"'")
+	}
+	if utf8.RuneCountInString(location.Address) > maxLocationAddressLength {
+		return nil, fiber.NewError(400, "Address too long for location field '"+field.Label+"'")
+	}
+	// Stored as a GeoJSON Point whatever shape was submitted
+	return location, nil
+}
+
+func validateHiddenAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, fiber.NewError(400, "Value for hidden field '"+field.Label+"' must be a string")
+	}
+	if utf8.RuneCountInString(str) > field.EffectiveMaxLength() {
+		return nil, fiber.NewError(400, "Value too long for hidden field '"+field.Label+"'")
+	}
+	if field.Validation.Pattern != "" && str != "" {
+		if matched, err := regexp.MatchString(field.Validation.Pattern, str); err != nil || !matched {
+			return nil, fiber.NewError(400, "Invalid value for hidden field '"+field.Label+"'")
+		}
+	}
+	return value, nil
+}
+
+// consentAnalytics reports the acceptance rate across all responses
+func consentAnalytics(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error {
+	acceptedCount, err := a.Responses.CountDocuments(ctx, bson.M{
+		"form_id":                 a.FormID,
+		"$nor":                    a.Uncounted,
+		"responses." + a.Field.ID: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	acceptanceRate := float64(0)
+	if a.TotalResponses > 0 {
+		acceptanceRate = float64(acceptedCount) / float64(a.TotalResponses) * 100
+	}
+	result["accepted_count"] = acceptedCount
+	result["acceptance_rate"] = acceptanceRate
+	return nil
+}
+
+// choiceAnalytics reports the distribution of chosen options and, for scored
+// fields, the score
+func choiceAnalytics(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"form_id":                 a.FormID,
+			"$nor":                    a.Uncounted,
+			"responses." + a.Field.ID: answeredPredicate(a.Field),
+		}},
+		{"$project": bson.M{
+			"value": "$responses." + a.Field.ID,
+		}},
+		{"$group": bson.M{
+			"_id":   "$value",
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": a.topNOr(defaultChoiceTopN)},
+	}
+
+	cursor, err := a.Responses.Aggregate(ctx, pipeline)
+	if err == nil {
+		var choiceResults []bson.M
+		cursor.All(ctx, &choiceResults)
+		cursor.Close(context.Background())
+
+		commonResponses := make([]fiber.Map, 0)
+		for _, choice := range choiceResults {
+			if choice["_id"] != nil {
+				count, _ := models.AsFloat(choice["count"])
+				percentage := count / float64(a.Answered) * 100
+				commonResponses = append(commonResponses, fiber.Map{
+					"value":      choice["_id"],
+					"count":      choice["count"],
+					"percentage": percentage,
+				})
+			}
+		}
+		result["common_responses"] = commonResponses
+		result["unique_responses"] = len(choiceResults)
+	}
+
+	if a.Field.Scored() {
+		score, err := a.rc.fieldScore(ctx, a.FormID, a.Field, a.Answered, a.Uncounted)
+		if err != nil {
+			return err
+		}
+		result["score"] = score
+	}
+	return nil
+}
+
+// ratingAnalytics reports the average rating and how often each rating was given
+func ratingAnalytics(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"form_id":                 a.FormID,
+			"$nor":                    a.Uncounted,
+			"responses." + a.Field.ID: answeredPredicate(a.Field),
+		}},
+		{"$group": bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": "$responses." + a.Field.ID},
+			"ratings": bson.M{"$push": "$responses." + a.Field.ID},
+		}},
+	}
+
+	cursor, err := a.Responses.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil
+	}
+	var ratingResults []bson.M
+	cursor.All(ctx, &ratingResults)
+	cursor.Close(context.Background())
+
+	if len(ratingResults) == 0 {
+		return nil
+	}
+	if avg, ok := ratingResults[0]["average"]; ok && avg != nil {
+		result["average_rating"] = avg
+	}
+
+	// Calculate rating distribution
+	if ratings, ok := ratingResults[0]["ratings"].(primitive.A); ok {
+		distribution := make(map[int]int)
+		for _, rating := range ratings {
+			if r, ok := models.AsFloat(rating); ok {
+				distribution[int(r)]++
+			}
+		}
+
+		commonResponses := make([]fiber.Map, 0)
+		for rating := 1; rating <= 5; rating++ {
+			count := distribution[rating]
+			if count > 0 {
+				percentage := float64(count) / float64(len(ratings)) * 100
+				commonResponses = append(commonResponses, fiber.Map{
+					"value":      rating,
+					"count":      count,
+					"percentage": percentage,
+				})
+			}
+		}
+		result["common_responses"] = commonResponses
+	}
+	return nil
+}
+
+// locationFieldAnalytics reports answer counts per region and recent points
+func locationFieldAnalytics(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error {
+	regions, points, err := a.rc.locationAnalytics(ctx, a.FormID, a.Field, a.Answered, a.opts)
+	if err != nil {
+		return err
+	}
+	result["regions"] = regions
+	result["points"] = points
+	return nil
+}
+
+// commonTextAnalytics reports the most common text answers
+func commonTextAnalytics(ctx context.Context, a FieldAnalyticsContext, result fiber.Map) error {
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"form_id":                 a.FormID,
+			"$nor":                    a.Uncounted,
+			"responses." + a.Field.ID: answeredPredicate(a.Field),
+		}},
+		{"$project": bson.M{
+			"value": "$responses." + a.Field.ID,
+		}},
+		{"$group": bson.M{
+			"_id":   "$value",
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$limit": a.topNOr(defaultTextTopN)},
+	}
+
+	cursor, err := a.Responses.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil
+	}
+	var textResults []bson.M
+	cursor.All(ctx, &textResults)
+	cursor.Close(context.Background())
+
+	commonResponses := make([]fiber.Map, 0)
+	for _, text := range textResults {
+		if text["_id"] != nil {
+			count, _ := models.AsFloat(text["count"])
+			percentage := count / float64(a.Answered) * 100
+			valueStr := ""
+			if str, ok := models.AsString(text["_id"]); ok {
+				// Truncate long text responses
+				if len(str) > 50 {
+					valueStr = str[:47] + "..."
+				} else {
+					valueStr = str
+				}
+			}
+			commonResponses = append(commonResponses, fiber.Map{
+				"value":      valueStr,
+				"count":      text["count"],
+				"percentage": percentage,
+			})
+		}
+	}
+	result["common_responses"] = commonResponses
+	result["unique_responses"] = len(textResults)
+	return nil
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"math"
+	"testing"
+
+	"form-builder-api/models"
+)
+
+func TestValidateRatingAnswer(t *testing.T) {
+	field := models.FormField{ID: "stars", Label: "Stars", Type: models.FieldTypeRating}
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"string", "4", 4.0, false},
+		{"padded string", " 4 ", 4.0, false},
+		{"integer", 4, 4.0, false},
+		{"float", 4.0, 4.0, false},
+		{"empty string", "", "", false},
+		{"too high", 6, nil, true},
+		{"too low", "0", nil, true},
+		{"not a number", "four", nil, true},
+		{"NaN", "NaN", nil, true},
+		{"infinity", "Inf", nil, true},
+		{"negative infinity", "-Infinity", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateRatingAnswer(field, tt.value, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateRatingAnswer(%#v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("validateRatingAnswer(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateNumberAnswer(t *testing.T) {
+	field := models.FormField{ID: "age", Label: "Age", Type: models.FieldTypeNumber}
+	field.Validation.Min = 1
+	field.Validation.Max = 120
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"in range", 30.0, false},
+		{"too low", 0.5, true},
+		{"too high", 121.0, true},
+		{"NaN", math.NaN(), true},
+		{"infinity", math.Inf(1), true},
+		{"negative infinity", math.Inf(-1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validateNumberAnswer(field, tt.value, false); (err != nil) != tt.wantErr {
+				t.Errorf("validateNumberAnswer(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"strconv"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// buildResponseFilter builds a Mongo filter for a form's responses from
+// field-value pairs (ANDed together) and an optional from/to date range.
+// Unknown field IDs are rejected so typos don't silently match nothing.
+func buildResponseFilter(form models.Form, answers map[string]string, from, to string) (bson.M, error) {
+	filter := bson.M{"form_id": form.ID}
+
+	known := make(map[string]bool, len(form.Fields))
+	for _, field := range form.Fields {
+		known[field.ID] = true
+	}
+
+	for fieldID, value := range answers {
+		if !known[fieldID] {
+			return nil, fiber.NewError(400, "Unknown field '"+fieldID+"'")
+		}
+		filter["responses."+fieldID] = bson.M{"$in": answerMatchValues(value)}
+	}
+
+	createdAt := bson.M{}
+	if from != "" {
+		start, _, err := parseDateParam(from)
+		if err != nil {
+			return nil, fiber.NewError(400, "Invalid from date")
+		}
+		createdAt["$gte"] = start
+	}
+	if to != "" {
+		end, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return nil, fiber.NewError(400, "Invalid to date")
+		}
+		if dateOnly {
+			// A plain date includes the whole day
+			createdAt["$lt"] = end.Add(24 * time.Hour)
+		} else {
+			createdAt["$lte"] = end
+		}
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	return filter, nil
+}
+
+// answerMatchValues returns the stored values a query-string value may
+// correspond to, since answers keep their JSON types (numbers, booleans)
+func answerMatchValues(value string) []interface{} {
+	values := []interface{}{value}
+	if num, err := strconv.ParseFloat(value, 64); err == nil {
+		values = append(values, num)
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		values = append(values, b)
+	}
+	return values
+}
+
+// parseDateParam parses an RFC 3339 timestamp or a plain YYYY-MM-DD date
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
@@ -1,22 +1,35 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"form-builder-api/auth"
 	"form-builder-api/database"
 	"form-builder-api/models"
 	"form-builder-api/websocket"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var validate = validator.New()
@@ -42,9 +55,487 @@ func generateShareToken() string {
 	return hex.EncodeToString(bytes)
 }
 
+// generateShortID generates a short random identifier for fields and options
+func generateShortID() string {
+	bytes := make([]byte, 5)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+// maxFieldOptions caps the number of options a choice field may define
+const maxFieldOptions = 100
+
+// maxOptionScore bounds option scores in either direction
+const maxOptionScore = 1e6
+
+// Form text limits, in characters. They mirror the request validator tags and
+// are checked again on every write path so partial updates and server-built
+// titles (e.g. duplicates) can't exceed them.
+const (
+	maxFormTitleLength       = 200
+	maxFormDescriptionLength = 1000
+)
+
+// validateFormTitle rejects blank or over-length titles
+func validateFormTitle(title string) error {
+	if strings.TrimSpace(title) == "" {
+		return fiber.NewError(400, "Title is required")
+	}
+	if utf8.RuneCountInString(title) > maxFormTitleLength {
+		return fiber.NewError(400, fmt.Sprintf("Title must be at most %d characters", maxFormTitleLength))
+	}
+	return nil
+}
+
+// validateFormDescription rejects over-length descriptions
+func validateFormDescription(description string) error {
+	if utf8.RuneCountInString(description) > maxFormDescriptionLength {
+		return fiber.NewError(400, fmt.Sprintf("Description must be at most %d characters", maxFormDescriptionLength))
+	}
+	return nil
+}
+
+// copyTitle builds the title of a duplicated form, trimming the original so
+// the suffix still fits within the title limit
+func copyTitle(title string) string {
+	const suffix = " (Copy)"
+	runes := []rune(title)
+	if limit := maxFormTitleLength - utf8.RuneCountInString(suffix); len(runes) > limit {
+		runes = runes[:limit]
+	}
+	return string(runes) + suffix
+}
+
+// validateFormFields validates field definitions before they are saved and
+// fills in server-generated values such as missing field and option IDs
+func validateFormFields(fields []models.FormField) error {
+	if err := assignFieldIDs(fields); err != nil {
+		return err
+	}
+
+	for i := range fields {
+		field := &fields[i]
+
+		if err := sanitizeFieldText(field); err != nil {
+			return err
+		}
+
+		if field.Order < 0 {
+			return fiber.NewError(400, "Field '"+field.Label+"' has a negative order")
+		}
+
+		if err := checkFieldFlags(*field); err != nil {
+			return err
+		}
+
+		for _, conditions := range [][]models.Condition{field.RequiredIf, field.RequiredUnless} {
+			for _, condition := range conditions {
+				if condition.FieldID == field.ID {
+					return fiber.NewError(400, "Field '"+field.Label+"' can't be required based on its own answer")
+				}
+			}
+			if err := validateConditions(conditions, fields); err != nil {
+				return err
+			}
+		}
+
+		if err := validateTransforms(*field); err != nil {
+			return err
+		}
+
+		if field.Encrypted && getFieldCipher() == nil {
+			return fiber.NewError(400, "Field '"+field.Label+"' is marked encrypted but field encryption is not configured")
+		}
+
+		switch field.Type {
+		case models.FieldTypeHidden:
+			// Respondents can't fill hidden fields, so they must never block a submission
+			field.Required = false
+			field.Validation.Required = false
+			field.RequiredIf = nil
+			field.RequiredUnless = nil
+			if field.Validation.Pattern != "" {
+				if _, err := regexp.Compile(field.Validation.Pattern); err != nil {
+					return fiber.NewError(400, "Invalid pattern for field '"+field.Label+"'")
+				}
+			}
+		case models.FieldTypeConsent:
+			if field.ConsentText == "" {
+				return fiber.NewError(400, "Consent field '"+field.Label+"' needs consent text")
+			}
+			if field.ConsentURL != "" {
+				if u, err := url.Parse(field.ConsentURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fiber.NewError(400, "Invalid consent link for field '"+field.Label+"'")
+				}
+			}
+		case models.FieldTypeFile:
+			if field.Validation.MaxFileSize < 0 {
+				return fiber.NewError(400, "Field '"+field.Label+"' has a negative maximum file size")
+			}
+			for _, mimeType := range field.Validation.AllowedMimeTypes {
+				if !mimeTypePattern.MatchString(strings.ToLower(strings.TrimSpace(mimeType))) {
+					return fiber.NewError(400, "Invalid MIME type '"+mimeType+"' for field '"+field.Label+"'")
+				}
+			}
+		case models.FieldTypeMultipleChoice, models.FieldTypeCheckbox:
+			if field.Required && len(field.Options) == 0 {
+				return fiber.NewError(400, "Field '"+field.Label+"' is required but has no options")
+			}
+			if len(field.Options) > maxFieldOptions {
+				return fiber.NewError(400, fmt.Sprintf("Field '%s' has too many options (max %d)", field.Label, maxFieldOptions))
+			}
+
+			scored := field.Scored()
+			seen := make(map[string]bool, len(field.Options))
+			for j := range field.Options {
+				option := &field.Options[j]
+				if scored && option.Score == nil {
+					return fiber.NewError(400, fmt.Sprintf("Option %d of field '%s' needs a score because other options have one", j+1, field.Label))
+				}
+				if option.Score != nil && math.Abs(*option.Score) > maxOptionScore {
+					return fiber.NewError(400, fmt.Sprintf("Option %d of field '%s' has a score outside ±%g", j+1, field.Label, float64(maxOptionScore)))
+				}
+				if option.Value == "" {
+					return fiber.NewError(400, fmt.Sprintf("Option %d of field '%s' has an empty value", j+1, field.Label))
+				}
+				if seen[option.Value] {
+					return fiber.NewError(400, fmt.Sprintf("Field '%s' has duplicate option value '%s'", field.Label, option.Value))
+				}
+				seen[option.Value] = true
+
+				if option.ID == "" {
+					option.ID = generateShortID()
+				}
+			}
+		}
+	}
+
+	normalizeFieldOrder(fields)
+	return nil
+}
+
+// mimeTypePattern matches the media types a file field may accept: exact
+// types, or a type followed by /* for all of its subtypes
+var mimeTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9!#$&^_.+-]*/(\*|[a-z0-9][a-z0-9!#$&^_.+-]*)$`)
+
+// maxGeneratedFieldIDLength bounds the label-derived part of generated field IDs
+const maxGeneratedFieldIDLength = 40
+
+// fieldIDPattern is the alphabet of field IDs, the one generated IDs use.
+// Answers are stored under responses.<field_id>, so characters such as '.'
+// or '$' would break those paths.
+var fieldIDPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// assignFieldIDs gives fields sent without an ID one derived from their label
+// (e.g. "Email address" becomes email_address), numbered when it's already
+// taken, so answers can be keyed by it. Explicit IDs must be unique and use
+// only lowercase letters, digits and underscores.
+func assignFieldIDs(fields []models.FormField) error {
+	taken := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field.ID == "" {
+			continue
+		}
+		if !fieldIDPattern.MatchString(field.ID) {
+			return fiber.NewError(400, "Field ID '"+field.ID+"' may only contain lowercase letters, digits and underscores")
+		}
+		if taken[field.ID] {
+			return fiber.NewError(400, "Duplicate field ID '"+field.ID+"'")
+		}
+		taken[field.ID] = true
+	}
+
+	for i := range fields {
+		if fields[i].ID != "" {
+			continue
+		}
+		base := strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(fields[i].Label), "_"), "_")
+		if len(base) > maxGeneratedFieldIDLength {
+			base = strings.TrimRight(base[:maxGeneratedFieldIDLength], "_")
+		}
+		if base == "" {
+			base = "field"
+		}
+		id := base
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%s_%d", base, n)
+		}
+		fields[i].ID = id
+		taken[id] = true
+	}
+	return nil
+}
+
+// validateTransforms checks a field's answer transforms
+func validateTransforms(field models.FormField) error {
+	if len(field.Transforms) == 0 {
+		return nil
+	}
+	if !models.TransformsText(field.Type) {
+		return fiber.NewError(400, "Field '"+field.Label+"' doesn't take text answers, so they can't be transformed")
+	}
+	seen := make(map[models.AnswerTransform]bool, len(field.Transforms))
+	for _, transform := range field.Transforms {
+		if !models.KnownAnswerTransform(transform) {
+			return fiber.NewError(400, "Unknown transform '"+string(transform)+"' on field '"+field.Label+"'")
+		}
+		if seen[transform] {
+			return fiber.NewError(400, "Transform '"+string(transform)+"' is listed twice on field '"+field.Label+"'")
+		}
+		seen[transform] = true
+	}
+	if seen[models.TransformLowercase] && seen[models.TransformUppercase] {
+		return fiber.NewError(400, "Field '"+field.Label+"' can't be both lowercased and uppercased")
+	}
+	return nil
+}
+
+// checkFieldOptionsJSON strictly decodes the options of each field in a JSON
+// form body. A malformed options list otherwise only fails the whole body
+// with a generic error, or worse, half-decodes; this names the field and
+// option at fault. Bodies that aren't JSON are left to BodyParser.
+func checkFieldOptionsJSON(c *fiber.Ctx) error {
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return nil
+	}
+
+	var body struct {
+		Fields []struct {
+			ID      string          `json:"id"`
+			Label   string          `json:"label"`
+			Options json.RawMessage `json:"options"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return nil
+	}
+
+	for i, field := range body.Fields {
+		name := field.Label
+		if name == "" {
+			name = field.ID
+		}
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		if len(field.Options) == 0 || string(field.Options) == "null" {
+			continue
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(field.Options, &items); err != nil {
+			return fiber.NewError(400, "Options of field '"+name+"' must be a list of objects")
+		}
+		for j, item := range items {
+			decoder := json.NewDecoder(bytes.NewReader(item))
+			decoder.DisallowUnknownFields()
+			var option models.FieldOption
+			if err := decoder.Decode(&option); err != nil {
+				return fiber.NewError(400, fmt.Sprintf("Option %d of field '%s' is malformed: expected an object with string \"label\" and \"value\" and an optional numeric \"score\"", j+1, name))
+			}
+		}
+	}
+	return nil
+}
+
+// checkFieldFlags rejects flag combinations respondents can't satisfy, such
+// as required fields they have no way to answer, which would make the form
+// impossible to submit
+func checkFieldFlags(field models.FormField) error {
+	// A read-only choice can only ever hold its default, so it must be a valid option
+	if field.Type == models.FieldTypeMultipleChoice && field.ReadOnly {
+		if value, ok := field.DefaultValue.(string); ok && value != "" && !hasOptionValue(field.Options, value) {
+			return fiber.NewError(400, "Default value of read-only field '"+field.Label+"' is not one of its options")
+		}
+	}
+
+	if !field.Required && !field.Validation.Required && len(field.RequiredIf) == 0 && len(field.RequiredUnless) == 0 {
+		return nil
+	}
+
+	// Respondents can't fill hidden, disabled or read-only fields, so a
+	// required one needs a default for the form to be submittable
+	switch {
+	case field.Type == models.FieldTypeHidden && isEmptyAnswer(field.DefaultValue):
+		return fiber.NewError(400, "Field '"+field.Label+"' is hidden and required but has no default value")
+	case field.Disabled:
+		return fiber.NewError(400, "Field '"+field.Label+"' is disabled and can't be required")
+	case field.ReadOnly && isEmptyAnswer(field.DefaultValue):
+		return fiber.NewError(400, "Field '"+field.Label+"' is read-only and required but has no default value")
+	}
+
+	return nil
+}
+
+// hasOptionValue reports whether value is one of the options' values
+func hasOptionValue(options []models.FieldOption, value string) bool {
+	for _, option := range options {
+		if option.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFieldOrder sorts fields by their client-supplied order, keeping
+// array position for ties, and renumbers them 0..n-1 so stored orders always
+// match array position
+func normalizeFieldOrder(fields []models.FormField) {
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].Order < fields[j].Order })
+	for i := range fields {
+		fields[i].Order = i
+	}
+}
+
+// validateAtLeastOneGroups checks that every group lists existing fields
+func validateAtLeastOneGroups(groups []models.AtLeastOneGroup, fields []models.FormField) error {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.ID] = true
+	}
+
+	for i, group := range groups {
+		name := group.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(group.FieldIDs) < 2 {
+			return fiber.NewError(400, "Field group '"+name+"' must list at least two fields")
+		}
+		for _, fieldID := range group.FieldIDs {
+			if !known[fieldID] {
+				return fiber.NewError(400, "Field group '"+name+"' references unknown field '"+fieldID+"'")
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateSections checks that section IDs are unique and that fields only
+// reference existing sections, filling in missing section IDs
+func validateSections(sections []models.FormSection, fields []models.FormField) error {
+	known := make(map[string]bool, len(sections))
+	for i := range sections {
+		section := &sections[i]
+		section.Title = sanitizeText(section.Title)
+		if section.ID == "" {
+			section.ID = generateShortID()
+		}
+		if section.ID == defaultSectionID {
+			return fiber.NewError(400, "Section ID '"+defaultSectionID+"' is reserved")
+		}
+		if known[section.ID] {
+			return fiber.NewError(400, "Duplicate section ID '"+section.ID+"'")
+		}
+		known[section.ID] = true
+	}
+
+	for _, field := range fields {
+		if field.SectionID != "" && !known[field.SectionID] {
+			return fiber.NewError(400, "Field '"+field.Label+"' references unknown section '"+field.SectionID+"'")
+		}
+	}
+
+	return nil
+}
+
+var (
+	slugPattern      = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// slugify derives a URL slug from a form title
+func slugify(title string) string {
+	slug := strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(title), "-"), "-")
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	if slug == "" {
+		slug = "form"
+	}
+	return slug
+}
+
+// slugTaken reports whether another form of the owner already uses the slug
+func (fc *FormController) slugTaken(ownerSlug, slug string, excludeID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"owner_slug": ownerSlug, "slug": slug}
+	if ownerSlug == "" {
+		// Forms without an owner omit the field entirely
+		filter["owner_slug"] = bson.M{"$in": []interface{}{nil, ""}}
+	}
+	if !excludeID.IsZero() {
+		filter["_id"] = bson.M{"$ne": excludeID}
+	}
+	count, err := fc.collection.CountDocuments(context.Background(), filter)
+	return count > 0, err
+}
+
+// uniqueSlug generates a slug from the title that is free for the owner
+func (fc *FormController) uniqueSlug(ownerSlug, title string) (string, error) {
+	base := slugify(title)
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := fc.slugTaken(ownerSlug, slug, primitive.NilObjectID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// shareTokenAttempts bounds how often a colliding share token is regenerated
+const shareTokenAttempts = 5
+
+// uniqueShareToken generates a share token no other form uses. Collisions
+// are practically impossible, but two forms sharing a token would make the
+// share link resolve to either of them.
+func (fc *FormController) uniqueShareToken() (string, error) {
+	for attempt := 0; attempt < shareTokenAttempts; attempt++ {
+		token := generateShareToken()
+		count, err := fc.collection.CountDocuments(context.Background(), bson.M{"share_token": token}, options.Count().SetLimit(1))
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return token, nil
+		}
+		log.Println("Share token collision, generating another")
+	}
+	return "", fmt.Errorf("no free share token after %d attempts", shareTokenAttempts)
+}
+
+// resolveSlug validates a requested slug, or generates one from the title when
+// none was given
+func (fc *FormController) resolveSlug(ownerSlug, slug, title string, formID primitive.ObjectID) (string, error) {
+	if slug == "" {
+		return fc.uniqueSlug(ownerSlug, title)
+	}
+
+	if !slugPattern.MatchString(slug) {
+		return "", fiber.NewError(400, "Slug may only contain lowercase letters, digits and single dashes")
+	}
+
+	taken, err := fc.slugTaken(ownerSlug, slug, formID)
+	if err != nil {
+		return "", err
+	}
+	if taken {
+		return "", fiber.NewError(409, "Slug '"+slug+"' is already in use")
+	}
+
+	return slug, nil
+}
+
 // CreateForm creates a new form
 func (fc *FormController) CreateForm(c *fiber.Ctx) error {
 	var req models.CreateFormRequest
+	if err := checkFieldOptionsJSON(c); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
@@ -53,15 +544,112 @@ func (fc *FormController) CreateForm(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	req.Title = sanitizeText(req.Title)
+	req.Description = sanitizeText(req.Description)
+	if err := validateFormTitle(req.Title); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateFormDescription(req.Description); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateFormFields(req.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateAtLeastOneGroups(req.RequireAtLeastOne, req.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateSections(req.Sections, req.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateNotificationRules(req.NotificationRules, req.DefaultNotificationTargets, req.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateMilestoneRules(req.MilestoneRules); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateMetadataSchema(req.MetadataSchema); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateWebhookTransform(req.WebhookTransform); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if req.WebhookURL != "" && !isHTTPURL(req.WebhookURL) {
+		return c.Status(400).JSON(fiber.Map{"error": "Webhook URL must be an absolute http(s) URL"})
+	}
+	if req.WebhookURL != "" && !isPublicURL(req.WebhookURL) {
+		return c.Status(400).JSON(fiber.Map{"error": "Webhook URL must point to a public address"})
+	}
+
+	if req.RedirectURL != "" && !isHTTPURL(req.RedirectURL) {
+		return c.Status(400).JSON(fiber.Map{"error": "Redirect URL must be an absolute http(s) URL"})
+	}
+	req.ThankYouMessage = sanitizeText(req.ThankYouMessage)
+	req.IntroContent = sanitizeContentBlock(req.IntroContent)
+	req.OutroContent = sanitizeContentBlock(req.OutroContent)
+
+	if req.RequireAuth && !auth.Enabled() {
+		return c.Status(400).JSON(fiber.Map{"error": "Form requires sign-in but authentication is not configured"})
+	}
+
+	if req.OwnerSlug != "" && !slugPattern.MatchString(req.OwnerSlug) {
+		return c.Status(400).JSON(fiber.Map{"error": "Owner slug may only contain lowercase letters, digits and single dashes"})
+	}
+
+	slug, err := fc.resolveSlug(req.OwnerSlug, req.Slug, req.Title, primitive.NilObjectID)
+	if err != nil {
+		if e, ok := err.(*fiber.Error); ok {
+			return c.Status(e.Code).JSON(fiber.Map{"error": e.Message})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create form"})
+	}
+
+	shareToken, err := fc.uniqueShareToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create form"})
+	}
+
 	form := models.Form{
 		ID:          primitive.NewObjectID(),
 		Title:       req.Title,
 		Description: req.Description,
 		Fields:      req.Fields,
+		Sections:    req.Sections,
 		IsPublished: false,
-		ShareToken:  generateShareToken(),
+		ShareToken:  shareToken,
+		OwnerSlug:   req.OwnerSlug,
+		Slug:        slug,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		RequireAtLeastOne:       req.RequireAtLeastOne,
+		EditWindowMinutes:       req.EditWindowMinutes,
+		DuplicateWindowMinutes:  req.DuplicateWindowMinutes,
+		IPStorage:               req.IPStorage,
+		DuplicateMatchUserAgent: req.DuplicateMatchUserAgent,
+		WebhookURL:              req.WebhookURL,
+		WebhookSecret:           req.WebhookSecret,
+		WebhookTransform:        req.WebhookTransform,
+		WebhookOrdered:          req.WebhookOrdered,
+
+		NotificationRules:          req.NotificationRules,
+		MilestoneRules:             req.MilestoneRules,
+		DefaultNotificationTargets: req.DefaultNotificationTargets,
+		MetadataSchema:             req.MetadataSchema,
+		RequireAuth:                req.RequireAuth,
+		RequireInvite:              req.RequireInvite,
+		RequireApproval:            req.RequireApproval,
+		AnalyticsIncludeIncomplete: req.AnalyticsIncludeIncomplete,
+		RedirectURL:                req.RedirectURL,
+		ThankYouMessage:            req.ThankYouMessage,
+		IntroContent:               req.IntroContent,
+		OutroContent:               req.OutroContent,
 	}
 
 	result, err := fc.collection.InsertOne(context.Background(), form)
@@ -71,6 +659,8 @@ func (fc *FormController) CreateForm(c *fiber.Ctx) error {
 
 	form.ID = result.InsertedID.(primitive.ObjectID)
 
+	recordAudit(c, "form_created", form.ID, nil, nil)
+
 	// Broadcast form creation
 	fc.hub.BroadcastGeneral("form_created", form)
 
@@ -114,16 +704,215 @@ func (fc *FormController) GetForm(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
 	}
 
-	return c.JSON(form)
+	form.Hints = form.DisplayHints()
+	return sendFormWithValidators(c, form)
 }
 
+// BatchGetForms fetches several forms in one query. Forms are returned in the
+// requested order; IDs that match no form are listed under missing.
+func (fc *FormController) BatchGetForms(c *fiber.Ctx) error {
+	var req models.BatchGetFormsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID '" + id + "'"})
+		}
+		ids = append(ids, objectID)
+	}
+
+	ctx := context.Background()
+	cursor, err := fc.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch forms"})
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Form
+	if err := cursor.All(ctx, &found); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode forms"})
+	}
+	byID := make(map[primitive.ObjectID]models.Form, len(found))
+	for _, form := range found {
+		byID[form.ID] = form
+	}
+
+	forms := make([]models.Form, 0, len(found))
+	missing := []string{}
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	for i, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		form, ok := byID[id]
+		if !ok {
+			missing = append(missing, req.IDs[i])
+			continue
+		}
+		form.Hints = form.DisplayHints()
+		forms = append(forms, form)
+	}
+
+	return c.JSON(fiber.Map{
+		"forms":   forms,
+		"missing": missing,
+	})
+}
+
+// sendFormWithValidators writes the form with ETag/Last-Modified headers and
+// answers conditional requests with 304 Not Modified when nothing changed.
+// The ETag hashes the serialized form, so every update (including field
+// reorders and publish toggles) produces a new one.
+func sendFormWithValidators(c *fiber.Ctx, form models.Form) error {
+	body, err := json.Marshal(form)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to encode form"})
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := form.UpdatedAt.UTC().Truncate(time.Second)
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+		if etagMatches(match, etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	} else if since := c.Get(fiber.HeaderIfModifiedSince); since != "" {
+		if t, err := http.ParseTime(since); err == nil && !lastModified.After(t) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldTypeChanges lists the fields, matched by ID, whose type differs
+// between two versions of a form's fields together with how many responses
+// answer each, and the number of responses answering any of them
+func fieldTypeChanges(formID primitive.ObjectID, before, after []models.FormField) ([]fiber.Map, int64, error) {
+	previous := make(map[string]models.FormField, len(before))
+	for _, field := range before {
+		previous[field.ID] = field
+	}
+
+	responses := database.GetCollection("responses")
+	changes := []fiber.Map{}
+	answered := bson.A{}
+	for _, field := range after {
+		old, ok := previous[field.ID]
+		if !ok || old.Type == field.Type {
+			continue
+		}
+		answer := bson.M{"responses." + field.ID: bson.M{"$exists": true, "$ne": nil}}
+		count, err := responses.CountDocuments(context.Background(), bson.M{"form_id": formID, "$and": bson.A{answer}})
+		if err != nil {
+			return nil, 0, err
+		}
+		if count == 0 {
+			continue
+		}
+		changes = append(changes, fiber.Map{
+			"field_id":           field.ID,
+			"label":              field.Label,
+			"from":               old.Type,
+			"to":                 field.Type,
+			"affected_responses": count,
+		})
+		answered = append(answered, answer)
+	}
+	if len(answered) == 0 {
+		return changes, 0, nil
+	}
+
+	total, err := responses.CountDocuments(context.Background(), bson.M{"form_id": formID, "$or": answered})
+	if err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+// stripOwnerSettings clears integration settings from a form served to
+// respondents; they're only meant for the form's owner
+func stripOwnerSettings(form *models.Form) {
+	form.WebhookURL = ""
+	form.WebhookTransform = nil
+	form.WebhookOrdered = false
+	form.NotificationRules = nil
+	form.DefaultNotificationTargets = nil
+	form.MilestoneRules = nil
+	form.FiredMilestones = nil
+	form.AnalyticsTokenCreatedAt = nil
+}
+
+// shareTokenOrder decides which form a share token resolves to should two
+// forms ever share one: the oldest
+var shareTokenOrder = bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+
 // GetFormByToken gets a form by its share token
 func (fc *FormController) GetFormByToken(c *fiber.Ctx) error {
 	token := c.Params("token")
 
+	// Tokens should be unique; if two forms ever share one, the oldest
+	// consistently wins and the clash is logged
+	ctx := context.Background()
+	cursor, err := fc.collection.Find(ctx, bson.M{
+		"share_token":  token,
+		"is_published": true,
+	}, options.Find().SetSort(shareTokenOrder).SetLimit(2))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	defer cursor.Close(ctx)
+
+	var matches []models.Form
+	if err := cursor.All(ctx, &matches); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	if len(matches) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found or not published"})
+	}
+	if len(matches) > 1 {
+		log.Printf("Share token is used by several forms, including %s and %s; serving %s", matches[0].ID.Hex(), matches[1].ID.Hex(), matches[0].ID.Hex())
+	}
+	form := matches[0]
+
+	stripOwnerSettings(&form)
+	form.Hints = form.DisplayHints()
+	return sendFormWithValidators(c, form)
+}
+
+// GetFormBySlug gets a published form by its owner-scoped slug
+func (fc *FormController) GetFormBySlug(c *fiber.Ctx) error {
 	var form models.Form
 	err := fc.collection.FindOne(context.Background(), bson.M{
-		"share_token":  token,
+		"owner_slug":   c.Params("ownerSlug"),
+		"slug":         c.Params("slug"),
 		"is_published": true,
 	}).Decode(&form)
 	if err != nil {
@@ -133,7 +922,9 @@ func (fc *FormController) GetFormByToken(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
 	}
 
-	return c.JSON(form)
+	stripOwnerSettings(&form)
+	form.Hints = form.DisplayHints()
+	return sendFormWithValidators(c, form)
 }
 
 // UpdateForm updates a form
@@ -145,6 +936,9 @@ func (fc *FormController) UpdateForm(c *fiber.Ctx) error {
 	}
 
 	var req models.UpdateFormRequest
+	if err := checkFieldOptionsJSON(c); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
@@ -153,6 +947,78 @@ func (fc *FormController) UpdateForm(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	// Only fields present in a partial update are checked, but those always are
+	if req.Title != "" {
+		req.Title = sanitizeText(req.Title)
+		if err := validateFormTitle(req.Title); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	req.Description = sanitizeText(req.Description)
+	if err := validateFormDescription(req.Description); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := validateFormFields(req.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var current models.Form
+	err = fc.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&current)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	// Changing a field's type can invalidate its stored answers, so it needs
+	// ?force=true once the field has been answered
+	if req.Fields != nil && c.Query("force") != "true" {
+		changes, affected, err := fieldTypeChanges(objectID, current.Fields, req.Fields)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to count affected responses"})
+		}
+		if affected > 0 {
+			return c.Status(409).JSON(fiber.Map{
+				"error":              "Changing field types would affect existing responses; repeat with ?force=true to apply",
+				"changed_fields":     changes,
+				"affected_responses": affected,
+			})
+		}
+	}
+
+	// Field groups are checked against the fields the form will have after the update
+	fields := current.Fields
+	if req.Fields != nil {
+		fields = req.Fields
+	}
+	groups := current.RequireAtLeastOne
+	if req.RequireAtLeastOne != nil {
+		groups = req.RequireAtLeastOne
+	}
+	if err := validateAtLeastOneGroups(groups, fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	sections := current.Sections
+	if req.Sections != nil {
+		sections = req.Sections
+	}
+	if err := validateSections(sections, fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	rules := current.NotificationRules
+	if req.NotificationRules != nil {
+		rules = req.NotificationRules
+	}
+	defaults := current.DefaultNotificationTargets
+	if req.DefaultNotificationTargets != nil {
+		defaults = req.DefaultNotificationTargets
+	}
+	if err := validateNotificationRules(rules, defaults, fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	update := bson.M{
 		"updated_at": time.Now(),
 	}
@@ -169,6 +1035,108 @@ func (fc *FormController) UpdateForm(c *fiber.Ctx) error {
 	if req.IsPublished != nil {
 		update["is_published"] = *req.IsPublished
 	}
+	if req.RequireAtLeastOne != nil {
+		update["require_at_least_one"] = req.RequireAtLeastOne
+	}
+	if req.Sections != nil {
+		update["sections"] = req.Sections
+	}
+	if req.EditWindowMinutes != nil {
+		update["edit_window_minutes"] = *req.EditWindowMinutes
+	}
+	if req.DuplicateWindowMinutes != nil {
+		update["duplicate_window_minutes"] = *req.DuplicateWindowMinutes
+	}
+	if req.IPStorage != nil {
+		update["ip_storage"] = *req.IPStorage
+	}
+	if req.DuplicateMatchUserAgent != nil {
+		update["duplicate_match_user_agent"] = *req.DuplicateMatchUserAgent
+	}
+	if req.RequireAuth != nil {
+		if *req.RequireAuth && !auth.Enabled() {
+			return c.Status(400).JSON(fiber.Map{"error": "Form requires sign-in but authentication is not configured"})
+		}
+		update["require_auth"] = *req.RequireAuth
+	}
+	if req.RequireInvite != nil {
+		update["require_invite"] = *req.RequireInvite
+	}
+	if req.RequireApproval != nil {
+		update["require_approval"] = *req.RequireApproval
+	}
+	if req.AnalyticsIncludeIncomplete != nil {
+		update["analytics_include_incomplete"] = *req.AnalyticsIncludeIncomplete
+	}
+	if req.RedirectURL != nil {
+		if *req.RedirectURL != "" && !isHTTPURL(*req.RedirectURL) {
+			return c.Status(400).JSON(fiber.Map{"error": "Redirect URL must be an absolute http(s) URL"})
+		}
+		update["redirect_url"] = *req.RedirectURL
+	}
+	if req.ThankYouMessage != nil {
+		update["thank_you_message"] = sanitizeText(*req.ThankYouMessage)
+	}
+	if req.IntroContent != nil {
+		update["intro_content"] = sanitizeContentBlock(req.IntroContent)
+	}
+	if req.OutroContent != nil {
+		update["outro_content"] = sanitizeContentBlock(req.OutroContent)
+	}
+	if req.WebhookURL != nil {
+		if *req.WebhookURL != "" && !isHTTPURL(*req.WebhookURL) {
+			return c.Status(400).JSON(fiber.Map{"error": "Webhook URL must be an absolute http(s) URL"})
+		}
+		if *req.WebhookURL != "" && !isPublicURL(*req.WebhookURL) {
+			return c.Status(400).JSON(fiber.Map{"error": "Webhook URL must point to a public address"})
+		}
+		update["webhook_url"] = *req.WebhookURL
+	}
+	if req.WebhookSecret != nil {
+		update["webhook_secret"] = *req.WebhookSecret
+	}
+	if req.WebhookTransform != nil {
+		if len(req.WebhookTransform.Mapping) == 0 && len(req.WebhookTransform.Static) == 0 {
+			update["webhook_transform"] = nil
+		} else {
+			if err := validateWebhookTransform(req.WebhookTransform); err != nil {
+				return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+			}
+			update["webhook_transform"] = req.WebhookTransform
+		}
+	}
+	if req.WebhookOrdered != nil {
+		update["webhook_ordered"] = *req.WebhookOrdered
+	}
+	if req.NotificationRules != nil {
+		update["notification_rules"] = req.NotificationRules
+	}
+	if req.DefaultNotificationTargets != nil {
+		update["default_notification_targets"] = req.DefaultNotificationTargets
+	}
+	if req.MilestoneRules != nil {
+		if err := validateMilestoneRules(req.MilestoneRules); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		update["milestone_rules"] = req.MilestoneRules
+		update["fired_milestones"] = keptMilestones(current.FiredMilestones, req.MilestoneRules)
+	}
+	if req.MetadataSchema != nil {
+		if err := validateMetadataSchema(req.MetadataSchema); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		update["metadata_schema"] = req.MetadataSchema
+	}
+	if req.Slug != "" {
+		slug, err := fc.resolveSlug(current.OwnerSlug, req.Slug, current.Title, objectID)
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				return c.Status(e.Code).JSON(fiber.Map{"error": e.Message})
+			}
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to update form"})
+		}
+		update["slug"] = slug
+	}
 
 	result, err := fc.collection.UpdateOne(
 		context.Background(),
@@ -189,6 +1157,20 @@ func (fc *FormController) UpdateForm(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch updated form"})
 	}
+	// Events held back while the form had no webhook go out once it has one,
+	// and switching ordering off hands the queue over to regular delivery
+	if (req.WebhookURL != nil || req.WebhookOrdered != nil) && updatedForm.WebhookURL != "" {
+		orderedWebhooks.kick(objectID)
+	}
+
+	changes := make([]string, 0, len(update))
+	for key := range update {
+		if key != "updated_at" {
+			changes = append(changes, key)
+		}
+	}
+	sort.Strings(changes)
+	recordAudit(c, "form_updated", objectID, nil, changes)
 
 	// Broadcast form update
 	fc.hub.BroadcastGeneral("form_updated", updatedForm)
@@ -196,6 +1178,78 @@ func (fc *FormController) UpdateForm(c *fiber.Ctx) error {
 	return c.JSON(updatedForm)
 }
 
+// ReorderFields persists a new field order without resending field definitions
+func (fc *FormController) ReorderFields(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var req models.ReorderFieldsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var form models.Form
+	err = fc.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	// The requested IDs must be a permutation of the current field IDs
+	fieldsByID := make(map[string]models.FormField, len(form.Fields))
+	for _, field := range form.Fields {
+		fieldsByID[field.ID] = field
+	}
+	if len(req.FieldIDs) != len(fieldsByID) {
+		return c.Status(400).JSON(fiber.Map{"error": "field_ids must list every field of the form exactly once"})
+	}
+
+	reordered := make([]models.FormField, 0, len(req.FieldIDs))
+	for i, fieldID := range req.FieldIDs {
+		field, ok := fieldsByID[fieldID]
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "field_ids must list every field of the form exactly once"})
+		}
+		delete(fieldsByID, fieldID)
+		field.Order = i
+		reordered = append(reordered, field)
+	}
+
+	// Only apply the new order if the form hasn't changed since it was read
+	now := time.Now()
+	result, err := fc.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": objectID, "updated_at": form.UpdatedAt},
+		bson.M{"$set": bson.M{"fields": reordered, "updated_at": now}},
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to reorder fields"})
+	}
+
+	if result.MatchedCount == 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "Form was modified concurrently, please retry"})
+	}
+
+	form.Fields = reordered
+	form.UpdatedAt = now
+
+	recordAudit(c, "fields_reordered", objectID, nil, []string{"fields"})
+
+	// Broadcast form update
+	fc.hub.BroadcastGeneral("form_updated", form)
+
+	return c.JSON(form)
+}
+
 // DeleteForm deletes a form
 func (fc *FormController) DeleteForm(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -214,8 +1268,19 @@ func (fc *FormController) DeleteForm(c *fiber.Ctx) error {
 	}
 
 	// Also delete all responses for this form
+	// and their uploaded files; CleanupUploads gets any left behind
 	responseCollection := database.GetCollection("responses")
+	if err := deleteResponseUploads(context.Background(), responseCollection, bson.M{"form_id": objectID}); err != nil {
+		log.Printf("Failed to delete uploads of form %s: %v", id, err)
+	}
 	responseCollection.DeleteMany(context.Background(), bson.M{"form_id": objectID})
+	database.GetCollection("form_stats").DeleteOne(context.Background(), bson.M{"form_id": objectID})
+	database.GetCollection("analytics").DeleteOne(context.Background(), bson.M{"form_id": objectID})
+	database.GetCollection("webhook_deliveries").DeleteMany(context.Background(), bson.M{"form_id": objectID})
+	database.GetCollection("field_timings").DeleteMany(context.Background(), bson.M{"form_id": objectID})
+	database.GetCollection("invite_tokens").DeleteMany(context.Background(), bson.M{"form_id": objectID})
+
+	recordAudit(c, "form_deleted", objectID, nil, nil)
 
 	// Broadcast form deletion
 	fc.hub.BroadcastGeneral("form_deleted", fiber.Map{"id": id})
@@ -267,6 +1332,8 @@ func (fc *FormController) PublishForm(c *fiber.Ctx) error {
 		action = "published"
 	}
 
+	recordAudit(c, "form_"+action, objectID, nil, []string{"is_published"})
+
 	// Broadcast form publication status change
 	fc.hub.BroadcastGeneral("form_"+action, updatedForm)
 
@@ -276,6 +1343,59 @@ func (fc *FormController) PublishForm(c *fiber.Ctx) error {
 	})
 }
 
+// SetAcceptingResponses pauses (?accepting=false) or resumes submissions
+// without unpublishing the form
+func (fc *FormController) SetAcceptingResponses(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	accepting, err := strconv.ParseBool(c.Query("accepting", "true"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid accepting parameter"})
+	}
+
+	result, err := fc.collection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": objectID},
+		bson.M{"$set": bson.M{
+			"accepting_responses": accepting,
+			"updated_at":          time.Now(),
+		}},
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update form"})
+	}
+
+	if result.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	var updatedForm models.Form
+	err = fc.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&updatedForm)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch updated form"})
+	}
+
+	action := "paused"
+	if accepting {
+		action = "resumed"
+	}
+
+	recordAudit(c, "form_"+action, objectID, nil, []string{"accepting_responses"})
+
+	// Respondents viewing the form learn about the change too
+	fc.hub.BroadcastGeneral("form_"+action, updatedForm)
+	fc.hub.BroadcastToForm(id, "form_"+action, fiber.Map{"accepting_responses": accepting})
+
+	return c.JSON(fiber.Map{
+		"message": fmt.Sprintf("Form %s successfully", action),
+		"form":    updatedForm,
+	})
+}
+
 // DuplicateForm creates a copy of an existing form
 func (fc *FormController) DuplicateForm(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -294,16 +1414,44 @@ func (fc *FormController) DuplicateForm(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
 	}
 
+	slug, err := fc.uniqueSlug(originalForm.OwnerSlug, originalForm.Title+" copy")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to duplicate form"})
+	}
+
+	shareToken, err := fc.uniqueShareToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to duplicate form"})
+	}
+
 	// Create a new form with the same fields but different ID and token
 	newForm := models.Form{
 		ID:          primitive.NewObjectID(),
-		Title:       originalForm.Title + " (Copy)",
+		Title:       copyTitle(originalForm.Title),
 		Description: originalForm.Description,
 		Fields:      originalForm.Fields,
+		Sections:    originalForm.Sections,
 		IsPublished: false,
-		ShareToken:  generateShareToken(),
+		ShareToken:  shareToken,
+		OwnerSlug:   originalForm.OwnerSlug,
+		Slug:        slug,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
+
+		RequireAtLeastOne:          originalForm.RequireAtLeastOne,
+		EditWindowMinutes:          originalForm.EditWindowMinutes,
+		DuplicateWindowMinutes:     originalForm.DuplicateWindowMinutes,
+		IPStorage:                  originalForm.IPStorage,
+		DuplicateMatchUserAgent:    originalForm.DuplicateMatchUserAgent,
+		MetadataSchema:             originalForm.MetadataSchema,
+		RequireAuth:                originalForm.RequireAuth,
+		RequireInvite:              originalForm.RequireInvite,
+		RequireApproval:            originalForm.RequireApproval,
+		AnalyticsIncludeIncomplete: originalForm.AnalyticsIncludeIncomplete,
+		RedirectURL:                originalForm.RedirectURL,
+		ThankYouMessage:            originalForm.ThankYouMessage,
+		IntroContent:               originalForm.IntroContent,
+		OutroContent:               originalForm.OutroContent,
 	}
 
 	result, err := fc.collection.InsertOne(context.Background(), newForm)
@@ -313,6 +1461,8 @@ func (fc *FormController) DuplicateForm(c *fiber.Ctx) error {
 
 	newForm.ID = result.InsertedID.(primitive.ObjectID)
 
+	recordAudit(c, "form_duplicated", newForm.ID, nil, []string{"source:" + id})
+
 	// Broadcast form creation
 	fc.hub.BroadcastGeneral("form_created", newForm)
 
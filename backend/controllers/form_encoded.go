@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"math"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// isFormEncoded reports whether a request carries an HTML form body rather
+// than JSON
+func isFormEncoded(c *fiber.Ctx) bool {
+	contentType := string(c.Request().Header.ContentType())
+	return strings.HasPrefix(contentType, fiber.MIMEApplicationForm) ||
+		strings.HasPrefix(contentType, fiber.MIMEMultipartForm)
+}
+
+// formPostRedirect returns where to send a plain HTML form after a completed
+// submission: the form's redirect URL, or "" for JSON requests and forms
+// without one. Browsers posting without script would otherwise land on the
+// JSON body.
+func formPostRedirect(c *fiber.Ctx, form models.Form) string {
+	if !isFormEncoded(c) {
+		return ""
+	}
+	return form.Confirmation().RedirectURL
+}
+
+// parseFormEncodedSubmission reads a submission posted by a plain HTML form.
+// Answers are named responses[<field_id>]; repeated names and a trailing []
+// make a list and responses[<field_id>][<key>] builds an object (e.g. a
+// location's lat and lng). metadata[<key>], invite_token, source, partial
+// and resume_token are read too. Every value arrives as a string;
+// coerceFormEncodedAnswers types them once the form is known. Uploaded files
+// are ignored.
+func parseFormEncodedSubmission(c *fiber.Ctx) (models.SubmitResponseRequest, error) {
+	values := make(map[string][]string)
+	uploads := make(map[string]*multipart.FileHeader)
+	if strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		form, err := c.MultipartForm()
+		if err != nil {
+			return models.SubmitResponseRequest{}, fiber.NewError(400, "Invalid request body")
+		}
+		values = form.Value
+		for name, files := range form.File {
+			path := formValuePath(name)
+			if len(path) != 2 || path[0] != "responses" {
+				continue
+			}
+			if len(files) > 1 {
+				return models.SubmitResponseRequest{}, fiber.NewError(400, "Only one file can be uploaded per field")
+			}
+			uploads[path[1]] = files[0]
+		}
+	} else {
+		c.Request().PostArgs().VisitAll(func(key, value []byte) {
+			values[string(key)] = append(values[string(key)], string(value))
+		})
+	}
+
+	req := models.SubmitResponseRequest{Responses: make(map[string]interface{})}
+	for name, list := range values {
+		path := formValuePath(name)
+		switch {
+		case len(path) == 1 && path[0] == "invite_token":
+			req.InviteToken = list[0]
+		case len(path) == 1 && path[0] == "source":
+			req.Source = list[0]
+		case len(path) == 1 && path[0] == "resume_token":
+			req.ResumeToken = list[0]
+		case len(path) == 1 && path[0] == "partial":
+			req.Partial = list[0] == "true" || list[0] == "on" || list[0] == "1"
+		case len(path) == 2 && path[0] == "metadata":
+			if req.Metadata == nil {
+				req.Metadata = make(map[string]interface{})
+			}
+			req.Metadata[path[1]] = list[0]
+		case len(path) == 2 && path[0] == "responses":
+			if len(list) == 1 {
+				req.Responses[path[1]] = list[0]
+			} else {
+				req.Responses[path[1]] = stringList(list)
+			}
+		case len(path) == 3 && path[0] == "responses" && path[2] == "":
+			req.Responses[path[1]] = stringList(list)
+		case len(path) == 3 && path[0] == "responses":
+			object, ok := req.Responses[path[1]].(map[string]interface{})
+			if !ok {
+				object = make(map[string]interface{})
+				req.Responses[path[1]] = object
+			}
+			object[path[2]] = list[0]
+		}
+	}
+	for fieldID, file := range uploads {
+		req.Responses[fieldID] = file
+	}
+	return req, nil
+}
+
+// formValuePath splits a form value name such as responses[email] into its
+// parts; a name without brackets is a single part
+func formValuePath(name string) []string {
+	open := strings.IndexByte(name, '[')
+	if open < 0 {
+		return []string{name}
+	}
+	path := []string{name[:open]}
+	rest := name[open:]
+	for strings.HasPrefix(rest, "[") {
+		end := strings.IndexByte(rest, ']')
+		if end < 0 {
+			return nil
+		}
+		path = append(path, rest[1:end])
+		rest = rest[end+1:]
+	}
+	if rest != "" {
+		return nil
+	}
+	return path
+}
+
+// stringList turns form values into an answer list
+func stringList(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// parseFiniteFloat parses a posted number. NaN and infinities parse as
+// floats but aren't numbers any field accepts.
+func parseFiniteFloat(str string) (float64, bool) {
+	num, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+		return 0, false
+	}
+	return num, true
+}
+
+// coerceFormEncodedAnswers converts the string answers of a form-encoded
+// submission to the types JSON clients send, so they are validated and
+// stored the same way. Empty strings are left for the required check.
+func coerceFormEncodedAnswers(responses map[string]interface{}, fields []models.FormField) error {
+	for _, field := range fields {
+		value, exists := responses[field.ID]
+		if !exists {
+			continue
+		}
+
+		switch field.Type {
+		case models.FieldTypeNumber, models.FieldTypeRating:
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				continue
+			}
+			num, ok := parseFiniteFloat(str)
+			if !ok {
+				return fiber.NewError(400, "Value for field '"+field.Label+"' must be a number")
+			}
+			responses[field.ID] = num
+		case models.FieldTypeCheckbox:
+			if str, ok := value.(string); ok {
+				if str == "" {
+					responses[field.ID] = []interface{}{}
+				} else {
+					responses[field.ID] = []interface{}{str}
+				}
+			}
+		case models.FieldTypeConsent:
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			// Checked boxes send "on" unless they set a value of their own
+			switch strings.ToLower(strings.TrimSpace(str)) {
+			case "on", "true", "1", "yes":
+				responses[field.ID] = true
+			case "", "off", "false", "0", "no":
+				responses[field.ID] = false
+			default:
+				return fiber.NewError(400, "Value for consent field '"+field.Label+"' must be true or false")
+			}
+		case models.FieldTypeLocation:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, key := range []string{"lat", "lng"} {
+				str, ok := object[key].(string)
+				if !ok {
+					continue
+				}
+				num, ok := parseFiniteFloat(str)
+				if !ok {
+					return fiber.NewError(400, "Value for location field '"+field.Label+"' must have a numeric lat and lng")
+				}
+				object[key] = num
+			}
+		}
+	}
+	return nil
+}
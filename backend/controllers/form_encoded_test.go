@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCoerceFormEncodedAnswers(t *testing.T) {
+	fields := []models.FormField{
+		{ID: "age", Label: "Age", Type: models.FieldTypeNumber},
+		{ID: "place", Label: "Place", Type: models.FieldTypeLocation},
+	}
+	tests := []struct {
+		name    string
+		answers map[string]interface{}
+		wantErr bool
+	}{
+		{"number", map[string]interface{}{"age": " 42 "}, false},
+		{"empty number", map[string]interface{}{"age": ""}, false},
+		{"not a number", map[string]interface{}{"age": "old"}, true},
+		{"NaN", map[string]interface{}{"age": "NaN"}, true},
+		{"infinity", map[string]interface{}{"age": "+Inf"}, true},
+		{"overflow", map[string]interface{}{"age": "1e400"}, true},
+		{"location", map[string]interface{}{"place": map[string]interface{}{"lat": "51.5", "lng": "-0.1"}}, false},
+		{"NaN location", map[string]interface{}{"place": map[string]interface{}{"lat": "nan", "lng": "0"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := coerceFormEncodedAnswers(tt.answers, fields); (err != nil) != tt.wantErr {
+				t.Errorf("coerceFormEncodedAnswers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormPostRedirect(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		redirectURL string
+		want        string
+	}{
+		{"html form", fiber.MIMEApplicationForm, "https://example.com/thanks", "https://example.com/thanks"},
+		{"multipart form", fiber.MIMEMultipartForm + "; boundary=x", "https://example.com/thanks", "https://example.com/thanks"},
+		{"html form without redirect", fiber.MIMEApplicationForm, "", ""},
+		{"json", fiber.MIMEApplicationJSON, "https://example.com/thanks", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				return c.SendString(formPostRedirect(c, models.Form{RedirectURL: tt.redirectURL}))
+			})
+			req := httptest.NewRequest("POST", "/", nil)
+			req.Header.Set(fiber.HeaderContentType, tt.contentType)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("formPostRedirect() = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
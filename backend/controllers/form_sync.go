@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// formsSyncPageSize bounds how many forms a single forms_sync message carries
+const formsSyncPageSize = 50
+
+// formsListETag fingerprints the form list. Creating, updating or deleting a
+// form changes the count, newest ID or latest update time, so a client holding
+// the same etag can keep its cached list.
+func (fc *FormController) formsListETag(ctx context.Context) (string, int64, error) {
+	cursor, err := fc.collection.Aggregate(ctx, []bson.M{
+		{"$group": bson.M{
+			"_id":         nil,
+			"count":       bson.M{"$sum": 1},
+			"last_id":     bson.M{"$max": "$_id"},
+			"last_update": bson.M{"$max": "$updated_at"},
+		}},
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var summary struct {
+		Count      int64              `bson:"count"`
+		LastID     primitive.ObjectID `bson:"last_id"`
+		LastUpdate time.Time          `bson:"last_update"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&summary); err != nil {
+			return "", 0, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return "", 0, err
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%d", summary.Count, summary.LastID.Hex(), summary.LastUpdate.UnixNano())))
+	return hex.EncodeToString(sum[:8]), summary.Count, nil
+}
+
+// FormsSync builds the forms_sync WebSocket reply. When etag matches the
+// current list only the version is returned; otherwise one page of forms,
+// newest first, starting after cursor.
+func (fc *FormController) FormsSync(etag, cursor string) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	current, total, err := fc.formsListETag(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if etag != "" && etag == current && cursor == "" {
+		return fiber.Map{"etag": current, "total": total, "unchanged": true}, nil
+	}
+
+	filter := bson.M{}
+	if cursor != "" {
+		after, err := primitive.ObjectIDFromHex(cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor %q", cursor)
+		}
+		filter["_id"] = bson.M{"$lt": after}
+	}
+
+	found, err := fc.collection.Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(formsSyncPageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	defer found.Close(ctx)
+
+	var forms []models.Form
+	if err := found.All(ctx, &forms); err != nil {
+		return nil, err
+	}
+
+	hasMore := len(forms) > formsSyncPageSize
+	if hasMore {
+		forms = forms[:formsSyncPageSize]
+	}
+	if forms == nil {
+		forms = []models.Form{}
+	}
+
+	nextCursor := ""
+	if hasMore {
+		nextCursor = forms[len(forms)-1].ID.Hex()
+	}
+
+	return fiber.Map{
+		"etag":        current,
+		"total":       total,
+		"forms":       forms,
+		"has_more":    hasMore,
+		"next_cursor": nextCursor,
+	}, nil
+}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCheckFieldFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   models.FormField
+		wantErr bool
+	}{
+		{"optional disabled", models.FormField{Type: models.FieldTypeText, Disabled: true}, false},
+		{"required disabled", models.FormField{Type: models.FieldTypeText, Required: true, Disabled: true}, true},
+		{"required read-only without default", models.FormField{Type: models.FieldTypeText, Required: true, ReadOnly: true}, true},
+		{"required read-only with default", models.FormField{Type: models.FieldTypeText, Required: true, ReadOnly: true, DefaultValue: "x"}, false},
+		{"required hidden without default", models.FormField{Type: models.FieldTypeHidden, Required: true}, true},
+		{"conditionally required hidden without default", models.FormField{Type: models.FieldTypeHidden, RequiredIf: []models.Condition{{FieldID: "a", Operator: models.ConditionEquals, Value: "x"}}}, true},
+		{"required hidden with default", models.FormField{Type: models.FieldTypeHidden, Required: true, DefaultValue: "x"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Label = "Field"
+			if err := checkFieldFlags(tt.field); (err != nil) != tt.wantErr {
+				t.Errorf("checkFieldFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFormFieldsOptions(t *testing.T) {
+	options := func(values ...string) []models.FieldOption {
+		var list []models.FieldOption
+		for _, value := range values {
+			list = append(list, models.FieldOption{Label: value, Value: value})
+		}
+		return list
+	}
+	tests := []struct {
+		name    string
+		field   models.FormField
+		wantErr string
+	}{
+		{"unique values", models.FormField{Type: models.FieldTypeMultipleChoice, Options: options("a", "b")}, ""},
+		{"duplicate values", models.FormField{Type: models.FieldTypeMultipleChoice, Options: options("a", "b", "a")}, "Field 'Field' has duplicate option value 'a'"},
+		{"duplicate checkbox values", models.FormField{Type: models.FieldTypeCheckbox, Options: options("a", "a")}, "Field 'Field' has duplicate option value 'a'"},
+		{"empty value", models.FormField{Type: models.FieldTypeCheckbox, Options: options("a", "")}, "Option 2 of field 'Field' has an empty value"},
+		{"required without options", models.FormField{Type: models.FieldTypeMultipleChoice, Required: true}, "Field 'Field' is required but has no options"},
+		{"required with empty options", models.FormField{Type: models.FieldTypeCheckbox, Required: true, Options: []models.FieldOption{}}, "Field 'Field' is required but has no options"},
+		{"optional without options", models.FormField{Type: models.FieldTypeMultipleChoice}, ""},
+		{"too many options", models.FormField{Type: models.FieldTypeMultipleChoice, Options: make([]models.FieldOption, maxFieldOptions+1)}, "Field 'Field' has too many options (max 100)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.ID = "field"
+			tt.field.Label = "Field"
+			fields := []models.FormField{tt.field}
+			err := validateFormFields(fields)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("validateFormFields() error = %q, want %q", got, tt.wantErr)
+			}
+			if err == nil {
+				for _, option := range fields[0].Options {
+					if option.ID == "" {
+						t.Errorf("validateFormFields() left option %q without an ID", option.Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateFormTextLimits(t *testing.T) {
+	app := fiber.New()
+	// Requests that pass validation would go on to the database; every case
+	// here must be rejected before that
+	app.Put("/forms/:id", (&FormController{}).UpdateForm)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{"title too long", `{"title":"` + strings.Repeat("a", maxFormTitleLength+1) + `"}`, "Title"},
+		{"title too long in characters", `{"title":"` + strings.Repeat("é", maxFormTitleLength+1) + `"}`, "Title"},
+		{"blank title", `{"title":"   "}`, "Title is required"},
+		{"title of markup only", `{"title":"<b></b>"}`, "Title is required"},
+		{"description too long", `{"description":"` + strings.Repeat("a", maxFormDescriptionLength+1) + `"}`, "Description"},
+		{"description too long with a title", `{"title":"Survey","description":"` + strings.Repeat("a", maxFormDescriptionLength+1) + `"}`, "Description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/forms/65a000000000000000000000", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != 400 || !strings.Contains(string(body), tt.wantErr) {
+				t.Errorf("UpdateForm(%s) = %d %s, want 400 mentioning %q", tt.name, resp.StatusCode, body, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFormTitle(t *testing.T) {
+	tests := []struct {
+		title   string
+		wantErr bool
+	}{
+		{"Survey", false},
+		{strings.Repeat("a", maxFormTitleLength), false},
+		{strings.Repeat("é", maxFormTitleLength), false},
+		{strings.Repeat("a", maxFormTitleLength+1), true},
+		{"", true},
+		{" \t", true},
+	}
+	for _, tt := range tests {
+		if err := validateFormTitle(tt.title); (err != nil) != tt.wantErr {
+			t.Errorf("validateFormTitle(%q) error = %v, wantErr %v", tt.title, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCopyTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Survey", "Survey (Copy)"},
+		{strings.Repeat("a", maxFormTitleLength), strings.Repeat("a", maxFormTitleLength-7) + " (Copy)"},
+	}
+	for _, tt := range tests {
+		got := copyTitle(tt.title)
+		if got != tt.want {
+			t.Errorf("copyTitle(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+		if err := validateFormTitle(got); err != nil {
+			t.Errorf("validateFormTitle(copyTitle(%q)) error = %v", tt.title, err)
+		}
+	}
+}
+
+func TestValidateFormFieldsRequiredUnless(t *testing.T) {
+	rating := models.FormField{ID: "rating", Label: "Rating", Type: models.FieldTypeRating}
+	tests := []struct {
+		name    string
+		unless  []models.Condition
+		wantErr bool
+	}{
+		{"existing field", []models.Condition{{FieldID: "rating", Operator: models.ConditionGreaterThan, Value: 3}}, false},
+		{"unknown field", []models.Condition{{FieldID: "missing", Operator: models.ConditionGreaterThan, Value: 3}}, true},
+		{"own answer", []models.Condition{{FieldID: "explain", Operator: models.ConditionIsEmpty}}, true},
+		{"unknown operator", []models.Condition{{FieldID: "rating", Operator: "at_least", Value: 3}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := []models.FormField{rating, {ID: "explain", Label: "Explain", Type: models.FieldTypeTextarea, RequiredUnless: tt.unless}}
+			if err := validateFormFields(fields); (err != nil) != tt.wantErr {
+				t.Errorf("validateFormFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAssignFieldIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  []models.FormField
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "empty IDs from labels",
+			fields: []models.FormField{{Label: "Email address"}, {Label: "  Your Name?  "}},
+			want:   []string{"email_address", "your_name"},
+		},
+		{
+			name:   "empty IDs with the same label",
+			fields: []models.FormField{{Label: "Comment"}, {Label: "Comment"}, {Label: "comment!"}},
+			want:   []string{"comment", "comment_2", "comment_3"},
+		},
+		{
+			name:   "generated ID taken by an explicit one",
+			fields: []models.FormField{{Label: "Name"}, {ID: "name", Label: "Full name"}},
+			want:   []string{"name_2", "name"},
+		},
+		{
+			name:   "label without usable characters",
+			fields: []models.FormField{{Label: "???"}, {Label: ""}},
+			want:   []string{"field", "field_2"},
+		},
+		{
+			name:   "long label",
+			fields: []models.FormField{{Label: strings.Repeat("word ", 20)}},
+			want:   []string{strings.TrimRight(strings.Repeat("word_", 8), "_")},
+		},
+		{
+			name:    "duplicate IDs",
+			fields:  []models.FormField{{ID: "email", Label: "Email"}, {ID: "email", Label: "Work email"}},
+			wantErr: true,
+		},
+		{
+			name:   "client-generated ID",
+			fields: []models.FormField{{ID: "field_1700000000000_k3j9x2m1q", Label: "Name"}},
+			want:   []string{"field_1700000000000_k3j9x2m1q"},
+		},
+		{
+			name:    "ID with a dot",
+			fields:  []models.FormField{{ID: "address.city", Label: "City"}},
+			wantErr: true,
+		},
+		{
+			name:    "ID starting with $",
+			fields:  []models.FormField{{ID: "$where", Label: "Where"}},
+			wantErr: true,
+		},
+		{
+			name:    "ID with uppercase letters",
+			fields:  []models.FormField{{ID: "Email", Label: "Email"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := assignFieldIDs(tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("assignFieldIDs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, want := range tt.want {
+				if got := tt.fields[i].ID; got != want {
+					t.Errorf("assignFieldIDs() field %d ID = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateInvites mints a batch of one-time submission tokens for a form. The
+// tokens are only returned here; the server keeps their hashes.
+func (rc *ResponseController) CreateInvites(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var req models.CreateInvitesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	count, err := rc.formCollection.CountDocuments(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	if count == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	now := time.Now()
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		expiry := now.Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	tokens := make([]string, 0, req.Count)
+	docs := make([]interface{}, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		token := generateShareToken()
+		tokens = append(tokens, token)
+		docs = append(docs, models.InviteToken{
+			ID:        primitive.NewObjectID(),
+			FormID:    objectID,
+			TokenHash: hashToken(token),
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		})
+	}
+
+	if _, err := rc.inviteCollection.InsertMany(context.Background(), docs); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create invites"})
+	}
+
+	recordAudit(c, "invites_created", objectID, nil, nil)
+
+	return c.Status(201).JSON(fiber.Map{
+		"tokens":     tokens,
+		"expires_at": expiresAt,
+	})
+}
+
+// claimInvite marks an unused, unexpired invite token of the form as used.
+// It reports false when the token is unknown, already used or expired.
+func (rc *ResponseController) claimInvite(formID primitive.ObjectID, token string) (*models.InviteToken, bool, error) {
+	if token == "" {
+		return nil, false, nil
+	}
+
+	now := time.Now()
+	var invite models.InviteToken
+	err := rc.inviteCollection.FindOneAndUpdate(context.Background(),
+		bson.M{
+			"form_id":    formID,
+			"token_hash": hashToken(token),
+			"used_at":    bson.M{"$exists": false},
+			"$or": []bson.M{
+				{"expires_at": bson.M{"$exists": false}},
+				{"expires_at": bson.M{"$gt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+	).Decode(&invite)
+	if err == mongo.ErrNoDocuments {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &invite, true, nil
+}
+
+// releaseInvite makes a claimed invite usable again after the submission it
+// was claimed for failed to store
+func (rc *ResponseController) releaseInvite(invite *models.InviteToken) {
+	rc.inviteCollection.UpdateOne(context.Background(),
+		bson.M{"_id": invite.ID},
+		bson.M{"$unset": bson.M{"used_at": ""}},
+	)
+}
+
+// completeInvite links a used invite to the response it was spent on
+func (rc *ResponseController) completeInvite(invite *models.InviteToken, responseID primitive.ObjectID) {
+	rc.inviteCollection.UpdateOne(context.Background(),
+		bson.M{"_id": invite.ID},
+		bson.M{"$set": bson.M{"response_id": responseID}},
+	)
+}
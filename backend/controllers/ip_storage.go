@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"form-builder-api/models"
+)
+
+// Bits of an address kept by truncation: the network part of a /24 for IPv4
+// and of a /48 for IPv6, enough for coarse geolocation
+const (
+	truncatedIPv4Bits = 24
+	truncatedIPv6Bits = 48
+)
+
+// defaultIPStorage reads the server-wide IP storage mode from
+// IP_STORAGE_MODE; full addresses are stored unless it says otherwise
+func defaultIPStorage() models.IPStorageMode {
+	mode := models.IPStorageMode(os.Getenv("IP_STORAGE_MODE"))
+	switch mode {
+	case models.IPStorageFull, models.IPStorageTruncated, models.IPStorageNone:
+		return mode
+	case "":
+		return models.IPStorageFull
+	}
+	log.Printf("Unknown IP_STORAGE_MODE %q, storing full IP addresses", mode)
+	return models.IPStorageFull
+}
+
+// serverIPStorage is the IP storage mode for forms that don't set their own
+var serverIPStorage = sync.OnceValue(defaultIPStorage)
+
+// ipCounterKey is the key per-IP submission counters are hashed with, from
+// IP_HASH_KEY. Without one a random key is used, so counters start over
+// when the server restarts and aren't shared between instances.
+var ipCounterKey = sync.OnceValue(func() []byte {
+	if key := os.Getenv("IP_HASH_KEY"); key != "" {
+		return []byte(key)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("Failed to generate IP hash key: %v", err)
+	}
+	return key
+})
+
+// hashIP returns an HMAC of ip keyed with ipCounterKey, so counters identify
+// an address without storing it or letting it be recovered by hashing
+// candidate addresses
+func hashIP(ip string) string {
+	mac := hmac.New(sha256.New, ipCounterKey())
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// storedIP returns the part of a respondent's address kept with their
+// response under the form's IP storage mode
+func (rc *ResponseController) storedIP(form models.Form, ip string) string {
+	return applyIPStorage(rc.formIPStorage(form), ip)
+}
+
+// formIPStorage returns the form's IP storage mode, or the server's when the
+// form doesn't set one
+func (rc *ResponseController) formIPStorage(form models.Form) models.IPStorageMode {
+	if form.IPStorage != "" {
+		return form.IPStorage
+	}
+	return rc.ipStorage
+}
+
+// applyIPStorage returns the part of ip kept under mode
+func applyIPStorage(mode models.IPStorageMode, ip string) string {
+	switch mode {
+	case models.IPStorageNone:
+		return ""
+	case models.IPStorageTruncated:
+		return truncateIP(ip)
+	}
+	return ip
+}
+
+// truncateIP zeroes the host part of an address: the last octet of an IPv4
+// address and the last 80 bits of an IPv6 one. Values that don't parse as an
+// address are dropped rather than stored as they are.
+func truncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(truncatedIPv4Bits, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(truncatedIPv6Bits, 128)).String()
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"testing"
+
+	"form-builder-api/models"
+)
+
+func TestTruncateIP(t *testing.T) {
+	tests := []struct {
+		ip, want string
+	}{
+		{"203.0.113.57", "203.0.113.0"},
+		{"::ffff:203.0.113.57", "203.0.113.0"},
+		{"2001:db8:85a3:1234:5678:8a2e:370:7334", "2001:db8:85a3::"},
+		{"::1", "::"},
+		{"not an ip", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := truncateIP(tt.ip); got != tt.want {
+			t.Errorf("truncateIP(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestApplyIPStorage(t *testing.T) {
+	tests := []struct {
+		mode     models.IPStorageMode
+		ip, want string
+	}{
+		{models.IPStorageFull, "203.0.113.57", "203.0.113.57"},
+		{models.IPStorageTruncated, "203.0.113.57", "203.0.113.0"},
+		{models.IPStorageTruncated, "2001:db8::1", "2001:db8::"},
+		{models.IPStorageNone, "203.0.113.57", ""},
+		{models.IPStorageNone, "2001:db8::1", ""},
+	}
+	for _, tt := range tests {
+		if got := applyIPStorage(tt.mode, tt.ip); got != tt.want {
+			t.Errorf("applyIPStorage(%q, %q) = %q, want %q", tt.mode, tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestFormIPStorage(t *testing.T) {
+	rc := &ResponseController{ipStorage: models.IPStorageFull}
+	tests := []struct {
+		form models.IPStorageMode
+		want models.IPStorageMode
+	}{
+		{"", models.IPStorageFull},
+		{models.IPStorageTruncated, models.IPStorageTruncated},
+		{models.IPStorageNone, models.IPStorageNone},
+	}
+	for _, tt := range tests {
+		if got := rc.formIPStorage(models.Form{IPStorage: tt.form}); got != tt.want {
+			t.Errorf("formIPStorage(%q) = %q, want %q", tt.form, got, tt.want)
+		}
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	ip := "203.0.113.57"
+	if hashIP(ip) != hashIP(ip) {
+		t.Errorf("hashIP(%q) isn't stable", ip)
+	}
+	if hashIP(ip) == hashIP("203.0.113.58") {
+		t.Errorf("hashIP(%q) matches another address", ip)
+	}
+	// A plain hash of the address could be reversed by hashing candidates
+	if hashIP(ip) == hashToken(ip) {
+		t.Errorf("hashIP(%q) = unkeyed SHA-256", ip)
+	}
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// maxLocationAddressLength caps the optional address of a location answer
+	maxLocationAddressLength = 500
+	// locationGridDegrees is the size of the grid cells answers are counted in
+	locationGridDegrees = 1
+	// maxLocationPoints caps the raw points returned for heatmaps
+	maxLocationPoints = 1000
+	// maxNearRadiusMeters caps the radius of nearby response queries
+	maxNearRadiusMeters = 100000
+	// Nearby response limits: default and maximum per request
+	defaultNearResponses = 50
+	maxNearResponses     = 100
+)
+
+// nearbyResponse is a response found by GetResponsesNear together with its
+// distance from the queried point
+type nearbyResponse struct {
+	models.FormResponse `bson:",inline"`
+	DistanceMeters      float64 `json:"distance_meters" bson:"distance_meters"`
+}
+
+// GetResponsesNear lists a form's responses whose location answer lies within
+// radius meters of lat/lng, nearest first. The location field is picked with
+// ?field= and may be omitted when the form has a single location field.
+func (rc *ResponseController) GetResponsesNear(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return c.Status(400).JSON(fiber.Map{"error": "lat must be a number between -90 and 90"})
+	}
+	lng, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return c.Status(400).JSON(fiber.Map{"error": "lng must be a number between -180 and 180"})
+	}
+	radius, err := strconv.ParseFloat(c.Query("radius"), 64)
+	if err != nil || radius <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "radius must be a positive number of meters"})
+	}
+	if radius > maxNearRadiusMeters {
+		radius = maxNearRadiusMeters
+	}
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultNearResponses)))
+	if err != nil || limit < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid limit parameter"})
+	}
+	if limit > maxNearResponses {
+		limit = maxNearResponses
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	field, err := nearQueryField(form, c.Query("field"))
+	if err != nil {
+		e := err.(*fiber.Error)
+		return c.Status(e.Code).JSON(fiber.Map{"error": e.Message})
+	}
+
+	// $geoNear measures to the nearest of a response's points, which may
+	// answer another location field of the form; the distance to the queried
+	// field's point is worked out below
+	ctx := context.Background()
+	cursor, err := rc.responseCollection.Aggregate(ctx, []bson.M{
+		{"$geoNear": bson.M{
+			"near":          bson.M{"type": "Point", "coordinates": bson.A{lng, lat}},
+			"key":           "geo.point",
+			"distanceField": "distance_meters",
+			"maxDistance":   radius,
+			"spherical":     true,
+			"query":         bson.M{"form_id": objectID, "geo.field_id": field.ID},
+		}},
+		{"$limit": limit},
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch nearby responses"})
+	}
+	defer cursor.Close(ctx)
+
+	var found []nearbyResponse
+	if err := cursor.All(ctx, &found); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode responses"})
+	}
+	responses := make([]nearbyResponse, 0, len(found))
+	for _, response := range found {
+		for _, point := range response.Geo {
+			if point.FieldID != field.ID {
+				continue
+			}
+			response.DistanceMeters = distanceMeters(lat, lng, point.Point.Lat(), point.Point.Lng())
+			if response.DistanceMeters <= radius {
+				decryptResponses(response.Responses)
+				responses = append(responses, response)
+			}
+			break
+		}
+	}
+	sort.SliceStable(responses, func(i, j int) bool { return responses[i].DistanceMeters < responses[j].DistanceMeters })
+
+	return c.JSON(fiber.Map{
+		"form_id":       id,
+		"field_id":      field.ID,
+		"radius_meters": radius,
+		"responses":     responses,
+	})
+}
+
+// earthRadiusMeters is the mean radius MongoDB uses for spherical distances
+const earthRadiusMeters = 6378100
+
+// distanceMeters is the great-circle distance between two points
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// nearQueryField picks the location field a nearby query runs against.
+// Encrypted answers are stored as ciphertext, so they can't be queried.
+func nearQueryField(form models.Form, fieldID string) (models.FormField, error) {
+	var candidates []models.FormField
+	for _, field := range form.Fields {
+		if field.Type != models.FieldTypeLocation {
+			continue
+		}
+		if fieldID == "" || field.ID == fieldID {
+			candidates = append(candidates, field)
+		}
+	}
+
+	switch {
+	case len(candidates) == 0 && fieldID != "":
+		return models.FormField{}, fiber.NewError(400, "Field "+fieldID+" is not a location field of this form")
+	case len(candidates) == 0:
+		return models.FormField{}, fiber.NewError(400, "Form has no location fields")
+	case len(candidates) > 1:
+		return models.FormField{}, fiber.NewError(400, "Form has several location fields; choose one with the field parameter")
+	case candidates[0].Encrypted:
+		return models.FormField{}, fiber.NewError(400, "Encrypted location fields can't be queried by distance")
+	}
+	return candidates[0], nil
+}
+
+// locationAnalytics counts a location field's answers per grid cell, most
+// answered cells first, and returns the most recent answers as raw points
+// for heatmaps
+func (rc *ResponseController) locationAnalytics(ctx context.Context, formID primitive.ObjectID, field models.FormField, answered int64, opts analyticsOptions) ([]fiber.Map, []fiber.Map, error) {
+	match := bson.M{"$match": bson.M{
+		"form_id":                         formID,
+		"$nor":                            opts.uncounted(),
+		"responses." + field.ID + ".type": "Point",
+	}}
+	coordinates := bson.M{"$project": bson.M{
+		"lng": bson.M{"$arrayElemAt": bson.A{"$responses." + field.ID + ".coordinates", 0}},
+		"lat": bson.M{"$arrayElemAt": bson.A{"$responses." + field.ID + ".coordinates", 1}},
+	}}
+
+	cursor, err := rc.responseCollection.Aggregate(ctx, []bson.M{
+		match,
+		coordinates,
+		{"$group": bson.M{
+			"_id": bson.M{
+				"lat": bson.M{"$multiply": bson.A{bson.M{"$floor": bson.M{"$divide": bson.A{"$lat", locationGridDegrees}}}, locationGridDegrees}},
+				"lng": bson.M{"$multiply": bson.A{bson.M{"$floor": bson.M{"$divide": bson.A{"$lng", locationGridDegrees}}}, locationGridDegrees}},
+			},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id.lat", Value: 1}, {Key: "_id.lng", Value: 1}}},
+		{"$limit": opts.topNOr(defaultChoiceTopN)},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var cells []struct {
+		Cell struct {
+			Lat float64 `bson:"lat"`
+			Lng float64 `bson:"lng"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	err = cursor.All(ctx, &cells)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	regions := make([]fiber.Map, 0, len(cells))
+	for _, cell := range cells {
+		percentage := float64(0)
+		if answered > 0 {
+			percentage = float64(cell.Count) / float64(answered) * 100
+		}
+		regions = append(regions, fiber.Map{
+			"lat_min":    cell.Cell.Lat,
+			"lat_max":    cell.Cell.Lat + locationGridDegrees,
+			"lng_min":    cell.Cell.Lng,
+			"lng_max":    cell.Cell.Lng + locationGridDegrees,
+			"count":      cell.Count,
+			"percentage": percentage,
+		})
+	}
+
+	cursor, err = rc.responseCollection.Aggregate(ctx, []bson.M{
+		match,
+		{"$sort": bson.M{"created_at": -1}},
+		{"$limit": maxLocationPoints},
+		coordinates,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var docs []struct {
+		Lat float64 `bson:"lat"`
+		Lng float64 `bson:"lng"`
+	}
+	err = cursor.All(ctx, &docs)
+	cursor.Close(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	points := make([]fiber.Map, 0, len(docs))
+	for _, doc := range docs {
+		points = append(points, fiber.Map{"lat": doc.Lat, "lng": doc.Lng})
+	}
+
+	return regions, points, nil
+}
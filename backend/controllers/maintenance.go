@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+	"form-builder-api/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// orphanGracePeriod keeps the cleanup away from responses that are still being
+// written, so a submission racing with the scan is never reported as orphaned
+const orphanGracePeriod = 10 * time.Minute
+
+// uploadGracePeriod keeps the upload cleanup away from files stored for a
+// submission that hasn't been saved yet
+const uploadGracePeriod = time.Hour
+
+// MaintenanceController handles data maintenance operations
+type MaintenanceController struct {
+	formCollection     *mongo.Collection
+	responseCollection *mongo.Collection
+}
+
+// NewMaintenanceController creates a new maintenance controller
+func NewMaintenanceController() *MaintenanceController {
+	return &MaintenanceController{
+		formCollection:     database.GetCollection("forms"),
+		responseCollection: database.GetCollection("responses"),
+	}
+}
+
+// OrphanReport summarizes responses whose form no longer exists
+type OrphanReport struct {
+	OrphanedForms     int       `json:"orphaned_forms"`
+	OrphanedResponses int64     `json:"orphaned_responses"`
+	DeletedResponses  int64     `json:"deleted_responses"`
+	Cutoff            time.Time `json:"cutoff"`
+}
+
+// GetOrphanedResponses reports responses that reference deleted forms
+func (mc *MaintenanceController) GetOrphanedResponses(c *fiber.Ctx) error {
+	report, err := mc.CleanupOrphanedResponses(context.Background(), false)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to scan for orphaned responses"})
+	}
+
+	return c.JSON(report)
+}
+
+// DeleteOrphanedResponses deletes responses that reference deleted forms
+func (mc *MaintenanceController) DeleteOrphanedResponses(c *fiber.Ctx) error {
+	report, err := mc.CleanupOrphanedResponses(context.Background(), true)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete orphaned responses"})
+	}
+
+	return c.JSON(report)
+}
+
+// ReloadDisposableDomains reloads the disposable email domain list so an
+// updated DISPOSABLE_EMAIL_DOMAINS_FILE takes effect without a restart
+func (mc *MaintenanceController) ReloadDisposableDomains(c *fiber.Ctx) error {
+	count, err := LoadDisposableDomains()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to load disposable email domains"})
+	}
+
+	return c.JSON(fiber.Map{"domains": count})
+}
+
+// CleanupOrphanedResponses finds responses whose form_id has no matching form
+// and, when remove is set, deletes them
+func (mc *MaintenanceController) CleanupOrphanedResponses(ctx context.Context, remove bool) (*OrphanReport, error) {
+	cutoff := time.Now().Add(-orphanGracePeriod)
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"created_at": bson.M{"$lt": cutoff}}},
+		{"$group": bson.M{
+			"_id":   "$form_id",
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$lookup": bson.M{
+			"from":         "forms",
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "form",
+		}},
+		{"$match": bson.M{"form": bson.M{"$size": 0}}},
+		{"$project": bson.M{"count": 1}},
+	}
+
+	cursor, err := mc.responseCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		FormID primitive.ObjectID `bson:"_id"`
+		Count  int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	report := &OrphanReport{Cutoff: cutoff}
+	formIDs := make([]primitive.ObjectID, 0, len(groups))
+	for _, group := range groups {
+		formIDs = append(formIDs, group.FormID)
+		report.OrphanedResponses += group.Count
+	}
+	report.OrphanedForms = len(formIDs)
+
+	if !remove || len(formIDs) == 0 {
+		return report, nil
+	}
+
+	filter := bson.M{
+		"form_id":    bson.M{"$in": formIDs},
+		"created_at": bson.M{"$lt": cutoff},
+	}
+	if err := deleteResponseUploads(ctx, mc.responseCollection, filter); err != nil {
+		return nil, err
+	}
+	result, err := mc.responseCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	report.DeletedResponses = result.DeletedCount
+
+	return report, nil
+}
+
+// StartOrphanCleanup periodically deletes orphaned responses
+func (mc *MaintenanceController) StartOrphanCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := mc.CleanupOrphanedResponses(context.Background(), true)
+			if err != nil {
+				log.Printf("Orphaned response cleanup failed: %v", err)
+				continue
+			}
+			if report.DeletedResponses > 0 {
+				log.Printf("Deleted %d orphaned responses across %d forms", report.DeletedResponses, report.OrphanedForms)
+			}
+		}
+	}()
+}
+
+// CleanupUploads deletes stored uploads that no response references, such as
+// the files of responses expired by RESPONSE_RETENTION_DAYS or of saves that
+// failed half way, and returns how many it deleted
+func (mc *MaintenanceController) CleanupUploads(ctx context.Context) (int, error) {
+	uploads, ok := storage.Uploads().(*storage.LocalStorage)
+	if !ok {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-uploadGracePeriod)
+	var stale []string
+	err := uploads.List(func(key string, modTime time.Time) {
+		if modTime.Before(cutoff) {
+			stale = append(stale, key)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, key := range stale {
+		count, err := mc.responseCollection.CountDocuments(ctx, bson.M{"files": key}, options.Count().SetLimit(1))
+		if err != nil {
+			return deleted, err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := uploads.Delete(key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// backfillUploadReferences records the files of responses stored before
+// responses listed them, so CleanupUploads doesn't take those files for
+// unreferenced ones
+func (mc *MaintenanceController) backfillUploadReferences(ctx context.Context) error {
+	cursor, err := mc.responseCollection.Find(ctx, bson.M{"files": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"form_id": 1, "responses": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var response models.FormResponse
+		if err := cursor.Decode(&response); err != nil {
+			return err
+		}
+		decryptResponses(response.Responses)
+		_, err := mc.responseCollection.UpdateOne(ctx, bson.M{"_id": response.ID}, bson.M{
+			"$set": bson.M{"files": uploadKeys(response.FormID, response.Responses)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// StartUploadCleanup periodically deletes unreferenced uploads, once the
+// responses stored before files were recorded have been backfilled
+func (mc *MaintenanceController) StartUploadCleanup(interval time.Duration) {
+	go func() {
+		if err := mc.backfillUploadReferences(context.Background()); err != nil {
+			log.Printf("Recording the uploads of existing responses failed, upload cleanup disabled: %v", err)
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, err := mc.CleanupUploads(context.Background())
+			if err != nil {
+				log.Printf("Upload cleanup failed: %v", err)
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d unreferenced uploads", deleted)
+			}
+		}
+	}()
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"sort"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxMetadataKeys bounds the size of a form's metadata schema
+const maxMetadataKeys = 50
+
+// validateMetadataSchema checks a form's metadata schema before it is saved
+func validateMetadataSchema(schema []models.MetadataKey) error {
+	if len(schema) > maxMetadataKeys {
+		return fiber.NewError(400, "Metadata schema has too many keys")
+	}
+
+	seen := make(map[string]bool, len(schema))
+	for _, key := range schema {
+		if key.Key == "" {
+			return fiber.NewError(400, "Metadata schema keys must have a name")
+		}
+		if seen[key.Key] {
+			return fiber.NewError(400, "Metadata schema has duplicate key '"+key.Key+"'")
+		}
+		seen[key.Key] = true
+
+		switch key.Type {
+		case models.MetadataString, models.MetadataNumber, models.MetadataBoolean:
+		default:
+			return fiber.NewError(400, "Metadata key '"+key.Key+"' has unknown type '"+string(key.Type)+"'")
+		}
+	}
+	return nil
+}
+
+// validateMetadata checks submission metadata against the form's schema.
+// Forms without a schema accept any metadata.
+func validateMetadata(metadata map[string]interface{}, schema []models.MetadataKey) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	expected := make(map[string]models.MetadataKey, len(schema))
+	for _, key := range schema {
+		expected[key.Key] = key
+		if _, ok := metadata[key.Key]; key.Required && !ok {
+			return fiber.NewError(400, "Metadata key '"+key.Key+"' is required")
+		}
+	}
+
+	// Report unknown keys in a stable order
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec, ok := expected[key]
+		if !ok {
+			return fiber.NewError(400, "Unknown metadata key '"+key+"'")
+		}
+
+		value := metadata[key]
+		if value == nil && !spec.Required {
+			continue
+		}
+
+		valid := false
+		switch spec.Type {
+		case models.MetadataString:
+			_, valid = value.(string)
+		case models.MetadataNumber:
+			_, valid = value.(float64)
+		case models.MetadataBoolean:
+			_, valid = value.(bool)
+		}
+		if !valid {
+			return fiber.NewError(400, "Metadata key '"+key+"' must be a "+string(spec.Type))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxMilestoneRules caps the milestone rules of a form
+const maxMilestoneRules = 20
+
+// validateMilestoneRules checks milestone rules and gives new rules an ID
+func validateMilestoneRules(rules []models.MilestoneRule) error {
+	if len(rules) > maxMilestoneRules {
+		return fiber.NewError(400, fmt.Sprintf("Too many milestone rules (max %d)", maxMilestoneRules))
+	}
+
+	seen := make(map[string]bool, len(rules))
+	for i := range rules {
+		rule := &rules[i]
+		if rule.ID == "" {
+			rule.ID = generateShortID()
+		}
+		if seen[rule.ID] {
+			return fiber.NewError(400, "Milestone rule ID '"+rule.ID+"' is used twice")
+		}
+		seen[rule.ID] = true
+		rule.Name = sanitizeText(rule.Name)
+
+		if !models.KnownMilestoneMetric(rule.Metric) {
+			return fiber.NewError(400, "Unknown milestone metric '"+string(rule.Metric)+"'")
+		}
+		if rule.Direction != models.MilestoneAbove && rule.Direction != models.MilestoneBelow {
+			return fiber.NewError(400, fmt.Sprintf("Milestone rule %d needs a direction of above or below", i+1))
+		}
+		if rule.Threshold < 0 || math.IsNaN(rule.Threshold) || math.IsInf(rule.Threshold, 0) {
+			return fiber.NewError(400, fmt.Sprintf("Milestone rule %d needs a non-negative threshold", i+1))
+		}
+		if rule.Metric == models.MilestoneCompletionRate && rule.Threshold > 100 {
+			return fiber.NewError(400, fmt.Sprintf("Milestone rule %d compares a percentage and needs a threshold up to 100", i+1))
+		}
+		if rule.MinResponses < 0 {
+			return fiber.NewError(400, fmt.Sprintf("Milestone rule %d has a negative min_responses", i+1))
+		}
+		if err := validateNotificationTargets(rule.Targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keptMilestones drops fired milestones of rules that no longer exist, so a
+// removed rule that is added again can fire again
+func keptMilestones(fired []models.FiredMilestone, rules []models.MilestoneRule) []models.FiredMilestone {
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ids[rule.ID] = true
+	}
+	kept := []models.FiredMilestone{}
+	for _, milestone := range fired {
+		if ids[milestone.RuleID] {
+			kept = append(kept, milestone)
+		}
+	}
+	return kept
+}
+
+// milestoneValue reads the metric a milestone rule watches from freshly
+// calculated analytics
+func milestoneValue(metric models.MilestoneMetric, analytics *models.FormAnalytics) (float64, bool) {
+	switch metric {
+	case models.MilestoneTotalResponses:
+		return float64(analytics.TotalResponses), true
+	case models.MilestoneResponsesLast24h:
+		return float64(analytics.ResponsesLast24h), true
+	case models.MilestoneCompletionRate:
+		return models.AsFloat(analytics.FieldAnalytics["completion_rate"])
+	}
+	return 0, false
+}
+
+// checkMilestones fires the form's milestone rules that the analytics reach
+// for the first time. Each rule is recorded on the form before anyone is
+// told, and only the update that records it notifies, so concurrent
+// recomputes can't fire a milestone twice.
+func (rc *ResponseController) checkMilestones(form models.Form, analytics *models.FormAnalytics) {
+	fired := make(map[string]bool, len(form.FiredMilestones))
+	for _, milestone := range form.FiredMilestones {
+		fired[milestone.RuleID] = true
+	}
+
+	for _, rule := range form.MilestoneRules {
+		if fired[rule.ID] {
+			continue
+		}
+		value, ok := milestoneValue(rule.Metric, analytics)
+		if !ok || !rule.Reached(value, analytics.TotalResponses) {
+			continue
+		}
+
+		milestone := models.FiredMilestone{RuleID: rule.ID, Value: value, FiredAt: time.Now()}
+		result, err := rc.formCollection.UpdateOne(context.Background(),
+			bson.M{"_id": form.ID, "fired_milestones.rule_id": bson.M{"$ne": rule.ID}},
+			bson.M{"$push": bson.M{"fired_milestones": milestone}},
+		)
+		if err != nil {
+			log.Printf("Failed to record milestone %s for form %s: %v", rule.ID, form.ID.Hex(), err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		rc.notifyMilestone(form, rule, milestone)
+	}
+}
+
+// notifyMilestone tells the form's viewers and the rule's targets that a
+// milestone was reached
+func (rc *ResponseController) notifyMilestone(form models.Form, rule models.MilestoneRule, milestone models.FiredMilestone) {
+	event := fiber.Map{
+		"event":      "milestone_reached",
+		"form_id":    form.ID.Hex(),
+		"rule":       rule,
+		"value":      milestone.Value,
+		"reached_at": milestone.FiredAt,
+	}
+	rc.hub.BroadcastToForm(form.ID.Hex(), "milestone_reached", event)
+
+	if len(rule.Targets) == 0 {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to build milestone payload for form %s: %v", form.ID.Hex(), err)
+		return
+	}
+	summary := milestoneSummary(form, rule, milestone.Value)
+
+	for _, target := range rule.Targets {
+		go func(target models.NotificationTarget) {
+			if err := sendNotification(form, target, "milestone_reached", payload, "Milestone reached on "+form.Title, summary); err != nil {
+				log.Printf("Failed to send %s milestone notification for form %s: %v", target.Type, form.ID.Hex(), err)
+			}
+		}(target)
+	}
+}
+
+// milestoneSummary describes a reached milestone in plain text
+func milestoneSummary(form models.Form, rule models.MilestoneRule, value float64) string {
+	name := rule.Name
+	if name == "" {
+		name = fmt.Sprintf("%s %s %g", rule.Metric, rule.Direction, rule.Threshold)
+	}
+	return fmt.Sprintf("Milestone \"%s\" reached on %s: %s is now %g\n", name, form.Title, rule.Metric, value)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AddNote adds an internal reviewer note to a response
+func (rc *ResponseController) AddNote(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var req models.AddNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.Author = strings.TrimSpace(req.Author)
+	req.Text = strings.TrimSpace(req.Text)
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	note := models.ResponseNote{
+		ID:        primitive.NewObjectID(),
+		Author:    req.Author,
+		Text:      req.Text,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := rc.responseCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": responseID, "form_id": objectID},
+		bson.M{"$push": bson.M{"notes": note}},
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to add note"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+	}
+
+	recordAudit(c, "response_note_added", objectID, &responseID, nil)
+
+	rc.hub.BroadcastToForm(id, "response_note_added", fiber.Map{
+		"form_id":     id,
+		"response_id": responseID.Hex(),
+		"note":        note,
+	})
+
+	return c.Status(201).JSON(note)
+}
+
+// GetNotes lists a response's reviewer notes, oldest first. They can be
+// narrowed by ?author= and ?since= (RFC 3339 or YYYY-MM-DD).
+func (rc *ResponseController) GetNotes(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var since time.Time
+	if value := c.Query("since"); value != "" {
+		since, _, err = parseDateParam(value)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid since date"})
+		}
+	}
+	author := c.Query("author")
+
+	var response models.FormResponse
+	err = rc.responseCollection.FindOne(
+		context.Background(),
+		bson.M{"_id": responseID, "form_id": objectID},
+		options.FindOne().SetProjection(bson.M{"notes": 1}),
+	).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch notes"})
+	}
+
+	notes := make([]models.ResponseNote, 0, len(response.Notes))
+	for _, note := range response.Notes {
+		if author != "" && !strings.EqualFold(note.Author, author) {
+			continue
+		}
+		if !since.IsZero() && note.CreatedAt.Before(since) {
+			continue
+		}
+		notes = append(notes, note)
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].CreatedAt.Before(notes[j].CreatedAt) })
+
+	return c.JSON(fiber.Map{"notes": notes})
+}
@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"form-builder-api/models"
+	"form-builder-api/webhook"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validateConditions checks that conditions reference existing fields with
+// supported operators
+func validateConditions(conditions []models.Condition, fields []models.FormField) error {
+	known := make(map[string]models.FormField, len(fields))
+	for _, field := range fields {
+		known[field.ID] = field
+	}
+
+	for _, condition := range conditions {
+		field, ok := known[condition.FieldID]
+		if !ok {
+			return fiber.NewError(400, "Condition references unknown field '"+condition.FieldID+"'")
+		}
+		if !models.KnownConditionOperator(condition.Operator) {
+			return fiber.NewError(400, "Unknown condition operator '"+string(condition.Operator)+"'")
+		}
+		if condition.Operator == models.ConditionIn {
+			if _, ok := models.AsStringSlice(condition.Value); !ok {
+				return fiber.NewError(400, "Condition on field '"+condition.FieldID+"' needs a list of values")
+			}
+		}
+		if models.CountsSelections(condition.Operator) {
+			if field.Type != models.FieldTypeCheckbox {
+				return fiber.NewError(400, "Selection count condition on field '"+field.Label+"' needs a checkbox field")
+			}
+			count, ok := models.AsFloat(condition.Value)
+			if !ok || count < 0 || count != math.Trunc(count) {
+				return fiber.NewError(400, "Selection count condition on field '"+field.Label+"' needs a whole number of selections")
+			}
+			if int(count) > len(field.Options) {
+				return fiber.NewError(400, fmt.Sprintf("Selection count condition on field '%s' asks for %d selections but the field has %d options", field.Label, int(count), len(field.Options)))
+			}
+		}
+	}
+	return nil
+}
+
+// validateNotificationTargets checks that each target's address suits its type
+func validateNotificationTargets(targets []models.NotificationTarget) error {
+	for _, target := range targets {
+		switch target.Type {
+		case models.NotificationEmail:
+			if !isValidEmail(target.Address) {
+				return fiber.NewError(400, "Invalid notification email '"+target.Address+"'")
+			}
+		case models.NotificationWebhook, models.NotificationSlack:
+			if !isHTTPURL(target.Address) {
+				return fiber.NewError(400, "Notification "+string(target.Type)+" target must be an absolute http(s) URL")
+			}
+			if !isPublicURL(target.Address) {
+				return fiber.NewError(400, "Notification "+string(target.Type)+" target must point to a public address")
+			}
+		default:
+			return fiber.NewError(400, "Unknown notification target type '"+string(target.Type)+"'")
+		}
+	}
+	return nil
+}
+
+// validateNotificationRules validates routing rules and fallback targets against the form's fields
+func validateNotificationRules(rules []models.NotificationRule, defaults []models.NotificationTarget, fields []models.FormField) error {
+	for i, rule := range rules {
+		if len(rule.Targets) == 0 {
+			return fiber.NewError(400, fmt.Sprintf("Notification rule %d has no targets", i+1))
+		}
+		if err := validateConditions(rule.Conditions, fields); err != nil {
+			return err
+		}
+		if err := validateNotificationTargets(rule.Targets); err != nil {
+			return err
+		}
+	}
+	return validateNotificationTargets(defaults)
+}
+
+// notifySubmission sends notifications about a submission to the targets its
+// answers route to, as event: "response_submitted", or "response_pending"
+// for responses awaiting approval. Delivery happens in the background and
+// failures are logged.
+func notifySubmission(form models.Form, response models.FormResponse, event string) {
+	targets := form.NotificationTargetsFor(response.Responses)
+	if len(targets) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(fiber.Map{
+		"event":    event,
+		"form_id":  form.ID.Hex(),
+		"response": redactEncrypted(form, response),
+	})
+	if err != nil {
+		log.Printf("Failed to build notification payload for form %s: %v", form.ID.Hex(), err)
+		return
+	}
+	summary := submissionSummary(form, response)
+	subject := "New response to " + form.Title
+	if event == "response_pending" {
+		subject = "Response awaiting approval on " + form.Title
+	}
+
+	for _, target := range targets {
+		go func(target models.NotificationTarget) {
+			if err := sendNotification(form, target, event, payload, subject, summary); err != nil {
+				log.Printf("Failed to send %s notification for form %s: %v", target.Type, form.ID.Hex(), err)
+			}
+		}(target)
+	}
+}
+
+// sendNotification delivers a single notification. Webhooks get payload as
+// the given event, Slack and email get the plain-text summary. Targets are
+// set by form owners, so webhooks and Slack only reach public addresses.
+func sendNotification(form models.Form, target models.NotificationTarget, event string, payload []byte, subject, summary string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookAttemptTimeout)
+	defer cancel()
+
+	switch target.Type {
+	case models.NotificationWebhook:
+		result, err := webhook.DeliverPublic(ctx, target.Address, event, payload, form.WebhookSecret)
+		if err == nil && !result.Success() {
+			err = fmt.Errorf("receiver responded with status %d", result.StatusCode)
+		}
+		return err
+	case models.NotificationSlack:
+		message, _ := json.Marshal(fiber.Map{"text": summary})
+		result, err := webhook.DeliverPublic(ctx, target.Address, "", message, "")
+		if err == nil && !result.Success() {
+			err = fmt.Errorf("slack responded with status %d", result.StatusCode)
+		}
+		return err
+	case models.NotificationEmail:
+		return sendEmail(target.Address, subject, summary)
+	}
+	return fmt.Errorf("unknown notification target type %q", target.Type)
+}
+
+// submissionSummary renders a submission as plain text, in field order.
+// Answers to encrypted fields are left out.
+func submissionSummary(form models.Form, response models.FormResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "New response to %s (%s)\n", form.Title, response.CreatedAt.UTC().Format(time.RFC1123))
+	if response.ReceiptCode != "" {
+		fmt.Fprintf(&b, "Receipt: %s\n", response.ReceiptCode)
+	}
+	b.WriteString("\n")
+	for _, field := range form.Fields {
+		value := formatExportValue(response.Responses[field.ID])
+		if field.Encrypted {
+			value = redactedAnswer
+		}
+		fmt.Fprintf(&b, "%s: %s\n", field.Label, value)
+	}
+	return b.String()
+}
+
+// sendEmail sends a plain-text email through the SMTP server configured with
+// SMTP_HOST, SMTP_PORT (default 587), SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+func sendEmail(to, subject, body string) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return fmt.Errorf("email notifications are not configured")
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = os.Getenv("SMTP_USERNAME")
+	}
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	// Keep header values on one line
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	message := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	return smtp.SendMail(host+":"+port, auth, from, []string{to}, []byte(message))
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Open Graph preview image size, the 1.91:1 ratio social sites expect
+const (
+	ogImageWidth  = 1200
+	ogImageHeight = 630
+)
+
+const (
+	maxOGDescriptionLength = 200
+	defaultOGDescription   = "Fill out this form"
+)
+
+// findPublishedForm loads a published form by its share token, resolving it
+// to the same form as GetFormByToken
+func (fc *FormController) findPublishedForm(token string) (models.Form, error) {
+	var form models.Form
+	err := fc.collection.FindOne(context.Background(), bson.M{
+		"share_token":  token,
+		"is_published": true,
+	}, options.FindOne().SetSort(shareTokenOrder)).Decode(&form)
+	return form, err
+}
+
+// GetFormOpenGraph returns Open Graph metadata for a published form's share
+// link. With ?format=html it serves the meta tags as a minimal HTML page for
+// link-preview crawlers.
+func (fc *FormController) GetFormOpenGraph(c *fiber.Ctx) error {
+	form, err := fc.findPublishedForm(c.Params("token"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found or not published"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	description := strings.TrimSpace(form.Description)
+	if description == "" {
+		description = defaultOGDescription
+	}
+	if utf8.RuneCountInString(description) > maxOGDescriptionLength {
+		description = string([]rune(description)[:maxOGDescriptionLength-1]) + "…"
+	}
+
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = c.BaseURL()
+	}
+	meta := fiber.Map{
+		"type":         "website",
+		"title":        form.Title,
+		"description":  description,
+		"image":        strings.TrimSuffix(baseURL, "/") + "/api/v1/forms/public/" + form.ShareToken + "/og.png",
+		"image_width":  ogImageWidth,
+		"image_height": ogImageHeight,
+	}
+	// The share link points at the frontend, which runs on its own origin
+	if formBaseURL := os.Getenv("FORM_BASE_URL"); formBaseURL != "" {
+		meta["url"] = strings.TrimSuffix(formBaseURL, "/") + "/f/" + form.ShareToken
+	}
+
+	if c.Query("format") != "html" {
+		return c.JSON(meta)
+	}
+
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html>\n<html><head>\n<meta charset=\"utf-8\">\n")
+	page.WriteString("<title>" + html.EscapeString(form.Title) + "</title>\n")
+	for _, key := range []string{"type", "title", "description", "url", "image", "image_width", "image_height"} {
+		value, ok := meta[key]
+		if !ok {
+			continue
+		}
+		property := "og:" + strings.Replace(key, "_", ":", 1)
+		page.WriteString("<meta property=\"" + property + "\" content=\"" + html.EscapeString(fmt.Sprint(value)) + "\">\n")
+	}
+	page.WriteString("<meta name=\"twitter:card\" content=\"summary_large_image\">\n")
+	page.WriteString("</head><body></body></html>\n")
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.SendString(page.String())
+}
+
+// GetFormOpenGraphImage renders a published form's preview image: a card in
+// a color derived from the form, with one line per question (up to eight).
+// Titles are carried by og:title, so the image itself holds no text.
+func (fc *FormController) GetFormOpenGraphImage(c *fiber.Ctx) error {
+	form, err := fc.findPublishedForm(c.Params("token"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found or not published"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, renderOGImage(form)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to render preview image"})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(buf.Bytes())
+}
+
+// renderOGImage draws the preview card for a form
+func renderOGImage(form models.Form) image.Image {
+	sum := sha256.Sum256([]byte(form.ID.Hex()))
+	accent := color.RGBA{R: 64 + sum[0]/2, G: 64 + sum[1]/2, B: 64 + sum[2]/2, A: 255}
+	background := color.RGBA{R: accent.R / 2, G: accent.G / 2, B: accent.B / 2, A: 255}
+	line := color.RGBA{R: 226, G: 230, B: 236, A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, ogImageWidth, ogImageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	card := image.Rect(120, 70, ogImageWidth-120, ogImageHeight-70)
+	draw.Draw(img, card, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(card.Min.X, card.Min.Y, card.Max.X, card.Min.Y+24), &image.Uniform{C: accent}, image.Point{}, draw.Src)
+
+	// Title bar, then a label and answer box per question
+	draw.Draw(img, image.Rect(card.Min.X+60, card.Min.Y+64, card.Min.X+560, card.Min.Y+100), &image.Uniform{C: accent}, image.Point{}, draw.Src)
+	questions := len(form.Fields)
+	if questions > 8 {
+		questions = 8
+	}
+	for i := 0; i < questions; i++ {
+		column, row := i/4, i%4
+		x := card.Min.X + 60 + column*430
+		y := card.Min.Y + 140 + row*80
+		draw.Draw(img, image.Rect(x, y, x+220, y+14), &image.Uniform{C: line}, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x, y+26, x+380, y+56), &image.Uniform{C: line}, image.Point{}, draw.Src)
+	}
+
+	return img
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"context"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetFormOverview returns a form together with its analytics so results pages
+// render from one request. Analytics come from the cache when available.
+// Private fields are left out of both the form and its analytics, as are
+// answers to fields that were removed from the form.
+func (rc *ResponseController) GetFormOverview(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	analytics, err := rc.cachedAnalytics(form)
+	if err != nil {
+		return sendAnalyticsError(c, err)
+	}
+
+	public := make(map[string]bool, len(form.Fields))
+	fields := make([]models.FormField, 0, len(form.Fields))
+	for _, field := range form.Fields {
+		if isPrivateField(field) {
+			continue
+		}
+		public[field.ID] = true
+		fields = append(fields, field)
+	}
+	form.Fields = fields
+	stripOwnerSettings(&form)
+
+	return c.JSON(fiber.Map{
+		"form":                 form,
+		"analytics":            publicAnalytics(analytics.FieldAnalytics, public),
+		"analytics_updated_at": analytics.UpdatedAt,
+	})
+}
+
+// cachedAnalytics returns the form's cached analytics, calculating and
+// caching them when the form has none yet. The calculation is bounded by the
+// analytics deadline.
+func (rc *ResponseController) cachedAnalytics(form models.Form) (*models.FormAnalytics, error) {
+	var analytics models.FormAnalytics
+	err := rc.analyticsCollection.FindOne(context.Background(), bson.M{"form_id": form.ID}).Decode(&analytics)
+	if err == nil {
+		return &analytics, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	computed, err := rc.computeAnalytics(form, defaultAnalyticsOptions(form))
+	if err != nil {
+		return nil, err
+	}
+	rc.analyticsCollection.ReplaceOne(context.Background(), bson.M{"form_id": form.ID}, computed, options.Replace().SetUpsert(true))
+	return computed, nil
+}
+
+// isPrivateField reports whether a field's answers are kept off public
+// results: hidden fields carry tracking parameters rather than answers, and
+// encrypted fields hold sensitive data
+func isPrivateField(field models.FormField) bool {
+	return field.Encrypted || field.Type == models.FieldTypeHidden
+}
+
+// publicAnalytics copies form analytics keeping only the per-field metrics
+// of the given fields. It accepts both freshly calculated and cached
+// analytics, whose nested values decode to different types.
+func publicAnalytics(analytics map[string]interface{}, public map[string]bool) fiber.Map {
+	result := make(fiber.Map, len(analytics))
+	for key, value := range analytics {
+		result[key] = value
+	}
+
+	keep := func(entry map[string]interface{}) bool {
+		fieldID, _ := entry["field_id"].(string)
+		return public[fieldID]
+	}
+
+	result["field_analytics"] = filterEntries(analytics["field_analytics"], keep)
+
+	if dropOff, ok := asMap(analytics["field_drop_off"]); ok {
+		filtered := make(fiber.Map, len(dropOff))
+		for key, value := range dropOff {
+			filtered[key] = value
+		}
+		filtered["fields"] = filterEntries(dropOff["fields"], keep)
+		result["field_drop_off"] = filtered
+	}
+
+	sections := filterEntries(analytics["section_analytics"], func(map[string]interface{}) bool { return true })
+	for i, section := range sections {
+		filtered := make(fiber.Map, len(section))
+		for key, value := range section {
+			filtered[key] = value
+		}
+		fieldIDs := []string{}
+		for _, fieldID := range asList(section["field_ids"]) {
+			if id, ok := fieldID.(string); ok && public[id] {
+				fieldIDs = append(fieldIDs, id)
+			}
+		}
+		filtered["field_ids"] = fieldIDs
+		sections[i] = filtered
+	}
+	result["section_analytics"] = sections
+
+	return result
+}
+
+// filterEntries returns the map entries of a list for which keep is true
+func filterEntries(list interface{}, keep func(map[string]interface{}) bool) []map[string]interface{} {
+	result := []map[string]interface{}{}
+	for _, item := range asList(list) {
+		if entry, ok := asMap(item); ok && keep(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// asList reads a list from calculated ([]interface{}, []fiber.Map, []string)
+// or cached (primitive.A) analytics
+func asList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case primitive.A:
+		return v
+	case []fiber.Map:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list
+	}
+	return nil
+}
+
+// asMap reads a document from calculated (fiber.Map) or cached analytics
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case fiber.Map:
+		return v, true
+	case map[string]interface{}:
+		return v, true
+	case primitive.M:
+		return v, true
+	}
+	return nil, false
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// completeResponses matches responses that aren't saved-for-later partial
+// submissions
+var completeResponses = bson.M{"$ne": true}
+
+// resumeResponse continues a response saved with partial set. The submitted
+// answers are laid over the saved ones; unless the request is partial again
+// the result is fully validated and the response completed, counting as
+// submitted from then on.
+func (rc *ResponseController) resumeResponse(c *fiber.Ctx, form models.Form, req models.SubmitResponseRequest, user *models.AuthenticatedUser) error {
+	var saved models.FormResponse
+	err := rc.responseCollection.FindOne(context.Background(), bson.M{
+		"form_id":           form.ID,
+		"resume_token_hash": hashToken(req.ResumeToken),
+		"incomplete":        true,
+	}).Decode(&saved)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Resume token is invalid or the response was already completed"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch response"})
+	}
+
+	decryptResponses(saved.Responses)
+	answers := make(map[string]interface{}, len(saved.Responses)+len(req.Responses))
+	for key, value := range saved.Responses {
+		answers[key] = value
+	}
+	for key, value := range req.Responses {
+		answers[key] = value
+	}
+	req.Responses = answers
+	if req.Metadata == nil {
+		req.Metadata = saved.Metadata
+	}
+
+	response, err := rc.buildResponse(c, form, req)
+	if err != nil {
+		rc.recordSubmissionOutcome(form.ID, outcomeValidationFailed)
+		return c.Status(400).JSON(validationErrorBody(err))
+	}
+
+	if !req.Partial && form.DuplicateWindowMinutes > 0 {
+		duplicate, err := rc.isRecentDuplicate(form, response)
+		if err != nil {
+			rc.recordSubmissionOutcome(form.ID, outcomeServerError)
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
+		}
+		if duplicate {
+			rc.recordSubmissionOutcome(form.ID, outcomeValidationFailed)
+			return c.Status(409).JSON(fiber.Map{"error": "An identical response was already submitted"})
+		}
+	}
+
+	uploaded, err := storeUploads(context.Background(), form.ID, req.Responses)
+	if err != nil {
+		rc.recordSubmissionOutcome(form.ID, outcomeServerError)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store uploaded files"})
+	}
+
+	storedResponses, err := encryptResponses(req.Responses, form.Fields)
+	if err != nil {
+		removeUploads(uploaded)
+		rc.recordSubmissionOutcome(form.ID, outcomeServerError)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to encrypt response"})
+	}
+
+	// Keep the original acceptance time for consents that are still given
+	now := time.Now()
+	for fieldID := range response.Consents {
+		if acceptedAt, ok := saved.Consents[fieldID]; ok {
+			response.Consents[fieldID] = acceptedAt
+		}
+	}
+
+	set := bson.M{
+		"responses":          storedResponses,
+		"metadata":           req.Metadata,
+		"consents":           response.Consents,
+		"completion_percent": response.CompletionPercent,
+		"score":              response.Score,
+		"fingerprint":        response.Fingerprint,
+		"geo":                response.Geo,
+		"files":              uploadKeys(form.ID, req.Responses),
+		"updated_at":         now,
+	}
+	update := bson.M{"$set": set}
+
+	editToken := ""
+	if !req.Partial {
+		// The response counts as submitted when it is completed
+		set["created_at"] = now
+		if user != nil {
+			set["submitted_by"] = user
+		}
+		if form.EditWindowMinutes > 0 {
+			editToken = generateShareToken()
+			set["edit_token_hash"] = hashToken(editToken)
+		}
+		update["$unset"] = bson.M{"incomplete": "", "resume_token_hash": ""}
+	}
+
+	// Only the first of two concurrent completions wins
+	result, err := rc.responseCollection.UpdateOne(context.Background(), bson.M{
+		"_id":        saved.ID,
+		"incomplete": true,
+	}, update)
+	if err != nil {
+		removeUploads(uploaded)
+		rc.recordSubmissionOutcome(form.ID, outcomeServerError)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
+	}
+	if result.MatchedCount == 0 {
+		removeUploads(uploaded)
+		return c.Status(404).JSON(fiber.Map{"error": "Resume token is invalid or the response was already completed"})
+	}
+
+	response.ID = saved.ID
+	response.ReceiptCode = saved.ReceiptCode
+	response.Source = saved.Source
+	response.SubmittedBy = saved.SubmittedBy
+	response.UpdatedAt = &now
+	if req.Partial {
+		response.CreatedAt = saved.CreatedAt
+		return c.JSON(fiber.Map{
+			"message":      "Response saved; submit again with the resume token to complete it",
+			"response":     response,
+			"incomplete":   true,
+			"resume_token": req.ResumeToken,
+		})
+	}
+	if user != nil {
+		response.SubmittedBy = user
+	}
+	rc.recordSubmissionOutcome(form.ID, outcomeSucceeded)
+	rc.announceSubmission(form, response)
+
+	if target := formPostRedirect(c, form); target != "" {
+		return c.Redirect(target, fiber.StatusSeeOther)
+	}
+
+	payload := fiber.Map{
+		"message":      "Response submitted successfully",
+		"response":     response,
+		"receipt_code": response.ReceiptCode,
+		"confirmation": form.Confirmation(),
+	}
+	if editToken != "" {
+		payload["edit_token"] = editToken
+		payload["edit_expires_at"] = now.Add(editWindow(form))
+	}
+	return c.JSON(payload)
+}
@@ -2,9 +2,23 @@ package controllers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"form-builder-api/auth"
 	"form-builder-api/database"
 	"form-builder-api/models"
 	"form-builder-api/websocket"
@@ -18,18 +32,48 @@ import (
 
 // ResponseController handles response-related operations
 type ResponseController struct {
-	responseCollection *mongo.Collection
-	formCollection     *mongo.Collection
-	hub                *websocket.Hub
+	responseCollection  *mongo.Collection
+	formCollection      *mongo.Collection
+	ipCounterCollection *mongo.Collection
+	statsCollection     *mongo.Collection
+	analyticsCollection *mongo.Collection
+	timingCollection    *mongo.Collection
+	inviteCollection    *mongo.Collection
+	hub                 *websocket.Hub
+	analytics           *analyticsScheduler
+	analyticsLimit      *analyticsLimiter
+	counts              *responseCountCache
+
+	// maxSubmissionsPerIP caps daily submissions per IP across all forms (0 disables)
+	maxSubmissionsPerIP int64
+	internalToken       string
+	// ipStorage is how much of respondents' IPs is stored for forms that
+	// don't choose themselves
+	ipStorage models.IPStorageMode
 }
 
 // NewResponseController creates a new response controller
 func NewResponseController(hub *websocket.Hub) *ResponseController {
-	return &ResponseController{
-		responseCollection: database.GetCollection("responses"),
-		formCollection:     database.GetCollection("forms"),
-		hub:                hub,
+	maxPerIP, _ := strconv.ParseInt(os.Getenv("MAX_SUBMISSIONS_PER_IP_PER_DAY"), 10, 64)
+
+	rc := &ResponseController{
+		responseCollection:  database.GetCollection("responses"),
+		formCollection:      database.GetCollection("forms"),
+		ipCounterCollection: database.GetCollection("ip_submission_counters"),
+		statsCollection:     database.GetCollection("form_stats"),
+		analyticsCollection: database.GetCollection("analytics"),
+		timingCollection:    database.GetCollection("field_timings"),
+		inviteCollection:    database.GetCollection("invite_tokens"),
+		hub:                 hub,
+		maxSubmissionsPerIP: maxPerIP,
+		internalToken:       os.Getenv("INTERNAL_API_TOKEN"),
+		ipStorage:           serverIPStorage(),
 	}
+	rc.analytics = newAnalyticsScheduler(analyticsDebounce(), rc.updateAnalytics)
+	rc.analyticsLimit = newAnalyticsLimiter()
+	rc.counts = newResponseCountCache()
+
+	return rc
 }
 
 // SubmitResponse submits a response to a form
@@ -40,8 +84,15 @@ func (rc *ResponseController) SubmitResponse(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
 	}
 
+	// Plain HTML forms post urlencoded or multipart bodies without nesting
 	var req models.SubmitResponseRequest
-	if err := c.BodyParser(&req); err != nil {
+	formEncoded := isFormEncoded(c)
+	if formEncoded {
+		req, err = parseFormEncodedSubmission(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	} else if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
@@ -62,45 +113,821 @@ func (rc *ResponseController) SubmitResponse(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
 	}
 
-	// Validate response against form fields
-	if err := rc.validateResponse(req.Responses, form.Fields); err != nil {
+	// Paused forms stay viewable but turn submissions away
+	if !form.IsAcceptingResponses() {
+		return c.Status(403).JSON(fiber.Map{"error": "This form is temporarily not accepting responses"})
+	}
+
+	if formEncoded {
+		if err := coerceFormEncodedAnswers(req.Responses, form.Fields); err != nil {
+			rc.recordSubmissionOutcome(objectID, outcomeValidationFailed)
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if err := checkSubmittedFiles(req.Responses, form.Fields); err != nil {
+		rc.recordSubmissionOutcome(objectID, outcomeValidationFailed)
 		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Create response document
-	response := models.FormResponse{
-		ID:        primitive.NewObjectID(),
-		FormID:    objectID,
-		Responses: req.Responses,
-		Metadata:  req.Metadata,
-		IPAddress: c.IP(),
-		UserAgent: c.Get("User-Agent"),
-		CreatedAt: time.Now(),
+	// Forms restricted to signed-in respondents reject anonymous submissions
+	var user *models.AuthenticatedUser
+	if form.RequireAuth {
+		user, err = auth.FromRequest(c)
+		if err != nil || user == nil {
+			return c.Status(401).JSON(fiber.Map{"error": "Sign in to submit this form"})
+		}
+	}
+
+	// Saved responses are continued with their resume token
+	if req.ResumeToken != "" {
+		return rc.resumeResponse(c, form, req, user)
+	}
+
+	// Validate response against form fields
+	response, err := rc.buildResponse(c, form, req)
+	if err != nil {
+		rc.recordSubmissionOutcome(objectID, outcomeValidationFailed)
+		return c.Status(400).JSON(validationErrorBody(err))
+	}
+	response.SubmittedBy = user
+
+	// Forms with a duplicate window reject resubmissions of the same answers
+	if form.DuplicateWindowMinutes > 0 && !req.Partial {
+		duplicate, err := rc.isRecentDuplicate(form, response)
+		if err != nil {
+			rc.recordSubmissionOutcome(objectID, outcomeServerError)
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
+		}
+		if duplicate {
+			rc.recordSubmissionOutcome(objectID, outcomeValidationFailed)
+			return c.Status(409).JSON(fiber.Map{"error": "An identical response was already submitted"})
+		}
+	}
+
+	// Invite-only forms spend one invite token per submission
+	var invite *models.InviteToken
+	if form.RequireInvite {
+		var ok bool
+		invite, ok, err = rc.claimInvite(objectID, req.InviteToken)
+		if err != nil {
+			rc.recordSubmissionOutcome(objectID, outcomeServerError)
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
+		}
+		if !ok {
+			return c.Status(403).JSON(fiber.Map{"error": "Invite token is invalid, expired or already used"})
+		}
+	}
+
+	// Enforce the global per-IP daily cap. Only submissions that got this far
+	// are counted, and the count is given back if the response isn't saved.
+	allowed, err := rc.allowIPSubmission(c, form)
+	if err != nil || !allowed {
+		if invite != nil {
+			rc.releaseInvite(invite)
+		}
+		if err != nil {
+			rc.recordSubmissionOutcome(objectID, outcomeServerError)
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
+		}
+		rc.recordSubmissionOutcome(objectID, outcomeRateLimited)
+		return c.Status(429).JSON(fiber.Map{"error": "Too many submissions from this address today"})
+	}
+
+	response.ID = primitive.NewObjectID()
+
+	// Forms that opt into editing hand the respondent a token to edit with
+	editToken := ""
+	if form.EditWindowMinutes > 0 && !req.Partial {
+		editToken = generateShareToken()
+		response.EditTokenHash = hashToken(editToken)
+	}
+
+	// Partial responses get a token to continue them with instead
+	resumeToken := ""
+	if req.Partial {
+		resumeToken = generateShareToken()
+		response.ResumeTokenHash = hashToken(resumeToken)
+	}
+
+	// Uploaded files are stored once the submission is accepted, and removed
+	// again if the response doesn't get saved
+	uploaded, err := storeUploads(context.Background(), objectID, req.Responses)
+	if err != nil {
+		if invite != nil {
+			rc.releaseInvite(invite)
+		}
+		rc.releaseIPSubmission(c)
+		rc.recordSubmissionOutcome(objectID, outcomeServerError)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store uploaded files"})
 	}
 
-	result, err := rc.responseCollection.InsertOne(context.Background(), response)
+	// Sensitive answers are stored encrypted; the caller still gets plaintext back
+	stored := response
+	stored.Files = uploadKeys(objectID, req.Responses)
+	stored.Responses, err = encryptResponses(req.Responses, form.Fields)
 	if err != nil {
+		removeUploads(uploaded)
+		if invite != nil {
+			rc.releaseInvite(invite)
+		}
+		rc.releaseIPSubmission(c)
+		rc.recordSubmissionOutcome(objectID, outcomeServerError)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to encrypt response"})
+	}
+
+	// Retry with a fresh receipt code in the unlikely event of a collision
+	var result *mongo.InsertOneResult
+	for attempt := 0; attempt < receiptCodeAttempts; attempt++ {
+		stored.ReceiptCode = generateReceiptCode()
+		result, err = rc.responseCollection.InsertOne(context.Background(), stored)
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+	if err != nil {
+		removeUploads(uploaded)
+		if invite != nil {
+			rc.releaseInvite(invite)
+		}
+		rc.releaseIPSubmission(c)
+		rc.recordSubmissionOutcome(objectID, outcomeServerError)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to submit response"})
 	}
+	response.ReceiptCode = stored.ReceiptCode
+	rc.recordSubmissionOutcome(objectID, outcomeSucceeded)
+	rc.counts.added(objectID)
 
 	response.ID = result.InsertedID.(primitive.ObjectID)
+	if invite != nil {
+		rc.completeInvite(invite, response.ID)
+	}
+
+	if resumeToken != "" {
+		return c.Status(201).JSON(fiber.Map{
+			"message":      "Response saved; submit again with the resume token to complete it",
+			"response":     response,
+			"incomplete":   true,
+			"resume_token": resumeToken,
+		})
+	}
 
-	// Broadcast new response via WebSocket
+	rc.announceSubmission(form, response)
+
+	// Plain HTML forms follow the confirmation redirect themselves
+	if target := formPostRedirect(c, form); target != "" {
+		return c.Redirect(target, fiber.StatusSeeOther)
+	}
+
+	// message, response, receipt_code and confirmation are always present;
+	// clients act on confirmation alone
+	payload := fiber.Map{
+		"message":      "Response submitted successfully",
+		"response":     response,
+		"receipt_code": response.ReceiptCode,
+		"confirmation": form.Confirmation(),
+	}
+	if editToken != "" {
+		payload["edit_token"] = editToken
+		payload["edit_expires_at"] = response.CreatedAt.Add(editWindow(form))
+	}
+
+	return c.Status(201).JSON(payload)
+}
+
+// announceSubmission tells everyone listening that a response was submitted
+// and refreshes the form's analytics. Responses awaiting approval are
+// announced to moderators instead, see announcePending.
+func (rc *ResponseController) announceSubmission(form models.Form, response models.FormResponse) {
+	id := form.ID.Hex()
+
+	if response.Approval == models.ApprovalPending {
+		rc.announcePending(form, response)
+		return
+	}
+
+	// Broadcast new response via WebSocket, without answers to encrypted fields
 	rc.hub.BroadcastToForm(id, "response_submitted", fiber.Map{
 		"form_id":  id,
-		"response": response,
+		"response": redactEncrypted(form, response),
 	})
 
+	// Notify the form's webhook, if any
+	dispatchWebhook(form, "response_submitted", &response.ID, fiber.Map{"response": redactEncrypted(form, response)})
+
+	// Alert whoever this submission's answers route to
+	notifySubmission(form, response, "response_submitted")
+
 	// Update analytics asynchronously
-	go rc.updateAnalytics(objectID)
+	rc.analytics.Schedule(form.ID)
+}
+
+// buildResponse validates a submission against the form and builds the
+// response document that would be stored for it. Submit and preview share it
+// so a preview is validated exactly like the real submission.
+func (rc *ResponseController) buildResponse(c *fiber.Ctx, form models.Form, req models.SubmitResponseRequest) (models.FormResponse, error) {
+	if err := rc.validateAnswers(req.Responses, form, req.Partial); err != nil {
+		return models.FormResponse{}, err
+	}
+	// Custom validators judge whole submissions, so they wait for completion
+	if !req.Partial {
+		if err := runSubmissionValidators(form, req.Responses); err != nil {
+			return models.FormResponse{}, err
+		}
+	}
+	if err := validateMetadata(req.Metadata, form.MetadataSchema); err != nil {
+		return models.FormResponse{}, err
+	}
+	source, err := submissionSource(c, req)
+	if err != nil {
+		return models.FormResponse{}, err
+	}
+
+	now := time.Now()
+	completion := form.CompletionPercent(req.Responses)
+	return models.FormResponse{
+		FormID:            form.ID,
+		Responses:         req.Responses,
+		Metadata:          req.Metadata,
+		IPAddress:         rc.storedIP(form, c.IP()),
+		Source:            source,
+		UserAgent:         c.Get("User-Agent"),
+		Consents:          consentTimestamps(req.Responses, form.Fields, now),
+		CompletionPercent: &completion,
+		Score:             form.ResponseScore(req.Responses),
+		Fingerprint:       form.AnswerFingerprint(req.Responses),
+		Geo:               form.GeoPoints(req.Responses),
+		Incomplete:        req.Partial,
+		Approval:          initialApproval(form),
+		CreatedAt:         now,
+	}, nil
+}
 
-	return c.Status(201).JSON(fiber.Map{
-		"message":  "Response submitted successfully",
+// PreviewResponse validates a submission and returns the response that would
+// be stored, without storing it, so respondents can review before submitting
+func (rc *ResponseController) PreviewResponse(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	// Previews accept the same bodies as submissions, uploads included
+	var req models.SubmitResponseRequest
+	formEncoded := isFormEncoded(c)
+	if formEncoded {
+		req, err = parseFormEncodedSubmission(c)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{
+		"_id":          objectID,
+		"is_published": true,
+	}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found or not published"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	if formEncoded {
+		if err := coerceFormEncodedAnswers(req.Responses, form.Fields); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error(), "valid": false})
+		}
+	}
+	if err := checkSubmittedFiles(req.Responses, form.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error(), "valid": false})
+	}
+
+	response, err := rc.buildResponse(c, form, req)
+	if err != nil {
+		body := validationErrorBody(err)
+		body["valid"] = false
+		return c.Status(400).JSON(body)
+	}
+	// Uploads aren't stored for a preview
+	response.Responses = previewUploads(response.Responses)
+
+	return c.JSON(fiber.Map{
+		"valid":    true,
 		"response": response,
 	})
 }
 
-// GetResponses gets all responses for a form
+// receiptCodeAlphabet is Crockford's base32, which avoids look-alike characters
+const (
+	receiptCodeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	receiptCodeLength   = 8
+	receiptCodeAttempts = 5
+)
+
+// generateReceiptCode generates a short human-friendly confirmation code
+func generateReceiptCode() string {
+	bytes := make([]byte, receiptCodeLength)
+	rand.Read(bytes)
+	code := make([]byte, receiptCodeLength)
+	for i, b := range bytes {
+		code[i] = receiptCodeAlphabet[int(b)%len(receiptCodeAlphabet)]
+	}
+	return string(code)
+}
+
+// GetResponseByReceipt looks up a response by its confirmation code
+func (rc *ResponseController) GetResponseByReceipt(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(c.Params("code")))
+
+	var response models.FormResponse
+	err = rc.responseCollection.FindOne(context.Background(), bson.M{
+		"form_id":      objectID,
+		"receipt_code": code,
+	}).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch response"})
+	}
+
+	decryptResponses(response.Responses)
+
+	return c.JSON(response)
+}
+
+// EditResponse lets a respondent correct their response within the form's
+// edit window, authorized by the edit token returned on submission
+func (rc *ResponseController) EditResponse(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var req models.SubmitResponseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{
+		"_id":          objectID,
+		"is_published": true,
+	}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found or not published"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	var response models.FormResponse
+	err = rc.responseCollection.FindOne(context.Background(), bson.M{
+		"_id":     responseID,
+		"form_id": objectID,
+	}).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch response"})
+	}
+
+	// Snapshot the answers as stored (still encrypted) before decrypting them
+	previous := make(map[string]interface{}, len(response.Responses))
+	for key, value := range response.Responses {
+		previous[key] = value
+	}
+	validFrom := response.CreatedAt
+	if response.UpdatedAt != nil {
+		validFrom = *response.UpdatedAt
+	}
+	snapshot := models.ResponseVersion{
+		Version:   response.EditCount + 1,
+		Responses: previous,
+		Consents:  response.Consents,
+		ValidFrom: validFrom,
+	}
+
+	decryptResponses(response.Responses)
+
+	token := c.Get("X-Edit-Token")
+	if token == "" || response.EditTokenHash == "" ||
+		subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(response.EditTokenHash)) != 1 {
+		return c.Status(403).JSON(fiber.Map{"error": "Invalid edit token"})
+	}
+
+	if form.EditWindowMinutes <= 0 {
+		return c.Status(403).JSON(fiber.Map{"error": "This form does not allow editing responses"})
+	}
+	deadline := response.CreatedAt.Add(editWindow(form))
+	if time.Now().After(deadline) {
+		return c.Status(403).JSON(fiber.Map{"error": "The edit window for this response has closed"})
+	}
+
+	if err := checkEditedFiles(req.Responses, response.Responses, form.Fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := rc.validateResponse(req.Responses, form); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := runSubmissionValidators(form, req.Responses); err != nil {
+		return c.Status(400).JSON(validationErrorBody(err))
+	}
+
+	// Keep the original acceptance time for consents that are still given
+	now := time.Now()
+	consents := consentTimestamps(req.Responses, form.Fields, now)
+	for fieldID := range consents {
+		if acceptedAt, ok := response.Consents[fieldID]; ok {
+			consents[fieldID] = acceptedAt
+		}
+	}
+
+	storedResponses, err := encryptResponses(req.Responses, form.Fields)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to encrypt response"})
+	}
+	completion := form.CompletionPercent(req.Responses)
+	score := form.ResponseScore(req.Responses)
+	fingerprint := form.AnswerFingerprint(req.Responses)
+	// Files dropped by the edit stay referenced by the response history
+	files := mergeKeys(response.Files, uploadKeys(objectID, req.Responses))
+
+	snapshot.ReplacedAt = now
+
+	changed := make([]string, 0)
+	for _, field := range form.Fields {
+		if !answersEqual(response.Responses[field.ID], req.Responses[field.ID]) {
+			changed = append(changed, field.ID)
+		}
+	}
+
+	set := bson.M{
+		"responses":          storedResponses,
+		"consents":           consents,
+		"completion_percent": completion,
+		"score":              score,
+		"fingerprint":        fingerprint,
+		"geo":                form.GeoPoints(req.Responses),
+		"files":              files,
+		"updated_at":         now,
+	}
+	// Remember the first answer to each field that changes for the first time
+	for _, fieldID := range changed {
+		if _, ok := response.OriginalAnswers[fieldID]; !ok {
+			set["original_answers."+fieldID] = models.OriginalAnswer{
+				Value:          previous[fieldID],
+				FirstChangedAt: now,
+			}
+		}
+	}
+
+	// Changed answers on a moderated form need approving again
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"edit_count": 1},
+		"$push": bson.M{"history": bson.M{
+			"$each":  bson.A{snapshot},
+			"$slice": -maxResponseVersions,
+		}},
+	}
+	reapprove := form.RequireApproval && len(changed) > 0
+	wasPending := response.Approval == models.ApprovalPending
+	if reapprove {
+		set["approval"] = models.ApprovalPending
+		update["$unset"] = bson.M{"approval_reason": "", "approval_decided_at": ""}
+	}
+
+	// Only apply the edit if nobody else edited since we read the response
+	filter := bson.M{"_id": responseID, "edit_count": response.EditCount}
+	if response.EditCount == 0 {
+		filter["edit_count"] = bson.M{"$exists": false}
+	}
+
+	result, err := rc.responseCollection.UpdateOne(context.Background(), filter, update)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update response"})
+	}
+	if result.MatchedCount == 0 {
+		return c.Status(409).JSON(fiber.Map{"error": "Response was edited concurrently, please retry"})
+	}
+
+	recordAudit(c, "response_edited", objectID, &responseID, changed)
+
+	response.Responses = req.Responses
+	response.Consents = consents
+	response.CompletionPercent = &completion
+	response.Score = score
+	response.Fingerprint = fingerprint
+	response.UpdatedAt = &now
+	response.EditCount++
+	if reapprove {
+		response.Approval = models.ApprovalPending
+		response.ApprovalReason = ""
+		response.ApprovalDecidedAt = nil
+	}
+
+	rc.hub.BroadcastToForm(id, "response_updated", fiber.Map{
+		"form_id":  id,
+		"response": redactEncrypted(form, response),
+	})
+	// Moderators already know about responses that were still pending
+	if reapprove && !wasPending {
+		rc.announcePending(form, response)
+	}
+
+	rc.analytics.Schedule(objectID)
+
+	return c.JSON(fiber.Map{
+		"message":         "Response updated successfully",
+		"response":        response,
+		"edit_expires_at": deadline,
+	})
+}
+
+// maxResponseVersions caps how many prior versions are kept per response
+const maxResponseVersions = 20
+
+// GetResponseHistory lists a response's versions, oldest first, each with a
+// field-level diff against the version before it. Versions older than the
+// retention cap are no longer available.
+func (rc *ResponseController) GetResponseHistory(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	var response models.FormResponse
+	err = rc.responseCollection.FindOne(context.Background(), bson.M{
+		"_id":     responseID,
+		"form_id": objectID,
+	}).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch response"})
+	}
+
+	// The current answers are the latest version
+	validFrom := response.CreatedAt
+	if response.UpdatedAt != nil {
+		validFrom = *response.UpdatedAt
+	}
+	versions := append(response.History, models.ResponseVersion{
+		Version:   response.EditCount + 1,
+		Responses: response.Responses,
+		Consents:  response.Consents,
+		ValidFrom: validFrom,
+	})
+
+	labels := make(map[string]string, len(form.Fields))
+	for _, field := range form.Fields {
+		labels[field.ID] = field.Label
+	}
+
+	history := make([]fiber.Map, 0, len(versions))
+	var before map[string]interface{}
+	for i, version := range versions {
+		decryptResponses(version.Responses)
+
+		entry := fiber.Map{
+			"version":    version.Version,
+			"responses":  version.Responses,
+			"consents":   version.Consents,
+			"valid_from": version.ValidFrom,
+			"current":    i == len(versions)-1,
+		}
+		if !version.ReplacedAt.IsZero() {
+			entry["replaced_at"] = version.ReplacedAt
+		}
+		if before != nil {
+			entry["changes"] = diffAnswers(before, version.Responses, labels)
+		}
+		history = append(history, entry)
+		before = version.Responses
+	}
+
+	return c.JSON(fiber.Map{
+		"response_id":   responseID.Hex(),
+		"edit_count":    response.EditCount,
+		"versions":      history,
+		"field_changes": fieldChanges(response, form.Fields),
+	})
+}
+
+// fieldChanges lists, in form order, each field the respondent has changed
+// with its originally submitted and current answers. Fields no longer on
+// the form follow, sorted by ID. It expects the current answers decrypted.
+func fieldChanges(response models.FormResponse, fields []models.FormField) []fiber.Map {
+	originals := make(map[string]interface{}, len(response.OriginalAnswers))
+	for fieldID, original := range response.OriginalAnswers {
+		originals[fieldID] = original.Value
+	}
+	decryptResponses(originals)
+
+	ordered := fieldsInFormOrder(fields)
+
+	fieldIDs := make([]string, 0, len(originals))
+	labels := make(map[string]string, len(ordered))
+	for _, field := range ordered {
+		labels[field.ID] = field.Label
+		if _, ok := originals[field.ID]; ok {
+			fieldIDs = append(fieldIDs, field.ID)
+		}
+	}
+	var removed []string
+	for fieldID := range originals {
+		if _, ok := labels[fieldID]; !ok {
+			removed = append(removed, fieldID)
+		}
+	}
+	sort.Strings(removed)
+	fieldIDs = append(fieldIDs, removed...)
+
+	changes := make([]fiber.Map, 0, len(fieldIDs))
+	for _, fieldID := range fieldIDs {
+		label, ok := labels[fieldID]
+		if !ok {
+			label = deletedFieldLabel
+		}
+		changes = append(changes, fiber.Map{
+			"field_id":         fieldID,
+			"label":            label,
+			"original":         originals[fieldID],
+			"current":          response.Responses[fieldID],
+			"first_changed_at": response.OriginalAnswers[fieldID].FirstChangedAt,
+		})
+	}
+	return changes
+}
+
+// diffAnswers lists the fields whose answers differ between two versions,
+// sorted by field ID
+func diffAnswers(before, after map[string]interface{}, labels map[string]string) []fiber.Map {
+	fieldIDs := make([]string, 0, len(before)+len(after))
+	seen := make(map[string]bool, len(before)+len(after))
+	for _, answers := range []map[string]interface{}{before, after} {
+		for fieldID := range answers {
+			if !seen[fieldID] {
+				seen[fieldID] = true
+				fieldIDs = append(fieldIDs, fieldID)
+			}
+		}
+	}
+	sort.Strings(fieldIDs)
+
+	changes := make([]fiber.Map, 0)
+	for _, fieldID := range fieldIDs {
+		if answersEqual(before[fieldID], after[fieldID]) {
+			continue
+		}
+		label, ok := labels[fieldID]
+		if !ok {
+			label = deletedFieldLabel
+		}
+		changes = append(changes, fiber.Map{
+			"field_id":    fieldID,
+			"field_label": label,
+			"old":         before[fieldID],
+			"new":         after[fieldID],
+		})
+	}
+	return changes
+}
+
+// answersEqual compares two answers by their JSON form, so values decoded
+// from Mongo (e.g. primitive.A) compare equal to freshly parsed request values
+func answersEqual(a, b interface{}) bool {
+	aJSON, errA := json.Marshal(a)
+	bJSON, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aJSON) == string(bJSON)
+}
+
+// editWindow returns how long responses to the form stay editable
+func editWindow(form models.Form) time.Duration {
+	return time.Duration(form.EditWindowMinutes) * time.Minute
+}
+
+// hashToken hashes a bearer token (edit or invite) for storage
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// allowIPSubmission counts a submission to form against the caller's daily
+// per-IP budget and reports whether it is still within the cap. Internal
+// callers presenting INTERNAL_API_TOKEN are exempt.
+func (rc *ResponseController) allowIPSubmission(c *fiber.Ctx, form models.Form) (bool, error) {
+	if !rc.countsIPSubmissions(c) {
+		return true, nil
+	}
+
+	now := time.Now().UTC()
+	day := now.Format("2006-01-02")
+	nextDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(24 * time.Hour)
+
+	var counter struct {
+		Count int64 `bson:"count"`
+	}
+	// Counters are keyed by a keyed hash of the address, so the cap is the
+	// same across forms; the address itself is only kept for forms that
+	// store full IPs
+	setOnInsert := bson.M{"day": day, "expires_at": nextDay}
+	if rc.formIPStorage(form) == models.IPStorageFull {
+		setOnInsert["ip"] = c.IP()
+	}
+	err := rc.ipCounterCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": ipCounterID(c.IP(), day)},
+		bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": setOnInsert,
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return false, err
+	}
+
+	return counter.Count <= rc.maxSubmissionsPerIP, nil
+}
+
+// releaseIPSubmission gives back a submission counted by allowIPSubmission
+// whose response wasn't saved after all
+func (rc *ResponseController) releaseIPSubmission(c *fiber.Ctx) {
+	if !rc.countsIPSubmissions(c) {
+		return
+	}
+	day := time.Now().UTC().Format("2006-01-02")
+	_, err := rc.ipCounterCollection.UpdateOne(context.Background(),
+		bson.M{"_id": ipCounterID(c.IP(), day), "count": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"count": -1}},
+	)
+	if err != nil {
+		log.Printf("Failed to release IP submission count: %v", err)
+	}
+}
+
+// countsIPSubmissions reports whether the caller's submissions count against
+// the per-IP daily cap: the cap is set and they didn't present
+// INTERNAL_API_TOKEN
+func (rc *ResponseController) countsIPSubmissions(c *fiber.Ctx) bool {
+	if rc.maxSubmissionsPerIP <= 0 {
+		return false
+	}
+	return rc.internalToken == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-Internal-Token")), []byte(rc.internalToken)) != 1
+}
+
+// ipCounterID is the _id of an address's per-IP counter for a day
+func ipCounterID(ip, day string) string {
+	return hashIP(ip) + "|" + day
+}
+
+// GetResponses gets all responses for a form.
+//
+// Offset pagination (page/limit) shifts when new responses arrive between page
+// fetches. Clients that page through a live form should use the cursor mode
+// instead: pass the returned next_cursor back as ?cursor=, together with the
+// returned as_of snapshot, so every page reflects the same view of the data.
 func (rc *ResponseController) GetResponses(c *fiber.Ctx) error {
 	id := c.Params("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -118,23 +945,104 @@ func (rc *ResponseController) GetResponses(c *fiber.Ctx) error {
 		limit = 50
 	}
 
-	skip := (page - 1) * limit
+	filter := bson.M{"form_id": objectID}
 
-	// Get total count
-	total, err := rc.responseCollection.CountDocuments(context.Background(), bson.M{"form_id": objectID})
+	// Snapshot: only include responses created up to as_of
+	var asOf time.Time
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		asOf, err = time.Parse(time.RFC3339Nano, asOfStr)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid as_of timestamp"})
+		}
+	} else {
+		asOf = time.Now()
+	}
+	filter["created_at"] = bson.M{"$lte": asOf}
+
+	// Completion filters skip responses stored before completion was tracked
+	completion := bson.M{}
+	for param, operator := range map[string]string{"min_completion": "$gte", "max_completion": "$lte"} {
+		if value := c.Query(param); value != "" {
+			percent, err := strconv.ParseFloat(value, 64)
+			if err != nil || percent < 0 || percent > 100 {
+				return c.Status(400).JSON(fiber.Map{"error": "Invalid " + param + " parameter"})
+			}
+			completion[operator] = percent
+		}
+	}
+	if len(completion) > 0 {
+		filter["completion_percent"] = completion
+	}
+	if source := c.Query("source"); source != "" {
+		filter["source"] = sourceFilter(source)
+	}
+	if approval := c.Query("approval"); approval != "" {
+		switch models.ApprovalState(approval) {
+		case models.ApprovalPending, models.ApprovalApproved, models.ApprovalRejected:
+			filter["approval"] = approval
+		default:
+			return c.Status(400).JSON(fiber.Map{"error": "Approval must be pending, approved or rejected"})
+		}
+	}
+
+	sortByCompletion := false
+	switch c.Query("sort") {
+	case "", "created_at":
+	case "completion":
+		sortByCompletion = true
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Sort must be created_at or completion"})
+	}
+
+	// Unfiltered lists reuse a recent total unless ?count=exact asks for a
+	// fresh one; filtered lists are always counted
+	exactCount := true
+	switch c.Query("count") {
+	case "", "estimate":
+		exactCount = c.Query("as_of") != "" || len(completion) > 0 || c.Query("source") != "" || c.Query("approval") != ""
+	case "exact":
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Count must be exact or estimate"})
+	}
+
+	var total int64
+	totalExact := true
+	if exactCount {
+		total, err = rc.responseCollection.CountDocuments(context.Background(), filter)
+	} else {
+		total, totalExact, err = rc.counts.total(context.Background(), rc.responseCollection, objectID)
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to count responses"})
 	}
 
+	findOptions := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursorStr := c.Query("cursor")
+	if sortByCompletion {
+		// Cursors follow submission order, so completion order pages by number
+		if cursorStr != "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Cursor pagination is not supported when sorting by completion"})
+		}
+		findOptions.SetSort(bson.D{{Key: "completion_percent", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	}
+	if cursorStr != "" {
+		cursorTime, cursorID, err := decodeResponseCursor(cursorStr)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid cursor"})
+		}
+		filter["$or"] = []bson.M{
+			{"created_at": bson.M{"$lt": cursorTime}},
+			{"created_at": cursorTime, "_id": bson.M{"$lt": cursorID}},
+		}
+	} else {
+		findOptions.SetSkip(int64((page - 1) * limit))
+	}
+
 	// Get responses with pagination
-	cursor, err := rc.responseCollection.Find(
-		context.Background(),
-		bson.M{"form_id": objectID},
-		options.Find().
-			SetSkip(int64(skip)).
-			SetLimit(int64(limit)).
-			SetSort(bson.D{{Key: "created_at", Value: -1}}),
-	)
+	cursor, err := rc.responseCollection.Find(context.Background(), filter, findOptions)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch responses"})
 	}
@@ -145,12 +1053,161 @@ func (rc *ResponseController) GetResponses(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode responses"})
 	}
 
-	if responses == nil {
-		responses = []models.FormResponse{}
+	if responses == nil {
+		responses = []models.FormResponse{}
+	}
+
+	for i := range responses {
+		decryptResponses(responses[i].Responses)
+	}
+
+	pagination := fiber.Map{
+		"page":        page,
+		"limit":       limit,
+		"total":       total,
+		"total_exact": totalExact,
+		"totalPages":  (total + int64(limit) - 1) / int64(limit),
+		"as_of":       asOf.Format(time.RFC3339Nano),
+	}
+	if len(responses) == limit && !sortByCompletion {
+		last := responses[len(responses)-1]
+		pagination["next_cursor"] = encodeResponseCursor(last.CreatedAt, last.ID)
+	}
+
+	return c.JSON(fiber.Map{
+		"responses":  responses,
+		"pagination": pagination,
+	})
+}
+
+// CountResponses counts responses matching field-value filters and a date
+// range without fetching them, e.g. ?<fieldId>=<value>&from=2024-01-01
+func (rc *ResponseController) CountResponses(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	answers := c.Queries()
+	from, to := answers["from"], answers["to"]
+	delete(answers, "from")
+	delete(answers, "to")
+
+	filter, err := buildResponseFilter(form, answers, from, to)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	count, err := rc.responseCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to count responses"})
+	}
+
+	return c.JSON(fiber.Map{"count": count})
+}
+
+// GetFieldValues lists the distinct answers a field has received with how
+// often each occurs, most common first. Checkbox answers are counted per
+// selected option.
+func (rc *ResponseController) GetFieldValues(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	fieldID := c.Params("fieldId")
+	var field *models.FormField
+	for i := range form.Fields {
+		if form.Fields[i].ID == fieldID {
+			field = &form.Fields[i]
+			break
+		}
+	}
+	if field == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Field not found"})
+	}
+	if field.Encrypted {
+		return c.Status(400).JSON(fiber.Map{"error": "Values of encrypted fields can't be listed"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	path := "$responses." + fieldID
+	pipeline := []bson.M{
+		{"$match": bson.M{"form_id": objectID}},
+		{"$unwind": path},
+		{"$match": bson.M{"responses." + fieldID: bson.M{"$nin": bson.A{nil, ""}}}},
+		{"$group": bson.M{"_id": path, "count": bson.M{"$sum": 1}}},
+		{"$facet": bson.M{
+			"values": []bson.M{
+				{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				{"$skip": (page - 1) * limit},
+				{"$limit": limit},
+			},
+			"total": []bson.M{{"$count": "count"}},
+		}},
+	}
+
+	cursor, err := rc.responseCollection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch field values"})
+	}
+	defer cursor.Close(context.Background())
+
+	var results []struct {
+		Values []struct {
+			Value interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"values"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+	if err := cursor.All(context.Background(), &results); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode field values"})
+	}
+
+	values := make([]fiber.Map, 0)
+	var total int64
+	if len(results) > 0 {
+		for _, value := range results[0].Values {
+			values = append(values, fiber.Map{"value": value.Value, "count": value.Count})
+		}
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Count
+		}
 	}
 
 	return c.JSON(fiber.Map{
-		"responses": responses,
+		"field_id": fieldID,
+		"values":   values,
 		"pagination": fiber.Map{
 			"page":       page,
 			"limit":      limit,
@@ -160,6 +1217,37 @@ func (rc *ResponseController) GetResponses(c *fiber.Ctx) error {
 	})
 }
 
+// encodeResponseCursor encodes a created_at/_id position as an opaque cursor
+func encodeResponseCursor(createdAt time.Time, id primitive.ObjectID) string {
+	raw := strconv.FormatInt(createdAt.UnixMilli(), 10) + "_" + id.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeResponseCursor decodes a cursor produced by encodeResponseCursor
+func decodeResponseCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+
+	parts := strings.SplitN(string(raw), "_", 2)
+	if len(parts) != 2 {
+		return time.Time{}, primitive.NilObjectID, fiber.ErrBadRequest
+	}
+
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, err
+	}
+
+	return time.UnixMilli(millis), id, nil
+}
+
 // GetAnalytics gets analytics for a form
 func (rc *ResponseController) GetAnalytics(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -178,21 +1266,153 @@ func (rc *ResponseController) GetAnalytics(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
 	}
 
-	analytics, err := rc.calculateAnalytics(objectID, form.Fields)
+	opts := defaultAnalyticsOptions(form)
+	if includeDeleted, err := strconv.ParseBool(c.Query("include_deleted", "true")); err == nil {
+		opts.IncludeDeletedFields = includeDeleted
+	}
+	if include := c.Query("include_incomplete"); include != "" {
+		includeIncomplete, err := strconv.ParseBool(include)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid include_incomplete parameter"})
+		}
+		opts.IncludeIncomplete = includeIncomplete
+	}
+	if topN := c.Query("topN"); topN != "" {
+		n, err := strconv.Atoi(topN)
+		if err != nil || n < 1 {
+			return c.Status(400).JSON(fiber.Map{"error": "topN must be a positive integer"})
+		}
+		if n > maxAnalyticsTopN {
+			n = maxAnalyticsTopN
+		}
+		opts.TopN = n
+	}
+	if sample := c.Query("sample"); sample != "" {
+		sampleSize, err := strconv.ParseInt(sample, 10, 64)
+		if err != nil || sampleSize < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid sample parameter"})
+		}
+		opts.SampleSize = sampleSize
+	}
+
+	analytics, err := rc.computeAnalytics(form, opts)
+	if errors.Is(err, errAnalyticsBusy) && reflect.DeepEqual(opts, defaultAnalyticsOptions(form)) {
+		// Serve the last background recompute rather than turning the caller
+		// away; it was calculated with the default options, so it only stands
+		// in for requests that don't ask for others
+		var cached models.FormAnalytics
+		if rc.analyticsCollection.FindOne(context.Background(), bson.M{"form_id": objectID}).Decode(&cached) == nil {
+			c.Set("X-Analytics-Cached", cached.UpdatedAt.UTC().Format(time.RFC3339))
+			return c.JSON(cached.FieldAnalytics)
+		}
+	}
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to calculate analytics"})
+		return sendAnalyticsError(c, err)
 	}
 
 	return c.JSON(analytics.FieldAnalytics)
 }
 
-// validateResponse validates a response against form fields
-func (rc *ResponseController) validateResponse(responses map[string]interface{}, fields []models.FormField) error {
+// Bounds on the shape of a submitted responses map
+const (
+	maxResponseKeys      = 500
+	maxAnswerArrayLength = 1000
+)
+
+// validateResponseShape keeps stored answers in the shape analytics relies
+// on: every answer is a scalar or a flat array of scalars, except answers to
+// location fields, which are objects.
+func validateResponseShape(responses map[string]interface{}, fields []models.FormField) error {
+	if len(responses) > maxResponseKeys {
+		return fiber.NewError(400, fmt.Sprintf("Too many answers (max %d)", maxResponseKeys))
+	}
+
+	// Some types (e.g. location) take objects; validateResponse checks their contents
+	objects := make(map[string]bool)
 	for _, field := range fields {
+		if handler, ok := fieldTypeHandler(field.Type); ok && handler.ObjectAnswers {
+			objects[field.ID] = true
+		}
+	}
+
+	for key, value := range responses {
+		if objects[key] {
+			continue
+		}
+
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case primitive.A:
+			items = v
+		default:
+			if !isScalarAnswer(value) {
+				return fiber.NewError(400, "Answer '"+key+"' must be a single value or a list of values")
+			}
+			continue
+		}
+
+		if len(items) > maxAnswerArrayLength {
+			return fiber.NewError(400, fmt.Sprintf("Answer '%s' has too many values (max %d)", key, maxAnswerArrayLength))
+		}
+		for _, item := range items {
+			if !isScalarAnswer(item) {
+				return fiber.NewError(400, "Answer '"+key+"' may not contain nested values")
+			}
+		}
+	}
+
+	return nil
+}
+
+// isScalarAnswer reports whether a decoded answer is a plain value
+func isScalarAnswer(value interface{}) bool {
+	switch value.(type) {
+	case nil, string, bool, float64, float32, int, int32, int64:
+		return true
+	}
+	return false
+}
+
+// validateResponse validates a response against form fields
+func (rc *ResponseController) validateResponse(responses map[string]interface{}, form models.Form) error {
+	return rc.validateAnswers(responses, form, false)
+}
+
+// validateAnswers validates answers against form fields. Partial answers,
+// saved to finish later, skip required checks but are otherwise validated
+// in full so nothing invalid is stored.
+func (rc *ResponseController) validateAnswers(responses map[string]interface{}, form models.Form, partial bool) error {
+	if err := validateResponseShape(responses, form.Fields); err != nil {
+		return err
+	}
+
+	// Transforms run first so required checks, length limits and conditions
+	// on other fields all see the values that are stored
+	for _, field := range form.Fields {
+		if value, exists := responses[field.ID]; exists && len(field.Transforms) > 0 {
+			responses[field.ID] = field.ApplyTransforms(value)
+		}
+	}
+
+	for _, field := range form.Fields {
 		value, exists := responses[field.ID]
 
+		// Read-only and disabled fields can't be altered by the respondent
+		if field.ReadOnly || field.Disabled {
+			if exists && value != nil && !answersEqual(value, field.DefaultValue) {
+				return fiber.NewError(400, "Field '"+field.Label+"' cannot be changed")
+			}
+			if field.ReadOnly && field.DefaultValue != nil {
+				responses[field.ID] = field.DefaultValue
+				value, exists = field.DefaultValue, true
+			}
+		}
+
 		// Check required fields
-		if field.Required && (!exists || value == nil || value == "") {
+		required := !partial && field.IsRequired(responses)
+		if required && field.Type != models.FieldTypeHidden && (!exists || value == nil || value == "") {
 			return fiber.NewError(400, "Field '"+field.Label+"' is required")
 		}
 
@@ -200,45 +1420,70 @@ func (rc *ResponseController) validateResponse(responses map[string]interface{},
 			continue
 		}
 
-		// Type-specific validation
-		switch field.Type {
-		case models.FieldTypeEmail:
-			if str, ok := value.(string); ok && str != "" {
-				// Basic email validation
-				if !isValidEmail(str) {
-					return fiber.NewError(400, "Invalid email format for field '"+field.Label+"'")
-				}
+		// Type-specific validation, which may normalize the stored value
+		if handler, ok := fieldTypeHandler(field.Type); ok && handler.Validate != nil {
+			stored, err := handler.Validate(field, value, required)
+			if err != nil {
+				return err
 			}
-		case models.FieldTypeNumber:
-			if num, ok := value.(float64); ok {
-				if field.Validation.Min != 0 && num < field.Validation.Min {
-					return fiber.NewError(400, "Value too low for field '"+field.Label+"'")
-				}
-				if field.Validation.Max != 0 && num > field.Validation.Max {
-					return fiber.NewError(400, "Value too high for field '"+field.Label+"'")
-				}
-			}
-		case models.FieldTypeText, models.FieldTypeTextarea:
-			if str, ok := value.(string); ok {
-				if field.Validation.MinLength > 0 && len(str) < field.Validation.MinLength {
-					return fiber.NewError(400, "Text too short for field '"+field.Label+"'")
-				}
-				if field.Validation.MaxLength > 0 && len(str) > field.Validation.MaxLength {
-					return fiber.NewError(400, "Text too long for field '"+field.Label+"'")
-				}
+			responses[field.ID] = stored
+		}
+	}
+
+	if partial {
+		return nil
+	}
+	return validateAtLeastOne(responses, form)
+}
+
+// validateAtLeastOne checks the form's "at least one of" field groups.
+// Fields that have since been removed from the form are ignored.
+func validateAtLeastOne(responses map[string]interface{}, form models.Form) error {
+	labels := make(map[string]string, len(form.Fields))
+	for _, field := range form.Fields {
+		labels[field.ID] = field.Label
+	}
+
+	for _, group := range form.RequireAtLeastOne {
+		names := make([]string, 0, len(group.FieldIDs))
+		satisfied := false
+		for _, fieldID := range group.FieldIDs {
+			label, ok := labels[fieldID]
+			if !ok {
+				continue
 			}
-		case models.FieldTypeRating:
-			if num, ok := value.(float64); ok {
-				if num < 1 || num > 5 {
-					return fiber.NewError(400, "Rating must be between 1 and 5 for field '"+field.Label+"'")
-				}
+			names = append(names, "'"+label+"'")
+			if !isEmptyAnswer(responses[fieldID]) {
+				satisfied = true
+				break
 			}
 		}
+
+		if !satisfied && len(names) > 0 {
+			return fiber.NewError(400, "Please fill in at least one of "+strings.Join(names, ", "))
+		}
 	}
 
 	return nil
 }
 
+// consentTimestamps records when each accepted consent field was agreed to
+func consentTimestamps(responses map[string]interface{}, fields []models.FormField, at time.Time) map[string]time.Time {
+	var consents map[string]time.Time
+	for _, field := range fields {
+		if field.Type != models.FieldTypeConsent {
+			continue
+		}
+		if accepted, ok := responses[field.ID].(bool); ok && accepted {
+			if consents == nil {
+				consents = make(map[string]time.Time)
+			}
+			consents[field.ID] = at
+		}
+	}
+	return consents
+}
+
 // isValidEmail performs basic email validation
 func isValidEmail(email string) bool {
 	// Basic email validation - in production, use a proper email validation library
@@ -260,18 +1505,168 @@ func countChar(s string, c rune) int {
 	return count
 }
 
-// calculateAnalytics calculates comprehensive analytics for a form
-func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fields []models.FormField) (*models.FormAnalytics, error) {
-	ctx := context.Background()
+// analyticsOptions tunes what calculateAnalytics computes
+type analyticsOptions struct {
+	// IncludeDeletedFields reports answers to fields no longer in the form
+	IncludeDeletedFields bool
+	// SampleSize estimates completion metrics from a random sample of this
+	// many responses once a form has more; 0 always uses every response
+	SampleSize int64
+	// TopN is how many of the most common answers each field lists; 0 keeps
+	// the per-type defaults
+	TopN int
+	// IncludeIncomplete counts responses missing required answers in totals,
+	// trends and field distributions; completion metrics always count them
+	IncludeIncomplete bool
+	// RequiredFields are the IDs of the fields the form currently always
+	// requires, which responses count as incomplete without
+	RequiredFields []string
+}
+
+// uncounted returns the filters for responses left out of totals, trends and
+// distributions, for use as "$nor": opts.uncounted()
+func (opts analyticsOptions) uncounted() bson.A {
+	if opts.IncludeIncomplete || len(opts.RequiredFields) == 0 {
+		return uncountedResponses
+	}
+	return append(append(bson.A{}, uncountedResponses...), missingAnswers(opts.RequiredFields))
+}
+
+// missingAnswers matches responses without an answer to any of the given
+// fields. It is checked against the form as it is now, so responses submitted
+// before a field became required count as incomplete; when they were stored,
+// validation made sure they answered what was required then.
+func missingAnswers(fieldIDs []string) bson.M {
+	missing := make(bson.A, 0, len(fieldIDs))
+	for _, fieldID := range fieldIDs {
+		missing = append(missing, bson.M{"responses." + fieldID: bson.M{"$in": bson.A{nil, "", bson.A{}}}})
+	}
+	return bson.M{"$or": missing}
+}
+
+// requiredFieldIDs lists the fields of a form that are always required
+func requiredFieldIDs(fields []models.FormField) []string {
+	ids := []string{}
+	for _, field := range fields {
+		if field.AlwaysRequired() {
+			ids = append(ids, field.ID)
+		}
+	}
+	return ids
+}
+
+// Most common answers listed per field: the defaults when no topN is
+// requested, and the most a caller may ask for
+const (
+	defaultChoiceTopN = 10
+	defaultTextTopN   = 5
+	maxAnalyticsTopN  = 100
+)
+
+// topNOr returns the requested top-N limit, or def when none was requested
+func (opts analyticsOptions) topNOr(def int) int {
+	if opts.TopN > 0 {
+		return opts.TopN
+	}
+	return def
+}
+
+// defaultAnalyticsOptions returns the options used for a form when a caller
+// doesn't override them; ANALYTICS_SAMPLE_SIZE enables sampling for large
+// forms
+func defaultAnalyticsOptions(form models.Form) analyticsOptions {
+	sampleSize, _ := strconv.ParseInt(os.Getenv("ANALYTICS_SAMPLE_SIZE"), 10, 64)
+	if sampleSize < 0 {
+		sampleSize = 0
+	}
+	includeIncomplete := defaultIncludeIncomplete()
+	if form.AnalyticsIncludeIncomplete != nil {
+		includeIncomplete = *form.AnalyticsIncludeIncomplete
+	}
+	return analyticsOptions{
+		IncludeDeletedFields: true,
+		SampleSize:           sampleSize,
+		IncludeIncomplete:    includeIncomplete,
+		RequiredFields:       requiredFieldIDs(form.Fields),
+	}
+}
+
+// defaultIncludeIncomplete reports whether analytics count responses missing
+// required answers for forms that don't decide themselves. They do unless
+// ANALYTICS_INCLUDE_INCOMPLETE is false.
+func defaultIncludeIncomplete() bool {
+	include, err := strconv.ParseBool(os.Getenv("ANALYTICS_INCLUDE_INCOMPLETE"))
+	return err != nil || include
+}
+
+// defaultAnalyticsTimeout bounds a single analytics calculation unless
+// ANALYTICS_TIMEOUT overrides it
+const defaultAnalyticsTimeout = 30 * time.Second
+
+// errAnalyticsTimeout is returned when analytics couldn't be calculated in time
+var errAnalyticsTimeout = errors.New("analytics calculation timed out")
+
+// analyticsTimeout returns the configured analytics deadline
+func analyticsTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("ANALYTICS_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return defaultAnalyticsTimeout
+	}
+	return timeout
+}
+
+// computeAnalytics runs calculateAnalytics under the analytics deadline. All
+// aggregations share the deadline, so a pathological form fails with
+// errAnalyticsTimeout instead of tying up the caller. It first waits for one
+// of the limited analytics slots and fails with errAnalyticsBusy if none
+// frees up in time.
+func (rc *ResponseController) computeAnalytics(form models.Form, opts analyticsOptions) (*models.FormAnalytics, error) {
+	if !rc.analyticsLimit.acquire() {
+		return nil, errAnalyticsBusy
+	}
+	defer rc.analyticsLimit.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), analyticsTimeout())
+	defer cancel()
+
+	analytics, err := rc.calculateAnalytics(ctx, form.ID, form.Fields, form.Sections, opts)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return nil, errAnalyticsTimeout
+	}
+	return analytics, err
+}
+
+// sendAnalyticsError reports a failed analytics calculation, asking the
+// client to retry later when it ran out of time
+func sendAnalyticsError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errAnalyticsBusy) {
+		c.Set(fiber.HeaderRetryAfter, "10")
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Too many analytics calculations are running",
+			"hint":  "Try again shortly",
+		})
+	}
+	if errors.Is(err, errAnalyticsTimeout) {
+		c.Set(fiber.HeaderRetryAfter, "60")
+		return c.Status(503).JSON(fiber.Map{
+			"error": "Analytics took too long to calculate",
+			"hint":  "Try again later; analytics are also recomputed in the background after submissions and served from cache by the form overview",
+		})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "Failed to calculate analytics"})
+}
 
+// calculateAnalytics calculates comprehensive analytics for a form
+func (rc *ResponseController) calculateAnalytics(ctx context.Context, formID primitive.ObjectID, fields []models.FormField, sections []models.FormSection, opts analyticsOptions) (*models.FormAnalytics, error) {
 	// Calculate time ranges
 	now := time.Now()
 	last24h := now.Add(-24 * time.Hour)
 	lastWeek := now.Add(-7 * 24 * time.Hour)
 	lastMonth := now.Add(-30 * 24 * time.Hour)
+	uncounted := opts.uncounted()
 
 	// Total responses
-	total, err := rc.responseCollection.CountDocuments(ctx, bson.M{"form_id": formID})
+	total, err := rc.responseCollection.CountDocuments(ctx, bson.M{"form_id": formID, "$nor": uncounted})
 	if err != nil {
 		return nil, err
 	}
@@ -279,6 +1674,7 @@ func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fiel
 	// Responses in last 24 hours
 	count24h, err := rc.responseCollection.CountDocuments(ctx, bson.M{
 		"form_id":    formID,
+		"$nor":       uncounted,
 		"created_at": bson.M{"$gte": last24h},
 	})
 	if err != nil {
@@ -288,6 +1684,7 @@ func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fiel
 	// Responses in last week
 	countWeek, err := rc.responseCollection.CountDocuments(ctx, bson.M{
 		"form_id":    formID,
+		"$nor":       uncounted,
 		"created_at": bson.M{"$gte": lastWeek},
 	})
 	if err != nil {
@@ -297,6 +1694,7 @@ func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fiel
 	// Responses in last month
 	countMonth, err := rc.responseCollection.CountDocuments(ctx, bson.M{
 		"form_id":    formID,
+		"$nor":       uncounted,
 		"created_at": bson.M{"$gte": lastMonth},
 	})
 	if err != nil {
@@ -304,25 +1702,73 @@ func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fiel
 	}
 
 	// Calculate response trends (last 7 days)
-	responseTrends, err := rc.calculateResponseTrends(formID)
+	responseTrends, err := rc.calculateResponseTrends(ctx, formID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Responses per submission channel
+	sources, err := rc.responsesBySource(ctx, formID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Calculate completion rate and average time, sampling large forms if configured
+	var sampleSize int64
+	if opts.SampleSize > 0 && total > opts.SampleSize {
+		sampleSize = opts.SampleSize
+	}
+	completionRate, avgTime, err := rc.calculateCompletionMetrics(ctx, formID, fields, sampleSize)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate completion rate and average time
-	completionRate, avgTime, err := rc.calculateCompletionMetrics(formID, fields)
+	// Where incomplete responses stop, in field order
+	dropOff, err := rc.calculateDropOff(ctx, formID, fields)
 	if err != nil {
 		return nil, err
 	}
 
-	// Field-specific analytics with enhanced metrics
-	fieldAnalytics := make([]interface{}, 0)
+	// Field-specific analytics with enhanced metrics, one entry per field in
+	// form order so the dashboard's list always matches the form
+	ordered := fieldsInFormOrder(fields)
 
-	for _, field := range fields {
-		analytics, err := rc.calculateEnhancedFieldAnalytics(formID, field, int(total))
+	// Time spent per field, reported by clients over the WebSocket. It's
+	// optional, so without it fields are reported without time_spent.
+	timings, err := rc.fieldTimings(ctx, formID)
+	if err != nil {
+		log.Printf("Failed to fetch field timings for form %s: %v", formID.Hex(), err)
+	}
+
+	fieldAnalytics := make([]interface{}, 0, len(ordered))
+	entries := make(map[string]fiber.Map, len(ordered))
+	for _, field := range ordered {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		entry := rc.fieldAnalyticsEntry(ctx, formID, field, int(total), opts)
+		if timing, ok := timings[field.ID]; ok {
+			entry["time_spent"] = timing
+		}
+		entries[field.ID] = entry
+		fieldAnalytics = append(fieldAnalytics, entry)
+	}
+
+	// Answers to fields that were removed from the form are still reported
+	var deletedFieldIDs []string
+	if opts.IncludeDeletedFields {
+		deletedFieldIDs, err = rc.findDeletedFieldIDs(ctx, formID, fields, opts)
 		if err != nil {
-			continue // Skip field if error calculating analytics
+			return nil, err
+		}
+	}
+	for _, fieldID := range deletedFieldIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
+		deletedField := models.FormField{ID: fieldID, Label: deletedFieldLabel}
+		analytics := rc.fieldAnalyticsEntry(ctx, formID, deletedField, int(total), opts)
+		analytics["deleted"] = true
 		fieldAnalytics = append(fieldAnalytics, analytics)
 	}
 
@@ -337,15 +1783,89 @@ func (rc *ResponseController) calculateAnalytics(formID primitive.ObjectID, fiel
 			"completion_rate":         completionRate,
 			"average_completion_time": avgTime,
 			"response_trends":         responseTrends,
+			"responses_by_source":     sources,
 			"field_analytics":         fieldAnalytics,
+			"section_analytics":       sectionAnalytics(sections, ordered, entries, dropOff),
+			"field_drop_off":          dropOff,
+			"completion_sample_size":  sampleSize,
 		},
 		UpdatedAt: now,
 	}, nil
 }
 
+// fieldsInFormOrder returns a copy of fields sorted by Order, keeping the
+// listed order for ties
+func fieldsInFormOrder(fields []models.FormField) []models.FormField {
+	ordered := append([]models.FormField(nil), fields...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Order < ordered[j].Order })
+	return ordered
+}
+
+// fieldAnalyticsEntry calculates a field's analytics, falling back to an entry
+// marked with an error so a failing field is reported rather than dropped
+func (rc *ResponseController) fieldAnalyticsEntry(ctx context.Context, formID primitive.ObjectID, field models.FormField, totalResponses int, opts analyticsOptions) fiber.Map {
+	analytics, err := rc.calculateEnhancedFieldAnalytics(ctx, formID, field, totalResponses, opts)
+	if err != nil {
+		log.Printf("Failed to calculate analytics for field %s of form %s: %v", field.ID, formID.Hex(), err)
+		return fiber.Map{
+			"field_id":    field.ID,
+			"field_label": field.Label,
+			"field_type":  field.Type,
+			"error":       "Failed to calculate analytics for this field",
+		}
+	}
+	return analytics
+}
+
+// deletedFieldLabel labels analytics for answers whose field no longer exists
+const deletedFieldLabel = "[deleted field]"
+
+// findDeletedFieldIDs returns, sorted, the field IDs that appear in stored
+// responses but are no longer part of the form definition
+func (rc *ResponseController) findDeletedFieldIDs(ctx context.Context, formID primitive.ObjectID, fields []models.FormField, opts analyticsOptions) ([]string, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"form_id": formID, "$nor": opts.uncounted()}},
+		{"$project": bson.M{
+			"keys": bson.M{"$map": bson.M{
+				"input": bson.M{"$objectToArray": "$responses"},
+				"in":    "$$this.k",
+			}},
+		}},
+		{"$unwind": "$keys"},
+		{"$group": bson.M{"_id": "$keys"}},
+	}
+
+	cursor, err := rc.responseCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var keys []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.ID] = true
+	}
+
+	deleted := make([]string, 0)
+	for _, key := range keys {
+		if !known[key.ID] {
+			deleted = append(deleted, key.ID)
+		}
+	}
+	sort.Strings(deleted)
+
+	return deleted, nil
+}
+
 // calculateResponseTrends calculates daily response trends for the last 7 days
-func (rc *ResponseController) calculateResponseTrends(formID primitive.ObjectID) ([]fiber.Map, error) {
-	ctx := context.Background()
+func (rc *ResponseController) calculateResponseTrends(ctx context.Context, formID primitive.ObjectID, opts analyticsOptions) ([]fiber.Map, error) {
 	now := time.Now()
 
 	trends := make([]fiber.Map, 0)
@@ -357,6 +1877,7 @@ func (rc *ResponseController) calculateResponseTrends(formID primitive.ObjectID)
 
 		count, err := rc.responseCollection.CountDocuments(ctx, bson.M{
 			"form_id": formID,
+			"$nor":    opts.uncounted(),
 			"created_at": bson.M{
 				"$gte": startOfDay,
 				"$lt":  endOfDay,
@@ -375,69 +1896,183 @@ func (rc *ResponseController) calculateResponseTrends(formID primitive.ObjectID)
 	return trends, nil
 }
 
-// calculateCompletionMetrics calculates completion rate and average completion time
-func (rc *ResponseController) calculateCompletionMetrics(formID primitive.ObjectID, fields []models.FormField) (float64, float64, error) {
-	ctx := context.Background()
-
-	// Get all responses
-	cursor, err := rc.responseCollection.Find(ctx, bson.M{"form_id": formID})
+// calculateCompletionMetrics calculates completion rate and average completion
+// time in the database; a non-zero sampleSize estimates them from a random sample
+func (rc *ResponseController) calculateCompletionMetrics(ctx context.Context, formID primitive.ObjectID, fields []models.FormField, sampleSize int64) (float64, float64, error) {
+	cursor, err := rc.responseCollection.Aggregate(ctx, completionPipeline(formID, fields, sampleSize))
 	if err != nil {
 		return 0, 0, err
 	}
-	defer cursor.Close(ctx)
+	defer cursor.Close(context.Background())
 
-	var responses []models.FormResponse
-	if err := cursor.All(ctx, &responses); err != nil {
+	var results []completionTotals
+	if err := cursor.All(ctx, &results); err != nil {
 		return 0, 0, err
 	}
 
-	if len(responses) == 0 {
+	if len(results) == 0 || results[0].Responses == 0 {
 		return 0, 0, nil
 	}
+	result := results[0]
+
+	completionRate := float64(result.Completed) / float64(result.Responses) * 100
+	// Completion time is estimated at 10 seconds per answered field
+	avgCompletionTime := float64(result.Answers) * 10 / float64(result.Responses)
+
+	return completionRate, avgCompletionTime, nil
+}
+
+// completionTotals is the single document completionPipeline reduces a form's
+// responses to
+type completionTotals struct {
+	Responses int64 `bson:"responses"`
+	Completed int64 `bson:"completed"`
+	Answers   int64 `bson:"answers"`
+}
+
+// completionPipeline builds the aggregation behind calculateCompletionMetrics
+func completionPipeline(formID primitive.ObjectID, fields []models.FormField, sampleSize int64) []bson.M {
+	// A response is complete when every required field has a non-empty
+	// answer; like nil and "", an empty list (no checkbox ticked) isn't one
+	requiredAnswered := make([]interface{}, 0)
+	for _, field := range fields {
+		if field.AlwaysRequired() {
+			requiredAnswered = append(requiredAnswered, bson.M{"$not": bson.A{
+				bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$responses." + field.ID, nil}}, bson.A{nil, "", bson.A{}}}},
+			}})
+		}
+	}
+
+	pipeline := []bson.M{{"$match": bson.M{"form_id": formID, "$nor": uncountedResponses}}}
+	if sampleSize > 0 {
+		pipeline = append(pipeline, bson.M{"$sample": bson.M{"size": sampleSize}})
+	}
+	pipeline = append(pipeline,
+		// Evaluate each response on its own...
+		bson.M{"$project": bson.M{
+			"complete": bson.M{"$and": requiredAnswered},
+			"answers": bson.M{"$size": bson.M{
+				"$objectToArray": bson.M{"$ifNull": bson.A{"$responses", bson.M{}}},
+			}},
+		}},
+		// ...then reduce to totals without leaving the database
+		bson.M{"$group": bson.M{
+			"_id":       nil,
+			"responses": bson.M{"$sum": 1},
+			"completed": bson.M{"$sum": bson.M{"$cond": bson.A{"$complete", 1, 0}}},
+			"answers":   bson.M{"$sum": "$answers"},
+		}},
+	)
+	return pipeline
+}
 
-	requiredFields := make([]string, 0)
+// calculateDropOff reports, in field order, where incomplete responses stop:
+// for each field, how many incomplete responses have their last answer on that
+// field and what share of them have nothing answered after it
+func (rc *ResponseController) calculateDropOff(ctx context.Context, formID primitive.ObjectID, fields []models.FormField) (fiber.Map, error) {
+	ordered := make([]models.FormField, 0, len(fields))
 	for _, field := range fields {
-		if field.Required {
-			requiredFields = append(requiredFields, field.ID)
+		// Hidden fields are filled automatically and say nothing about abandonment
+		if field.Type != models.FieldTypeHidden {
+			ordered = append(ordered, field)
 		}
 	}
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Order < ordered[j].Order })
 
-	completedResponses := 0
-	totalCompletionTime := float64(0)
+	cursor, err := rc.responseCollection.Find(ctx, bson.M{"form_id": formID, "$nor": uncountedResponses},
+		options.Find().SetProjection(bson.M{"responses": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
 
-	for _, response := range responses {
-		// Check if all required fields are completed
-		isComplete := true
-		for _, fieldID := range requiredFields {
-			if value, exists := response.Responses[fieldID]; !exists || value == nil || value == "" {
-				isComplete = false
-				break
+	// stoppedAt[i] counts incomplete responses whose last answer is field i-1
+	// (stoppedAt[0] holds responses with no visible answers at all)
+	stoppedAt := make([]int, len(ordered)+1)
+	incomplete := 0
+
+	for cursor.Next(ctx) {
+		var response models.FormResponse
+		if err := cursor.Decode(&response); err != nil {
+			return nil, err
+		}
+
+		last := 0
+		answered := 0
+		for i, field := range ordered {
+			if !isEmptyAnswer(response.Responses[field.ID]) {
+				last = i + 1
+				answered++
 			}
 		}
 
-		if isComplete {
-			completedResponses++
+		if answered < len(ordered) {
+			incomplete++
+			stoppedAt[last]++
 		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
 
-		// Calculate completion time
-		estimatedTime := float64(len(response.Responses)) * 10 // 10 seconds per field
-		totalCompletionTime += estimatedTime
+	points := make([]fiber.Map, 0, len(ordered))
+	remaining := incomplete - stoppedAt[0]
+	for i, field := range ordered {
+		stopped := stoppedAt[i+1]
+		percentage := float64(0)
+		nothingAfter := float64(0)
+		if incomplete > 0 {
+			percentage = float64(stopped) / float64(incomplete) * 100
+			// Everyone who stopped on or before this field has nothing after it
+			nothingAfter = float64(incomplete-remaining+stopped) / float64(incomplete) * 100
+		}
+		remaining -= stopped
+
+		points = append(points, fiber.Map{
+			"field_id":                 field.ID,
+			"field_label":              field.Label,
+			"order":                    field.Order,
+			"stopped_here":             stopped,
+			"percentage":               percentage,
+			"nothing_after_percentage": nothingAfter,
+		})
 	}
 
-	completionRate := float64(completedResponses) / float64(len(responses)) * 100
-	avgCompletionTime := totalCompletionTime / float64(len(responses))
+	return fiber.Map{
+		"incomplete_responses": incomplete,
+		"no_answers":           stoppedAt[0],
+		"fields":               points,
+	}, nil
+}
 
-	return completionRate, avgCompletionTime, nil
+// isEmptyAnswer reports whether a stored answer counts as unanswered
+func isEmptyAnswer(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case primitive.A:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	case primitive.M:
+		return len(v) == 0
+	case primitive.D:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
 }
 
 // calculateEnhancedFieldAnalytics calculates comprehensive analytics for a specific field
-func (rc *ResponseController) calculateEnhancedFieldAnalytics(formID primitive.ObjectID, field models.FormField, totalResponses int) (fiber.Map, error) {
-	ctx := context.Background()
-
-	// Count responses for this field (not null/empty)
+func (rc *ResponseController) calculateEnhancedFieldAnalytics(ctx context.Context, formID primitive.ObjectID, field models.FormField, totalResponses int, opts analyticsOptions) (fiber.Map, error) {
+	// Count responses that answered this field, see answeredPredicate
 	fieldResponseCount, err := rc.responseCollection.CountDocuments(ctx, bson.M{
 		"form_id":               formID,
-		"responses." + field.ID: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}},
+		"$nor":                  opts.uncounted(),
+		"responses." + field.ID: answeredPredicate(field),
 	})
 	if err != nil {
 		return nil, err
@@ -461,157 +2096,67 @@ func (rc *ResponseController) calculateEnhancedFieldAnalytics(formID primitive.O
 		"common_responses": []fiber.Map{},
 	}
 
-	switch field.Type {
-	case models.FieldTypeMultipleChoice, models.FieldTypeCheckbox:
-		// Get choice distribution
-		pipeline := []bson.M{
-			{"$match": bson.M{
-				"form_id":               formID,
-				"responses." + field.ID: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}},
-			}},
-			{"$project": bson.M{
-				"value": "$responses." + field.ID,
-			}},
-			{"$group": bson.M{
-				"_id":   "$value",
-				"count": bson.M{"$sum": 1},
-			}},
-			{"$sort": bson.M{"count": -1}},
-			{"$limit": 10},
-		}
-
-		cursor, err := rc.responseCollection.Aggregate(ctx, pipeline)
-		if err == nil {
-			var choiceResults []bson.M
-			cursor.All(ctx, &choiceResults)
-			cursor.Close(ctx)
-
-			commonResponses := make([]fiber.Map, 0)
-			for _, choice := range choiceResults {
-				if choice["_id"] != nil {
-					percentage := float64(choice["count"].(int32)) / float64(fieldResponseCount) * 100
-					commonResponses = append(commonResponses, fiber.Map{
-						"value":      choice["_id"],
-						"count":      choice["count"],
-						"percentage": percentage,
-					})
-				}
-			}
-			result["common_responses"] = commonResponses
-			result["unique_responses"] = len(choiceResults)
-		}
-
-	case models.FieldTypeRating:
-		// Calculate average rating and distribution
-		pipeline := []bson.M{
-			{"$match": bson.M{
-				"form_id":               formID,
-				"responses." + field.ID: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}},
-			}},
-			{"$group": bson.M{
-				"_id":     nil,
-				"average": bson.M{"$avg": "$responses." + field.ID},
-				"ratings": bson.M{"$push": "$responses." + field.ID},
-			}},
-		}
-
-		cursor, err := rc.responseCollection.Aggregate(ctx, pipeline)
-		if err == nil {
-			var ratingResults []bson.M
-			cursor.All(ctx, &ratingResults)
-			cursor.Close(ctx)
-
-			if len(ratingResults) > 0 {
-				if avg, ok := ratingResults[0]["average"]; ok && avg != nil {
-					result["average_rating"] = avg
-				}
-
-				// Calculate rating distribution
-				if ratings, ok := ratingResults[0]["ratings"].(primitive.A); ok {
-					distribution := make(map[int]int)
-					for _, rating := range ratings {
-						if r, ok := rating.(int32); ok {
-							distribution[int(r)]++
-						} else if r, ok := rating.(float64); ok {
-							distribution[int(r)]++
-						}
-					}
-
-					commonResponses := make([]fiber.Map, 0)
-					for rating := 1; rating <= 5; rating++ {
-						count := distribution[rating]
-						if count > 0 {
-							percentage := float64(count) / float64(len(ratings)) * 100
-							commonResponses = append(commonResponses, fiber.Map{
-								"value":      rating,
-								"count":      count,
-								"percentage": percentage,
-							})
-						}
-					}
-					result["common_responses"] = commonResponses
-				}
-			}
-		}
+	// Ciphertext can't be aggregated, so only the response rate is reported
+	if field.Encrypted {
+		result["encrypted"] = true
+		return result, nil
+	}
 
-	case models.FieldTypeText, models.FieldTypeTextarea, models.FieldTypeEmail:
-		// Get most common text responses
-		pipeline := []bson.M{
-			{"$match": bson.M{
-				"form_id":               formID,
-				"responses." + field.ID: bson.M{"$exists": true, "$nin": []interface{}{nil, ""}},
-			}},
-			{"$project": bson.M{
-				"value": "$responses." + field.ID,
-			}},
-			{"$group": bson.M{
-				"_id":   "$value",
-				"count": bson.M{"$sum": 1},
-			}},
-			{"$sort": bson.M{"count": -1}},
-			{"$limit": 5},
-		}
-
-		cursor, err := rc.responseCollection.Aggregate(ctx, pipeline)
-		if err == nil {
-			var textResults []bson.M
-			cursor.All(ctx, &textResults)
-			cursor.Close(ctx)
-
-			commonResponses := make([]fiber.Map, 0)
-			for _, text := range textResults {
-				if text["_id"] != nil {
-					percentage := float64(text["count"].(int32)) / float64(fieldResponseCount) * 100
-					valueStr := ""
-					if str, ok := text["_id"].(string); ok {
-						// Truncate long text responses
-						if len(str) > 50 {
-							valueStr = str[:47] + "..."
-						} else {
-							valueStr = str
-						}
-					}
-					commonResponses = append(commonResponses, fiber.Map{
-						"value":      valueStr,
-						"count":      text["count"],
-						"percentage": percentage,
-					})
-				}
-			}
-			result["common_responses"] = commonResponses
-			result["unique_responses"] = len(textResults)
+	if handler, ok := fieldTypeHandler(field.Type); ok && handler.Analytics != nil {
+		err := handler.Analytics(ctx, FieldAnalyticsContext{
+			Responses:      rc.responseCollection,
+			FormID:         formID,
+			Field:          field,
+			TotalResponses: totalResponses,
+			Answered:       fieldResponseCount,
+			TopN:           opts.TopN,
+			Uncounted:      opts.uncounted(),
+			rc:             rc,
+			opts:           opts,
+		}, result)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	return result, nil
 }
 
-// updateAnalytics updates analytics after a new response (async)
+// updateAnalytics recomputes a form's analytics, stores them in the cached
+// analytics collection and broadcasts the update. It runs from the analytics
+// scheduler, so bursts of submissions share one recompute.
 func (rc *ResponseController) updateAnalytics(formID primitive.ObjectID) {
-	// This would typically update a cached analytics collection
-	// For now, we'll just broadcast an analytics update event
+	ctx := context.Background()
+
+	var form models.Form
+	if err := rc.formCollection.FindOne(ctx, bson.M{"_id": formID}).Decode(&form); err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to load form %s for analytics: %v", formID.Hex(), err)
+		}
+		return
+	}
+
+	analytics, err := rc.computeAnalytics(form, defaultAnalyticsOptions(form))
+	if errors.Is(err, errAnalyticsBusy) {
+		// Try again once the debounce interval has passed
+		rc.analytics.Schedule(formID)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to recompute analytics for form %s: %v", formID.Hex(), err)
+		return
+	}
+
+	_, err = rc.analyticsCollection.ReplaceOne(ctx, bson.M{"form_id": formID}, analytics, options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Printf("Failed to cache analytics for form %s: %v", formID.Hex(), err)
+	}
+
 	rc.hub.BroadcastToForm(formID.Hex(), "analytics_updated", fiber.Map{
 		"form_id":    formID.Hex(),
-		"updated_at": time.Now(),
+		"updated_at": analytics.UpdatedAt,
+		"analytics":  analytics.FieldAnalytics,
 	})
+
+	rc.checkMilestones(form, analytics)
 }
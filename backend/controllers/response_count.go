@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// responseCountTTL is how long a form's cached response total is served
+// before it is counted again
+const responseCountTTL = time.Minute
+
+// responseCountCache remembers each form's response total so paging through
+// an unfiltered response list doesn't count the whole form on every page
+type responseCountCache struct {
+	mu      sync.Mutex
+	entries map[primitive.ObjectID]cachedResponseCount
+}
+
+type cachedResponseCount struct {
+	count     int64
+	countedAt time.Time
+}
+
+func newResponseCountCache() *responseCountCache {
+	return &responseCountCache{entries: make(map[primitive.ObjectID]cachedResponseCount)}
+}
+
+// total returns the form's response total and whether it was just counted.
+// A cached total is returned while it is younger than responseCountTTL;
+// submissions since then are added to it, deletions aren't subtracted.
+func (cc *responseCountCache) total(ctx context.Context, collection *mongo.Collection, formID primitive.ObjectID) (int64, bool, error) {
+	cc.mu.Lock()
+	entry, ok := cc.entries[formID]
+	cc.mu.Unlock()
+	if ok && time.Since(entry.countedAt) < responseCountTTL {
+		return entry.count, false, nil
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"form_id": formID})
+	if err != nil {
+		return 0, false, err
+	}
+
+	cc.mu.Lock()
+	cc.entries[formID] = cachedResponseCount{count: count, countedAt: time.Now()}
+	// Drop expired totals so forms nobody pages through don't pile up
+	for id, cached := range cc.entries {
+		if time.Since(cached.countedAt) >= responseCountTTL {
+			delete(cc.entries, id)
+		}
+	}
+	cc.mu.Unlock()
+	return count, true, nil
+}
+
+// added counts a new submission towards the form's cached total, if any
+func (cc *responseCountCache) added(formID primitive.ObjectID) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if entry, ok := cc.entries[formID]; ok {
+		entry.count++
+		cc.entries[formID] = entry
+	}
+}
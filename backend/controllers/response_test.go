@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pagedResponse is the part of a stored response GetResponses pages by
+type pagedResponse struct {
+	id        primitive.ObjectID
+	createdAt time.Time
+}
+
+// newestFirst sorts like GetResponses: created_at, then _id, descending
+func newestFirst(rows []pagedResponse) {
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].createdAt.Equal(rows[j].createdAt) {
+			return rows[i].createdAt.After(rows[j].createdAt)
+		}
+		return rows[i].id.Hex() > rows[j].id.Hex()
+	})
+}
+
+// offsetPage fetches a page the way ?page= does
+func offsetPage(rows []pagedResponse, page, limit int) []pagedResponse {
+	newestFirst(rows)
+	start := (page - 1) * limit
+	if start >= len(rows) {
+		return nil
+	}
+	return rows[start:min(start+limit, len(rows))]
+}
+
+// cursorPage fetches a page the way ?cursor=&as_of= does, returning the
+// next_cursor GetResponses would hand out
+func cursorPage(t *testing.T, rows []pagedResponse, cursor string, asOf time.Time, limit int) ([]pagedResponse, string) {
+	t.Helper()
+	var matching []pagedResponse
+	for _, row := range rows {
+		if row.createdAt.After(asOf) {
+			continue
+		}
+		if cursor != "" {
+			cursorTime, cursorID, err := decodeResponseCursor(cursor)
+			if err != nil {
+				t.Fatalf("decodeResponseCursor(%q) error = %v", cursor, err)
+			}
+			before := row.createdAt.Before(cursorTime) ||
+				(row.createdAt.Equal(cursorTime) && row.id.Hex() < cursorID.Hex())
+			if !before {
+				continue
+			}
+		}
+		matching = append(matching, row)
+	}
+	newestFirst(matching)
+	page := matching[:min(limit, len(matching))]
+
+	next := ""
+	if len(page) == limit {
+		last := page[len(page)-1]
+		next = encodeResponseCursor(last.createdAt, last.id)
+	}
+	return page, next
+}
+
+func TestResponsePaginationWithConcurrentInserts(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var rows []pagedResponse
+	for i := 0; i < 4; i++ {
+		// Two responses per millisecond, so ties on created_at are broken by _id
+		rows = append(rows, pagedResponse{primitive.NewObjectID(), start.Add(time.Duration(i/2) * time.Millisecond)})
+	}
+	asOf := start.Add(time.Second)
+	inserted := pagedResponse{primitive.NewObjectID(), asOf.Add(time.Second)}
+
+	tests := []struct {
+		name   string
+		pages  func() [][]pagedResponse
+		stable bool
+	}{
+		{
+			name: "offset pages repeat a row",
+			pages: func() [][]pagedResponse {
+				rows := append([]pagedResponse{}, rows...)
+				first := append([]pagedResponse{}, offsetPage(rows, 1, 2)...)
+				rows = append(rows, inserted)
+				return [][]pagedResponse{first, offsetPage(rows, 2, 2)}
+			},
+			stable: false,
+		},
+		{
+			name: "cursor pages see each row once",
+			pages: func() [][]pagedResponse {
+				rows := append([]pagedResponse{}, rows...)
+				first, next := cursorPage(t, rows, "", asOf, 2)
+				rows = append(rows, inserted)
+				second, _ := cursorPage(t, rows, next, asOf, 2)
+				return [][]pagedResponse{first, second}
+			},
+			stable: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[primitive.ObjectID]int{}
+			for _, page := range tt.pages() {
+				for _, row := range page {
+					seen[row.id]++
+				}
+			}
+			stable := len(seen) == len(rows) && seen[inserted.id] == 0
+			for _, count := range seen {
+				if count > 1 {
+					stable = false
+				}
+			}
+			if stable != tt.stable {
+				t.Errorf("each original row seen once = %v, want %v (seen %v)", stable, tt.stable, seen)
+			}
+		})
+	}
+}
+
+func TestResponseCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 123e6, time.UTC)
+
+	gotTime, gotID, err := decodeResponseCursor(encodeResponseCursor(createdAt, id))
+	if err != nil || !gotTime.Equal(createdAt) || gotID != id {
+		t.Errorf("decodeResponseCursor(encodeResponseCursor(%v, %v)) = %v, %v, %v", createdAt, id, gotTime, gotID, err)
+	}
+
+	for _, cursor := range []string{"", "not base64!", "MTIz", "YWJjX2RlZg"} {
+		if _, _, err := decodeResponseCursor(cursor); err == nil {
+			t.Errorf("decodeResponseCursor(%q) error = nil, want an error", cursor)
+		}
+	}
+}
+
+func TestCompletionPipeline(t *testing.T) {
+	fields := []models.FormField{
+		{ID: "name", Type: models.FieldTypeText, Required: true},
+		{ID: "email", Type: models.FieldTypeEmail},
+		{ID: "reason", Type: models.FieldTypeText, Required: true, RequiredUnless: []models.Condition{{FieldID: "name"}}},
+	}
+	tests := []struct {
+		name       string
+		sampleSize int64
+		wantStages []string
+	}{
+		{"every response", 0, []string{"$match", "$project", "$group"}},
+		{"sampled", 500, []string{"$match", "$sample", "$project", "$group"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := completionPipeline(primitive.NewObjectID(), fields, tt.sampleSize)
+			var stages []string
+			for _, stage := range pipeline {
+				for name := range stage {
+					stages = append(stages, name)
+				}
+			}
+			if fmt.Sprint(stages) != fmt.Sprint(tt.wantStages) {
+				t.Fatalf("completionPipeline() stages = %v, want %v", stages, tt.wantStages)
+			}
+			if tt.sampleSize > 0 {
+				if size := pipeline[1]["$sample"].(bson.M)["size"]; size != tt.sampleSize {
+					t.Errorf("completionPipeline() sample size = %v, want %d", size, tt.sampleSize)
+				}
+			}
+
+			// Only fields required on every response decide completeness
+			project := pipeline[len(pipeline)-2]["$project"].(bson.M)
+			required := project["complete"].(bson.M)["$and"].([]interface{})
+			if len(required) != 1 {
+				t.Errorf("completionPipeline() checks %d required answers, want 1", len(required))
+			}
+		})
+	}
+}
+
+func TestValidateResponseLegacyForm(t *testing.T) {
+	// Fields as stored before validation blocks existed
+	raw, err := bson.Marshal(bson.D{
+		{Key: "title", Value: "Legacy"},
+		{Key: "fields", Value: bson.A{
+			bson.D{{Key: "id", Value: "name"}, {Key: "type", Value: "text"}, {Key: "label", Value: "Name"}, {Key: "required", Value: true}},
+			bson.D{{Key: "id", Value: "age"}, {Key: "type", Value: "number"}, {Key: "label", Value: "Age"}, {Key: "validation", Value: nil}},
+			bson.D{{Key: "id", Value: "bio"}, {Key: "type", Value: "textarea"}, {Key: "label", Value: "Bio"}, {Key: "validation", Value: bson.D{{Key: "max_length", Value: 5.0}}}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("bson.Marshal() error = %v", err)
+	}
+	var form models.Form
+	if err := bson.Unmarshal(raw, &form); err != nil {
+		t.Fatalf("bson.Unmarshal() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		responses map[string]interface{}
+		wantErr   bool
+	}{
+		{"all answered", map[string]interface{}{"name": "Ada", "age": 36.0, "bio": "Hi"}, false},
+		{"optional left out", map[string]interface{}{"name": "Ada"}, false},
+		{"required left out", map[string]interface{}{"age": 36.0}, true},
+		{"over the legacy limit", map[string]interface{}{"name": "Ada", "bio": "Too long"}, true},
+	}
+	rc := &ResponseController{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rc.validateResponse(tt.responses, form); (err != nil) != tt.wantErr {
+				t.Errorf("validateResponse(%v) error = %v, wantErr %v", tt.responses, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAnswersTransforms(t *testing.T) {
+	form := models.Form{Fields: []models.FormField{
+		{ID: "name", Label: "Name", Type: models.FieldTypeText, Required: true,
+			Transforms: []models.AnswerTransform{models.TransformTrim}},
+		{ID: "code", Label: "Code", Type: models.FieldTypeText,
+			Transforms: []models.AnswerTransform{models.TransformNormalizeWhitespace, models.TransformUppercase},
+			Validation: models.ValidationRule{MinLength: 3, MaxLength: 5}},
+		{ID: "email", Label: "Email", Type: models.FieldTypeEmail,
+			Transforms: []models.AnswerTransform{models.TransformTrim, models.TransformLowercase}},
+	}}
+
+	tests := []struct {
+		name      string
+		responses map[string]interface{}
+		wantErr   bool
+		want      map[string]interface{}
+	}{
+		{
+			name:      "transformed before storing",
+			responses: map[string]interface{}{"name": "  Ada ", "code": " ab  1 ", "email": " Ada@Example.COM "},
+			want:      map[string]interface{}{"name": "Ada", "code": "AB 1", "email": "ada@example.com"},
+		},
+		{
+			name:      "whitespace-only required answer",
+			responses: map[string]interface{}{"name": "   "},
+			wantErr:   true,
+		},
+		{
+			name:      "within the limit once normalized",
+			responses: map[string]interface{}{"name": "Ada", "code": "a    b    c"},
+			want:      map[string]interface{}{"name": "Ada", "code": "A B C"},
+		},
+		{
+			name:      "under the minimum once normalized",
+			responses: map[string]interface{}{"name": "Ada", "code": "  a  "},
+			wantErr:   true,
+		},
+		{
+			name:      "over the limit once normalized",
+			responses: map[string]interface{}{"name": "Ada", "code": "abc def"},
+			wantErr:   true,
+		},
+	}
+	rc := &ResponseController{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rc.validateResponse(tt.responses, form)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for fieldID, want := range tt.want {
+				if got := tt.responses[fieldID]; got != want {
+					t.Errorf("validateResponse() stored %s = %q, want %q", fieldID, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateResponseRequiredUnless(t *testing.T) {
+	form := models.Form{Fields: []models.FormField{
+		{ID: "rating", Label: "Rating", Type: models.FieldTypeRating},
+		{ID: "explain", Label: "Explain your rating", Type: models.FieldTypeTextarea,
+			RequiredUnless: []models.Condition{{FieldID: "rating", Operator: models.ConditionGreaterThan, Value: 3}}},
+	}}
+
+	tests := []struct {
+		name      string
+		responses map[string]interface{}
+		wantErr   bool
+	}{
+		{"unless met, left out", map[string]interface{}{"rating": 5.0}, false},
+		{"unless not met, left out", map[string]interface{}{"rating": 2.0}, true},
+		{"unless not met, answered", map[string]interface{}{"rating": 2.0, "explain": "Too slow"}, false},
+		{"unless field unanswered", map[string]interface{}{}, true},
+	}
+	rc := &ResponseController{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := rc.validateResponse(tt.responses, form); (err != nil) != tt.wantErr {
+				t.Errorf("validateResponse(%v) error = %v, wantErr %v", tt.responses, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStreamResponsesRequiresUser(t *testing.T) {
+	// Rejected before the form is looked up, so no collection is needed
+	rc := &ResponseController{}
+	app := fiber.New()
+	app.Get("/forms/:id/responses/stream", rc.StreamResponses)
+
+	path := "/forms/" + primitive.NewObjectID().Hex() + "/responses/stream"
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != 401 {
+		t.Errorf("anonymous stream status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestCountsIPSubmissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		rc     *ResponseController
+		header string
+		want   bool
+	}{
+		{"cap disabled", &ResponseController{}, "", false},
+		{"capped", &ResponseController{maxSubmissionsPerIP: 5}, "", true},
+		{"capped with token configured", &ResponseController{maxSubmissionsPerIP: 5, internalToken: "secret"}, "", true},
+		{"wrong internal token", &ResponseController{maxSubmissionsPerIP: 5, internalToken: "secret"}, "guess", true},
+		{"internal caller", &ResponseController{maxSubmissionsPerIP: 5, internalToken: "secret"}, "secret", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			app := fiber.New()
+			app.Post("/", func(c *fiber.Ctx) error {
+				got = tt.rc.countsIPSubmissions(c)
+				return nil
+			})
+			req := httptest.NewRequest("POST", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Internal-Token", tt.header)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("countsIPSubmissions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
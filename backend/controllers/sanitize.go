@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Default limits for field help text, in characters. FIELD_PLACEHOLDER_MAX_LENGTH
+// and FIELD_DESCRIPTION_MAX_LENGTH override them.
+const (
+	defaultFieldPlaceholderMaxLength = 200
+	defaultFieldDescriptionMaxLength = 1000
+)
+
+var (
+	// markupTag matches tag syntax only: a '<' directly followed by a letter,
+	// '/' or '!', so comparisons such as "< 18" are left alone
+	markupTag     = regexp.MustCompile(`(?s)<[a-zA-Z/!][^>]*>`)
+	markupComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	markupBlock   = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed)\b.*?</(script|style|iframe|object|embed)\s*>`)
+	// unsafeLink matches markdown link and image targets with a script or data scheme
+	unsafeLink = regexp.MustCompile(`(?i)\]\(\s*(javascript|vbscript|data):(?:[^()]|\([^()]*\))*\)`)
+	// unsafeReference matches the same targets in reference definitions
+	// ("[x]: javascript:..."), keeping the label in the first group
+	unsafeReference = regexp.MustCompile(`(?im)^( {0,3}\[[^\]]+\]:[ \t]*)<?[ \t]*(javascript|vbscript|data):.*$`)
+)
+
+// fieldTextLimit reads a positive character limit from the environment
+func fieldTextLimit(key string, def int) int {
+	limit, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || limit <= 0 {
+		return def
+	}
+	return limit
+}
+
+// sanitizeText turns author-supplied text into plain text before it is
+// stored. Markup is removed rather than entity-escaped because clients render
+// these strings as text, where escaped entities would show up literally; for
+// the same reason entities are kept as written, so "&lt;b&gt;" stays text.
+// Stray '<' and '>' in prose are kept too.
+func sanitizeText(s string) string {
+	// Stripping can join the pieces around a tag into a new one
+	// ("<<b>script>"), so repeat until nothing changes
+	for {
+		stripped := markupComment.ReplaceAllString(s, "")
+		stripped = markupBlock.ReplaceAllString(stripped, "")
+		stripped = markupTag.ReplaceAllString(stripped, "")
+		if stripped == s {
+			break
+		}
+		s = stripped
+	}
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// sanitizeMarkdown strips HTML from author-supplied markdown. Unlike
+// sanitizeText it keeps '>' so blockquotes survive, and it empties links
+// whose target could run script when rendered, inline or through a
+// reference definition. Autolinks (<javascript:...>) go with the tags.
+func sanitizeMarkdown(s string) string {
+	s = html.UnescapeString(s)
+	s = markupComment.ReplaceAllString(s, "")
+	s = markupBlock.ReplaceAllString(s, "")
+	s = markupTag.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, "<", "")
+	s = unsafeLink.ReplaceAllString(s, "]()")
+	s = unsafeReference.ReplaceAllString(s, "${1}<>")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// sanitizeContentBlock sanitizes an intro or outro block in place, returning
+// nil when nothing is left to show
+func sanitizeContentBlock(block *models.ContentBlock) *models.ContentBlock {
+	if block == nil {
+		return nil
+	}
+	block.Title = sanitizeText(block.Title)
+	block.Body = sanitizeMarkdown(block.Body)
+	if block.IsEmpty() {
+		return nil
+	}
+	return block
+}
+
+// sanitizeFieldText sanitizes the author-facing text of a field and enforces
+// the placeholder and description limits
+func sanitizeFieldText(field *models.FormField) error {
+	field.Label = sanitizeText(field.Label)
+	field.Placeholder = sanitizeText(field.Placeholder)
+	field.Description = sanitizeText(field.Description)
+	field.ConsentText = sanitizeText(field.ConsentText)
+	for i := range field.Options {
+		field.Options[i].Label = sanitizeText(field.Options[i].Label)
+	}
+
+	if limit := fieldTextLimit("FIELD_PLACEHOLDER_MAX_LENGTH", defaultFieldPlaceholderMaxLength); utf8.RuneCountInString(field.Placeholder) > limit {
+		return fiber.NewError(400, fmt.Sprintf("Placeholder of field '%s' must be at most %d characters", field.Label, limit))
+	}
+	if limit := fieldTextLimit("FIELD_DESCRIPTION_MAX_LENGTH", defaultFieldDescriptionMaxLength); utf8.RuneCountInString(field.Description) > limit {
+		return fiber.NewError(400, fmt.Sprintf("Description of field '%s' must be at most %d characters", field.Label, limit))
+	}
+	return nil
+}
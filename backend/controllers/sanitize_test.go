@@ -0,0 +1,56 @@
+package controllers
+
+import "testing"
+
+func TestSanitizeMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "Hello **there**", "Hello **there**"},
+		{"blockquote", "> quoted", "> quoted"},
+		{"safe link", "[docs](https://example.com)", "[docs](https://example.com)"},
+		{"inline script link", "[x](javascript:alert(1))", "[x]()"},
+		{"inline data image", "![x]( DATA:text/html;base64,AAAA)", "![x]()"},
+		{"encoded scheme", "[x](javascript&#58;alert(1))", "[x]()"},
+		{"reference definition", "[x]\n\n[x]: javascript:alert(1)", "[x]\n\n[x]: <>"},
+		{"indented reference", "  [x]: VBScript:msgbox", "[x]: <>"},
+		{"bracketed reference", "[x]: <javascript:alert(1)>", "[x]:"},
+		{"safe reference", "[x]: https://example.com", "[x]: https://example.com"},
+		{"autolink", "see <javascript:alert(1)> now", "see  now"},
+		{"tags", "<b>bold</b><script>alert(1)</script>", "bold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeMarkdown(tt.in); got != tt.want {
+				t.Errorf("sanitizeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "What is your name?", "What is your name?"},
+		{"comparisons", "Are you < 18 or > 65?", "Are you < 18 or > 65?"},
+		{"unspaced comparison", "Age <18 or >65", "Age <18 or >65"},
+		{"arrow", "Next -> step", "Next -> step"},
+		{"literal entities", "Type &lt;b&gt; for bold", "Type &lt;b&gt; for bold"},
+		{"ampersand", "Terms &amp; conditions", "Terms &amp; conditions"},
+		{"tags", "<b>Name</b>", "Name"},
+		{"closing tag only", "Name</b>", "Name"},
+		{"script block", "Name<script>alert(1)</script>", "Name"},
+		{"comment", "Name<!-- hidden -->", "Name"},
+		{"doctype", "<!DOCTYPE html>Name", "Name"},
+		{"reassembled tag", "<<b>script>alert(1)<</b>/script>", ""},
+		{"control characters", "Na\x00me\tfield\n", "Name\tfield"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeText(tt.in); got != tt.want {
+				t.Errorf("sanitizeText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
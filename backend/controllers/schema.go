@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// GetFormSchema serves a JSON Schema for the responses object a submission
+// to the form must carry, so third-party clients can validate before
+// submitting
+func (fc *FormController) GetFormSchema(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = fc.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	body, err := json.Marshal(responsesSchema(form))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to encode schema"})
+	}
+	c.Set(fiber.HeaderContentType, "application/schema+json")
+	return c.Send(body)
+}
+
+// responsesSchema describes a form's responses object. It mirrors
+// validateResponse: answers may be omitted or null unless required, required
+// answers can't be empty strings, and unknown keys are allowed up to the
+// answer limit. Conditional requirements (required_if, required_unless) can't
+// be expressed faithfully, so they are passed through as the x-required-if
+// and x-required-unless annotations.
+func responsesSchema(form models.Form) fiber.Map {
+	properties := make(fiber.Map, len(form.Fields))
+	required := []string{}
+	for _, field := range form.Fields {
+		// The server fills in read-only defaults, so those may be omitted
+		isRequired := field.AlwaysRequired() && field.Type != models.FieldTypeHidden && !(field.ReadOnly && field.DefaultValue != nil)
+		properties[field.ID] = fieldSchema(field, isRequired)
+		if isRequired {
+			required = append(required, field.ID)
+		}
+	}
+
+	schema := fiber.Map{
+		"$schema":       jsonSchemaDialect,
+		"title":         form.Title,
+		"type":          "object",
+		"properties":    properties,
+		"required":      required,
+		"maxProperties": maxResponseKeys,
+	}
+	if form.Description != "" {
+		schema["description"] = form.Description
+	}
+
+	// Each group needs at least one non-empty answer
+	if len(form.RequireAtLeastOne) > 0 {
+		groups := make([]fiber.Map, 0, len(form.RequireAtLeastOne))
+		for _, group := range form.RequireAtLeastOne {
+			anyOf := make([]fiber.Map, 0, len(group.FieldIDs))
+			for _, fieldID := range group.FieldIDs {
+				anyOf = append(anyOf, fiber.Map{
+					"required": []string{fieldID},
+					"properties": fiber.Map{
+						fieldID: fiber.Map{"not": fiber.Map{"enum": []interface{}{nil, "", []interface{}{}}}},
+					},
+				})
+			}
+			groups = append(groups, fiber.Map{"anyOf": anyOf})
+		}
+		schema["allOf"] = groups
+	}
+
+	return schema
+}
+
+// fieldSchema describes the answer to a single field
+func fieldSchema(field models.FormField, required bool) fiber.Map {
+	schema := fiber.Map{"title": field.Label}
+	if field.Description != "" {
+		schema["description"] = field.Description
+	}
+	if len(field.RequiredIf) > 0 {
+		schema["x-required-if"] = field.RequiredIf
+	}
+	if len(field.RequiredUnless) > 0 {
+		schema["x-required-unless"] = field.RequiredUnless
+	}
+
+	// Respondents can't change read-only or disabled fields
+	if field.ReadOnly || field.Disabled {
+		if field.DefaultValue != nil {
+			schema["enum"] = []interface{}{field.DefaultValue, nil}
+		} else {
+			schema["type"] = "null"
+		}
+		return schema
+	}
+
+	var valueType string
+	var enum []interface{}
+	switch field.Type {
+	case models.FieldTypeText, models.FieldTypeTextarea:
+		valueType = "string"
+		if field.Validation.MinLength > 0 {
+			schema["minLength"] = field.Validation.MinLength
+		}
+		if maxLength := field.EffectiveMaxLength(); maxLength > 0 {
+			schema["maxLength"] = maxLength
+		}
+	case models.FieldTypeEmail:
+		valueType = "string"
+		schema["format"] = "email"
+		if len(field.Validation.AllowedEmailDomains) > 0 {
+			schema["x-allowed-email-domains"] = field.Validation.AllowedEmailDomains
+		}
+	case models.FieldTypeNumber:
+		valueType = "number"
+		if field.Validation.Min != 0 {
+			schema["minimum"] = field.Validation.Min
+		}
+		if field.Validation.Max != 0 {
+			schema["maximum"] = field.Validation.Max
+		}
+	case models.FieldTypeRating:
+		valueType = "number"
+		schema["minimum"] = 1
+		schema["maximum"] = 5
+	case models.FieldTypeDate:
+		valueType = "string"
+		schema["format"] = "date"
+	case models.FieldTypeMultipleChoice:
+		valueType = "string"
+		enum = optionValues(field.Options)
+	case models.FieldTypeCheckbox:
+		valueType = "array"
+		schema["items"] = fiber.Map{"type": "string", "enum": optionValues(field.Options)}
+		schema["maxItems"] = maxAnswerArrayLength
+	case models.FieldTypeConsent:
+		valueType = "boolean"
+		if required {
+			schema["const"] = true
+		}
+	case models.FieldTypeLocation:
+		valueType = "object"
+		schema["properties"] = fiber.Map{
+			"lat":     fiber.Map{"type": "number", "minimum": -90, "maximum": 90},
+			"lng":     fiber.Map{"type": "number", "minimum": -180, "maximum": 180},
+			"address": fiber.Map{"type": "string", "maxLength": maxLocationAddressLength},
+		}
+		schema["required"] = []string{"lat", "lng"}
+	case models.FieldTypeFile:
+		// Uploaded as a multipart file part named responses[<field id>]
+		valueType = "string"
+		schema["format"] = "binary"
+		schema["x-max-file-size"] = field.EffectiveMaxFileSize()
+		if len(field.Validation.AllowedMimeTypes) > 0 {
+			schema["x-allowed-mime-types"] = field.Validation.AllowedMimeTypes
+		}
+	case models.FieldTypeHidden:
+		valueType = "string"
+		schema["maxLength"] = field.EffectiveMaxLength()
+		if field.Validation.Pattern != "" {
+			schema["pattern"] = field.Validation.Pattern
+		}
+	}
+
+	// An empty string counts as unanswered, so required strings need a character
+	if required && valueType == "string" {
+		if _, ok := schema["minLength"]; !ok {
+			schema["minLength"] = 1
+		}
+	}
+
+	if valueType != "" {
+		if required {
+			schema["type"] = valueType
+		} else {
+			schema["type"] = []string{valueType, "null"}
+		}
+	}
+	if enum != nil {
+		if !required {
+			enum = append(enum, nil)
+		}
+		schema["enum"] = enum
+	}
+
+	return schema
+}
+
+// optionValues lists the values of a choice field's options
+func optionValues(options []models.FieldOption) []interface{} {
+	values := make([]interface{}, 0, len(options))
+	for _, option := range options {
+		values = append(values, option.Value)
+	}
+	return values
+}
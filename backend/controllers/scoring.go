@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fieldScore reports the weighted score of a scored choice field: the total
+// across responses and the average per answering response. Checkbox answers
+// score the sum of their selected options. Responses matching uncounted are
+// left out.
+func (rc *ResponseController) fieldScore(ctx context.Context, formID primitive.ObjectID, field models.FormField, answered int64, uncounted bson.A) (fiber.Map, error) {
+	cursor, err := rc.responseCollection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			"form_id":               formID,
+			"$nor":                  uncounted,
+			"responses." + field.ID: answeredPredicate(field),
+		}},
+		{"$project": bson.M{"value": "$responses." + field.ID}},
+		// Single answers unwind to themselves, checkbox answers to each selection
+		{"$unwind": "$value"},
+		{"$group": bson.M{"_id": "$value", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var counts []struct {
+		Value interface{} `bson:"_id"`
+		Count int64       `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, count := range counts {
+		total += field.AnswerScore(count.Value) * float64(count.Count)
+	}
+
+	average := float64(0)
+	if answered > 0 {
+		average = total / float64(answered)
+	}
+
+	return fiber.Map{
+		"total":     total,
+		"average":   average,
+		"responses": answered,
+	}, nil
+}
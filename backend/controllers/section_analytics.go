@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultSectionID identifies the section of fields that aren't assigned to one
+const defaultSectionID = "default"
+
+// sectionAnalytics rolls field metrics up into the form's sections. Fields are
+// expected in form order; sections follow the order they are listed on the
+// form, after the default section holding unassigned fields. A form without
+// sections reports all of its fields as the default section.
+func sectionAnalytics(sections []models.FormSection, ordered []models.FormField, entries map[string]fiber.Map, dropOff fiber.Map) []fiber.Map {
+	stopped := make(map[string]int)
+	stoppedPercentage := make(map[string]float64)
+	if points, ok := dropOff["fields"].([]fiber.Map); ok {
+		for _, point := range points {
+			fieldID, _ := point["field_id"].(string)
+			stopped[fieldID], _ = point["stopped_here"].(int)
+			stoppedPercentage[fieldID], _ = point["percentage"].(float64)
+		}
+	}
+
+	known := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		known[section.ID] = true
+	}
+	members := make(map[string][]models.FormField)
+	for _, field := range ordered {
+		sectionID := field.SectionID
+		if !known[sectionID] {
+			sectionID = defaultSectionID
+		}
+		members[sectionID] = append(members[sectionID], field)
+	}
+
+	all := make([]models.FormSection, 0, len(sections)+1)
+	if len(members[defaultSectionID]) > 0 || len(sections) == 0 {
+		all = append(all, models.FormSection{ID: defaultSectionID})
+	}
+	all = append(all, sections...)
+
+	result := make([]fiber.Map, 0, len(all))
+	for i, section := range all {
+		fields := members[section.ID]
+
+		fieldIDs := make([]string, 0, len(fields))
+		var rateSum, timeSum, dropOffPercentage float64
+		var rated, timed, stoppedHere int
+		for _, field := range fields {
+			fieldIDs = append(fieldIDs, field.ID)
+			stoppedHere += stopped[field.ID]
+			dropOffPercentage += stoppedPercentage[field.ID]
+
+			entry := entries[field.ID]
+			if rate, ok := entry["response_rate"].(float64); ok && field.Type != models.FieldTypeHidden {
+				rateSum += rate
+				rated++
+			}
+			if timing, ok := entry["time_spent"].(fiber.Map); ok {
+				if average, ok := timing["average_ms"].(float64); ok {
+					timeSum += average
+					timed++
+				}
+			}
+		}
+
+		completionRate := float64(0)
+		if rated > 0 {
+			completionRate = rateSum / float64(rated)
+		}
+
+		rollup := fiber.Map{
+			"section_id":          section.ID,
+			"title":               section.Title,
+			"order":               i,
+			"field_ids":           fieldIDs,
+			"completion_rate":     completionRate,
+			"stopped_here":        stoppedHere,
+			"drop_off_percentage": dropOffPercentage,
+		}
+		// Time is the sum of the per-field averages, so it is only reported
+		// once every field has timing data
+		if timed > 0 && timed == len(fields) {
+			rollup["average_time_ms"] = timeSum
+		}
+		result = append(result, rollup)
+	}
+
+	return result
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultResponseSource is recorded for submissions that don't name a source
+const defaultResponseSource = "direct"
+
+// sourcePattern restricts sources to short lowercase labels such as "email" or "in-app"
+var sourcePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,49}$`)
+
+// submissionSource picks the channel a submission came through: the request's
+// source, else the ?source= query parameter, else a "source" metadata key.
+// Metadata is free-form and often set by other tools, so a metadata value
+// that isn't a valid source is ignored rather than rejected.
+func submissionSource(c *fiber.Ctx, req models.SubmitResponseRequest) (string, error) {
+	source := req.Source
+	if source == "" {
+		source = c.Query("source")
+	}
+	if source == "" {
+		source, _ = req.Metadata["source"].(string)
+		source = strings.ToLower(strings.TrimSpace(source))
+		if !sourcePattern.MatchString(source) {
+			return defaultResponseSource, nil
+		}
+		return source, nil
+	}
+
+	source = strings.ToLower(strings.TrimSpace(source))
+	if source == "" {
+		return defaultResponseSource, nil
+	}
+	if !sourcePattern.MatchString(source) {
+		return "", fiber.NewError(400, "Source must be up to 50 lowercase letters, digits, dots, dashes or underscores")
+	}
+	return source, nil
+}
+
+// sourceFilter matches responses from a source; responses stored before
+// sources were recorded count as direct
+func sourceFilter(source string) interface{} {
+	source = strings.ToLower(strings.TrimSpace(source))
+	if source == defaultResponseSource {
+		return bson.M{"$in": bson.A{defaultResponseSource, nil}}
+	}
+	return source
+}
+
+// responsesBySource counts a form's responses per source, largest first
+func (rc *ResponseController) responsesBySource(ctx context.Context, formID primitive.ObjectID, opts analyticsOptions) ([]fiber.Map, error) {
+	cursor, err := rc.responseCollection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"form_id": formID, "$nor": opts.uncounted()}},
+		{"$group": bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$source", defaultResponseSource}},
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var results []struct {
+		Source string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	sources := make([]fiber.Map, 0, len(results))
+	for _, result := range results {
+		sources = append(sources, fiber.Map{"source": result.Source, "count": result.Count})
+	}
+	return sources, nil
+}
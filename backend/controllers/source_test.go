@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSubmissionSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		req      models.SubmitResponseRequest
+		want     string
+		wantCode int
+	}{
+		{"default", "", models.SubmitResponseRequest{}, "direct", 200},
+		{"body", "?source=email", models.SubmitResponseRequest{Source: "In-App"}, "in-app", 200},
+		{"query", "?source=email", models.SubmitResponseRequest{}, "email", 200},
+		{"invalid body", "", models.SubmitResponseRequest{Source: "Google Ads"}, "", 400},
+		{"invalid query", "?source=Google%20Ads", models.SubmitResponseRequest{}, "", 400},
+		{"metadata", "", models.SubmitResponseRequest{Metadata: map[string]interface{}{"source": "Newsletter"}}, "newsletter", 200},
+		{"invalid metadata", "", models.SubmitResponseRequest{Metadata: map[string]interface{}{"source": "Google Ads"}}, "direct", 200},
+		{"non-string metadata", "", models.SubmitResponseRequest{Metadata: map[string]interface{}{"source": 3}}, "direct", 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				source, err := submissionSource(c, tt.req)
+				if err != nil {
+					return err
+				}
+				return c.SendString(source)
+			})
+			resp, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil))
+			if err != nil {
+				t.Fatalf("app.Test: %v", err)
+			}
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantCode)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if tt.wantCode == 200 && string(body) != tt.want {
+				t.Errorf("submissionSource() = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Submission outcomes, named after their counter in the form_stats collection
+const (
+	outcomeSucceeded        = "succeeded"
+	outcomeValidationFailed = "validation_failed"
+	outcomeRateLimited      = "rate_limited"
+	outcomeServerError      = "server_errors"
+)
+
+// recordSubmissionOutcome increments the form's counter for outcome in the
+// background; a lost increment only skews the stats, so failures are logged
+func (rc *ResponseController) recordSubmissionOutcome(formID primitive.ObjectID, outcome string) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_, err := rc.statsCollection.UpdateOne(
+			ctx,
+			bson.M{"form_id": formID},
+			bson.M{
+				"$inc": bson.M{outcome: 1},
+				"$set": bson.M{"updated_at": time.Now()},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			log.Printf("Failed to record %s submission for form %s: %v", outcome, formID.Hex(), err)
+		}
+	}()
+}
+
+// GetSubmissionStats gets submission counters for a form, including failed
+// attempts that never show up in the responses analytics
+func (rc *ResponseController) GetSubmissionStats(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	count, err := rc.formCollection.CountDocuments(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	if count == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	stats := models.SubmissionStats{FormID: objectID}
+	err = rc.statsCollection.FindOne(context.Background(), bson.M{"form_id": objectID}).Decode(&stats)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch submission stats"})
+	}
+
+	attempts := stats.Succeeded + stats.ValidationFailed + stats.RateLimited + stats.ServerErrors
+	rate := func(n int64) float64 {
+		if attempts == 0 {
+			return 0
+		}
+		return float64(n) / float64(attempts) * 100
+	}
+
+	return c.JSON(fiber.Map{
+		"stats":    stats,
+		"attempts": attempts,
+		"rates": fiber.Map{
+			"success":           rate(stats.Succeeded),
+			"validation_failed": rate(stats.ValidationFailed),
+			"rate_limited":      rate(stats.RateLimited),
+			"server_errors":     rate(stats.ServerErrors),
+		},
+	})
+}
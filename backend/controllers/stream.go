@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"form-builder-api/models"
+	"form-builder-api/websocket"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// streamHeartbeat is how often an idle event stream sends a comment, so
+// proxies keep the connection open and closed clients are noticed
+const streamHeartbeat = 15 * time.Second
+
+// StreamResponses streams a form's real-time events (response_submitted,
+// analytics_updated and the form's other broadcasts) as Server-Sent Events,
+// for clients that can't use the WebSocket feed. Each event is named after
+// its type and carries the same JSON message WebSocket subscribers receive.
+// The stream carries response data, so it requires a signed-in user.
+func (rc *ResponseController) StreamResponses(c *fiber.Ctx) error {
+	if _, ok := c.Locals("user").(*models.AuthenticatedUser); !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication required"})
+	}
+
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	count, err := rc.formCollection.CountDocuments(context.Background(), bson.M{"_id": objectID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	if count == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	client := rc.hub.Subscribe(id)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer rc.hub.Unsubscribe(client)
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+		for {
+			select {
+			case message, ok := <-client.Send:
+				if !ok {
+					return
+				}
+				// Hub-wide broadcasts reach every client; only the form's are streamed
+				var msg websocket.Message
+				if err := json.Unmarshal(message, &msg); err != nil || msg.FormID != id {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, message)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
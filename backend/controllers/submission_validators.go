@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SubmissionValidator is an extension point for deployment-specific business
+// rules, such as checking a promo code against another system. Validators
+// run after the built-in field validation passes, for submissions, previews
+// and edits alike; returning an error rejects the submission with its
+// message. Return a *FieldError to point the respondent at a field.
+type SubmissionValidator interface {
+	Validate(ctx context.Context, form models.Form, responses map[string]interface{}) error
+}
+
+// SubmissionValidatorFunc adapts a function to SubmissionValidator
+type SubmissionValidatorFunc func(ctx context.Context, form models.Form, responses map[string]interface{}) error
+
+// Validate calls f
+func (f SubmissionValidatorFunc) Validate(ctx context.Context, form models.Form, responses map[string]interface{}) error {
+	return f(ctx, form, responses)
+}
+
+// FieldError is a validation error about one field's answer
+type FieldError struct {
+	FieldID string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// submissionValidatorTimeout bounds how long all custom validators may take
+// for one submission
+const submissionValidatorTimeout = 5 * time.Second
+
+var (
+	submissionValidatorsMu sync.RWMutex
+	submissionValidators   []SubmissionValidator
+)
+
+// RegisterSubmissionValidator adds a validator that every submission must
+// pass. Validators run in registration order and stop at the first error.
+// Register them at startup, before the server accepts requests.
+func RegisterSubmissionValidator(validator SubmissionValidator) {
+	submissionValidatorsMu.Lock()
+	defer submissionValidatorsMu.Unlock()
+	submissionValidators = append(submissionValidators, validator)
+}
+
+// runSubmissionValidators runs the registered validators against a
+// submission that passed the built-in validation
+func runSubmissionValidators(form models.Form, responses map[string]interface{}) error {
+	submissionValidatorsMu.RLock()
+	validators := submissionValidators
+	submissionValidatorsMu.RUnlock()
+
+	if len(validators) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), submissionValidatorTimeout)
+	defer cancel()
+
+	for _, validator := range validators {
+		if err := validator.Validate(ctx, form, responses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validationErrorBody is the error response for a rejected submission. It
+// names the field when the error is about one.
+func validationErrorBody(err error) fiber.Map {
+	body := fiber.Map{"error": err.Error()}
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) && fieldErr.FieldID != "" {
+		body["field_id"] = fieldErr.FieldID
+	}
+	return body
+}
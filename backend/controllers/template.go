@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+	"form-builder-api/websocket"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TemplateController handles form templates
+type TemplateController struct {
+	collection *mongo.Collection
+	forms      *FormController
+}
+
+// NewTemplateController creates a new template controller
+func NewTemplateController(hub *websocket.Hub) *TemplateController {
+	return &TemplateController{
+		collection: database.GetCollection("templates"),
+		forms:      NewFormController(hub),
+	}
+}
+
+// GetTemplates lists templates, optionally narrowed to a category
+func (tc *TemplateController) GetTemplates(c *fiber.Ctx) error {
+	filter := bson.M{}
+	if category := c.Query("category"); category != "" {
+		filter["category"] = category
+	}
+
+	cursor, err := tc.collection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch templates"})
+	}
+	defer cursor.Close(context.Background())
+
+	var templates []models.FormTemplate
+	if err := cursor.All(context.Background(), &templates); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode templates"})
+	}
+
+	if templates == nil {
+		templates = []models.FormTemplate{}
+	}
+
+	return c.JSON(templates)
+}
+
+// SaveAsTemplate copies a form's definition into a new template. Responses,
+// share tokens, slugs, owner and integration settings are not copied.
+func (tc *TemplateController) SaveAsTemplate(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var req models.SaveAsTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var form models.Form
+	err = tc.forms.collection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	template := models.FormTemplate{
+		ID:                primitive.NewObjectID(),
+		Name:              req.Name,
+		Category:          req.Category,
+		Description:       req.Description,
+		Title:             form.Title,
+		FormDescription:   form.Description,
+		Fields:            form.Fields,
+		Sections:          form.Sections,
+		RequireAtLeastOne: form.RequireAtLeastOne,
+		SourceFormID:      &form.ID,
+		CreatedAt:         time.Now(),
+	}
+
+	if _, err := tc.collection.InsertOne(context.Background(), template); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save template"})
+	}
+
+	recordAudit(c, "form_saved_as_template", objectID, nil, nil)
+
+	return c.Status(201).JSON(template)
+}
+
+// InstantiateTemplate creates a new draft form from a template
+func (tc *TemplateController) InstantiateTemplate(c *fiber.Ctx) error {
+	templateID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	var req models.InstantiateTemplateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.OwnerSlug != "" && !slugPattern.MatchString(req.OwnerSlug) {
+		return c.Status(400).JSON(fiber.Map{"error": "Owner slug may only contain lowercase letters, digits and single dashes"})
+	}
+
+	var template models.FormTemplate
+	err = tc.collection.FindOne(context.Background(), bson.M{"_id": templateID}).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Template not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch template"})
+	}
+
+	title := template.Title
+	if req.Title != "" {
+		title = req.Title
+	}
+	title = sanitizeText(title)
+	if err := validateFormTitle(title); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Templates may predate this deployment's configuration (e.g. encryption)
+	fields := append([]models.FormField(nil), template.Fields...)
+	if err := validateFormFields(fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateSections(template.Sections, fields); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	slug, err := tc.forms.uniqueSlug(req.OwnerSlug, title)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create form"})
+	}
+
+	shareToken, err := tc.forms.uniqueShareToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create form"})
+	}
+
+	now := time.Now()
+	form := models.Form{
+		ID:          primitive.NewObjectID(),
+		Title:       title,
+		Description: template.FormDescription,
+		Fields:      fields,
+		Sections:    template.Sections,
+		IsPublished: false,
+		ShareToken:  shareToken,
+		OwnerSlug:   req.OwnerSlug,
+		Slug:        slug,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+
+		RequireAtLeastOne: template.RequireAtLeastOne,
+	}
+
+	if _, err := tc.forms.collection.InsertOne(context.Background(), form); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create form"})
+	}
+
+	recordAudit(c, "form_created", form.ID, nil, nil)
+
+	tc.forms.hub.BroadcastGeneral("form_created", form)
+
+	return c.Status(201).JSON(form)
+}
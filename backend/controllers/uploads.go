@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"form-builder-api/models"
+	"form-builder-api/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// validateFileAnswer checks an upload against the field's size and type
+// limits and turns it into a models.Upload, kept until storeUploads stores
+// it.
+// Answers already stored (e.g. when a saved response is resumed) are kept as
+// their reference; submissions can't send references themselves, see
+// checkSubmittedFiles.
+func validateFileAnswer(field models.FormField, value interface{}, required bool) (interface{}, error) {
+	// A file input left empty posts an empty value
+	if value == "" {
+		return value, nil
+	}
+
+	if upload, ok := value.(models.Upload); ok {
+		return upload, nil
+	}
+	header, ok := value.(*multipart.FileHeader)
+	if !ok {
+		answer, ok := models.AsFileAnswer(value)
+		if !ok {
+			return nil, fiber.NewError(400, "Value for file field '"+field.Label+"' must be an uploaded file")
+		}
+		return answer, nil
+	}
+
+	if header.Size > field.EffectiveMaxFileSize() {
+		return nil, fiber.NewError(400, fmt.Sprintf("File for field '%s' is too large (max %d bytes)", field.Label, field.EffectiveMaxFileSize()))
+	}
+	mimeType, err := uploadMimeType(header)
+	if err != nil {
+		return nil, fiber.NewError(400, "Could not read the file for field '"+field.Label+"'")
+	}
+	if !field.AllowsMimeType(mimeType) {
+		return nil, fiber.NewError(400, "Files of type "+mimeType+" are not accepted for field '"+field.Label+"'")
+	}
+	digest, err := uploadDigest(header)
+	if err != nil {
+		return nil, fiber.NewError(400, "Could not read the file for field '"+field.Label+"'")
+	}
+	return models.Upload{Header: header, ContentType: mimeType, SHA256: digest}, nil
+}
+
+// uploadDigest returns the hex SHA-256 of an upload's contents
+func uploadDigest(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checkSubmittedFiles makes sure the answers a client submits to file fields
+// are uploads. A reference to a stored file would let a submission claim a
+// file it never uploaded.
+func checkSubmittedFiles(responses map[string]interface{}, fields []models.FormField) error {
+	for _, field := range fields {
+		if field.Type != models.FieldTypeFile {
+			continue
+		}
+		switch responses[field.ID].(type) {
+		case nil, *multipart.FileHeader:
+		case string:
+			if responses[field.ID] != "" {
+				return fiber.NewError(400, "Value for file field '"+field.Label+"' must be an uploaded file")
+			}
+		default:
+			return fiber.NewError(400, "Value for file field '"+field.Label+"' must be an uploaded file")
+		}
+	}
+	return nil
+}
+
+// checkEditedFiles makes sure an edit leaves the files of a response as they
+// are: answers to file fields must be the stored reference or be left out
+func checkEditedFiles(responses, stored map[string]interface{}, fields []models.FormField) error {
+	for _, field := range fields {
+		value, exists := responses[field.ID]
+		if field.Type != models.FieldTypeFile || !exists || value == nil || value == "" {
+			continue
+		}
+		answer, ok := models.AsFileAnswer(value)
+		current, _ := models.AsFileAnswer(stored[field.ID])
+		if !ok || answer != current {
+			return fiber.NewError(400, "The file for field '"+field.Label+"' can't be changed when editing a response")
+		}
+	}
+	return nil
+}
+
+// uploadMimeType returns the media type of an upload as sniffed from its
+// contents; the type the client declares is only used to narrow down a
+// generic sniffed type, see resolveMimeType
+func uploadMimeType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	declared, _, _ := mime.ParseMediaType(header.Header.Get(fiber.HeaderContentType))
+	return resolveMimeType(sniffed, declared), nil
+}
+
+// resolveMimeType picks the type an upload is checked and served as. The
+// sniffed type wins, except that a declared type may refine the generic
+// types sniffing ends with for many formats: plain text may be declared as
+// another non-markup text type (e.g. text/csv) and a ZIP archive as a
+// vendor document format (e.g. .docx). Anything else sniffed as unknown
+// binary data stays application/octet-stream.
+func resolveMimeType(sniffed, declared string) string {
+	declared = strings.ToLower(declared)
+	switch sniffed {
+	case "text/plain":
+		if strings.HasPrefix(declared, "text/") && declared != "text/html" && declared != "text/xml" {
+			return declared
+		}
+	case "application/zip":
+		if strings.HasPrefix(declared, "application/vnd.") {
+			return declared
+		}
+	}
+	return sniffed
+}
+
+// storeUploads stores the validated uploads among a submission's answers in
+// upload storage and replaces each with its FileAnswer reference. It returns
+// the keys it stored so callers can remove them again if the response isn't
+// saved; on error nothing is left behind.
+func storeUploads(ctx context.Context, formID primitive.ObjectID, responses map[string]interface{}) ([]string, error) {
+	var keys []string
+	for fieldID, value := range responses {
+		upload, ok := value.(models.Upload)
+		if !ok {
+			continue
+		}
+
+		uploads := storage.Uploads()
+		if uploads == nil {
+			return nil, fmt.Errorf("upload storage is not available")
+		}
+
+		file, err := upload.Header.Open()
+		if err != nil {
+			removeUploads(keys)
+			return nil, err
+		}
+		key := formID.Hex() + "-" + generateShareToken()
+		size, err := uploads.Put(ctx, key, file)
+		file.Close()
+		if err != nil {
+			removeUploads(keys)
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		responses[fieldID] = models.FileAnswer{
+			Key:         key,
+			Name:        upload.Header.Filename,
+			ContentType: upload.ContentType,
+			Size:        size,
+			SHA256:      upload.SHA256,
+		}
+	}
+	return keys, nil
+}
+
+// removeUploads deletes stored uploads, logging the ones that can't be
+// deleted; CleanupUploads gets to those later
+func removeUploads(keys []string) {
+	uploads := storage.Uploads()
+	if uploads == nil {
+		return
+	}
+	for _, key := range keys {
+		if err := uploads.Delete(key); err != nil {
+			log.Printf("Failed to delete upload %s: %v", key, err)
+		}
+	}
+}
+
+// uploadKeys lists the stored files a form's answers reference, recorded on
+// the response as files so they can be deleted along with it. Only keys
+// storeUploads could have made for the form count.
+func uploadKeys(formID primitive.ObjectID, responses map[string]interface{}) []string {
+	keys := []string{}
+	for _, value := range responses {
+		answer, ok := models.AsFileAnswer(value)
+		if ok && strings.HasPrefix(answer.Key, formID.Hex()+"-") {
+			keys = append(keys, answer.Key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeKeys returns the sorted union of two key lists
+func mergeKeys(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	keys := []string{}
+	for _, key := range append(append([]string{}, a...), b...) {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deleteResponseUploads deletes the stored files of the responses matching
+// filter. Call it before deleting the responses themselves.
+func deleteResponseUploads(ctx context.Context, responses *mongo.Collection, filter bson.M) error {
+	cursor, err := responses.Find(ctx, filter, options.Find().SetProjection(bson.M{"files": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			Files []string `bson:"files"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		removeUploads(doc.Files)
+	}
+	return cursor.Err()
+}
+
+// previewUploads returns a copy of a submission's answers with each upload
+// shown as the file answer it would be stored as, less its key
+func previewUploads(responses map[string]interface{}) map[string]interface{} {
+	preview := make(map[string]interface{}, len(responses))
+	for fieldID, value := range responses {
+		if upload, ok := value.(models.Upload); ok {
+			value = models.FileAnswer{
+				Name:        upload.Header.Filename,
+				ContentType: upload.ContentType,
+				Size:        upload.Header.Size,
+				SHA256:      upload.SHA256,
+			}
+		}
+		preview[fieldID] = value
+	}
+	return preview
+}
+
+// DownloadFile serves the file uploaded as a response's answer to a file field
+func (rc *ResponseController) DownloadFile(c *fiber.Ctx) error {
+	objectID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	responseID, err := primitive.ObjectIDFromHex(c.Params("responseId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid response ID"})
+	}
+
+	var response models.FormResponse
+	err = rc.responseCollection.FindOne(context.Background(), bson.M{
+		"_id":     responseID,
+		"form_id": objectID,
+	}).Decode(&response)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Response not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch response"})
+	}
+
+	decryptResponses(response.Responses)
+	answer, ok := models.AsFileAnswer(response.Responses[c.Params("fieldId")])
+	if !ok {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	uploads := storage.Uploads()
+	if uploads == nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Upload storage is not available"})
+	}
+	file, err := uploads.Open(answer.Key)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "File not found"})
+	}
+
+	if answer.ContentType != "" {
+		c.Set(fiber.HeaderContentType, answer.ContentType)
+	}
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	name := answer.Name
+	if name == "" {
+		name = answer.Key
+	}
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	return c.SendStream(file)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"mime/multipart"
+	"reflect"
+	"testing"
+
+	"form-builder-api/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestResolveMimeType(t *testing.T) {
+	tests := []struct {
+		sniffed, declared, want string
+	}{
+		{"image/png", "image/png", "image/png"},
+		{"application/octet-stream", "image/png", "application/octet-stream"},
+		{"text/html", "image/png", "text/html"},
+		{"text/plain", "text/csv", "text/csv"},
+		{"text/plain", "text/html", "text/plain"},
+		{"text/plain", "image/png", "text/plain"},
+		{"application/zip", "application/vnd.openxmlformats-officedocument.wordprocessingml.document", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"application/zip", "application/pdf", "application/zip"},
+		{"application/pdf", "", "application/pdf"},
+	}
+	for _, tt := range tests {
+		if got := resolveMimeType(tt.sniffed, tt.declared); got != tt.want {
+			t.Errorf("resolveMimeType(%q, %q) = %q, want %q", tt.sniffed, tt.declared, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSubmittedFiles(t *testing.T) {
+	fields := []models.FormField{{ID: "cv", Label: "CV", Type: models.FieldTypeFile}}
+	tests := []struct {
+		name    string
+		value   interface{}
+		wantErr bool
+	}{
+		{"upload", &multipart.FileHeader{Filename: "cv.pdf"}, false},
+		{"left empty", "", false},
+		{"missing", nil, false},
+		{"stored reference", map[string]interface{}{"key": "abc", "name": "cv.pdf"}, true},
+		{"text", "cv.pdf", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := map[string]interface{}{}
+			if tt.value != nil {
+				responses["cv"] = tt.value
+			}
+			if err := checkSubmittedFiles(responses, fields); (err != nil) != tt.wantErr {
+				t.Errorf("checkSubmittedFiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadKeys(t *testing.T) {
+	formID, _ := primitive.ObjectIDFromHex("65a1b2c3d4e5f60718293a4b")
+	responses := map[string]interface{}{
+		"cv":     models.FileAnswer{Key: formID.Hex() + "-b", Name: "cv.pdf"},
+		"photo":  map[string]interface{}{"key": formID.Hex() + "-a", "name": "me.png"},
+		"other":  map[string]interface{}{"key": "65a1b2c3d4e5f60718293a4c-c"},
+		"plain":  map[string]interface{}{"key": "value"},
+		"name":   "Ada",
+		"skills": []interface{}{"go"},
+	}
+	want := []string{formID.Hex() + "-a", formID.Hex() + "-b"}
+	if got := uploadKeys(formID, responses); !reflect.DeepEqual(got, want) {
+		t.Errorf("uploadKeys() = %v, want %v", got, want)
+	}
+	if got := uploadKeys(formID, nil); len(got) != 0 || got == nil {
+		t.Errorf("uploadKeys(nil) = %#v, want an empty list", got)
+	}
+}
+
+func TestMergeKeys(t *testing.T) {
+	got := mergeKeys([]string{"b", "a"}, []string{"c", "a"})
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeKeys() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// validationReportSamples caps how many offending response IDs are listed per rule
+const validationReportSamples = 10
+
+// ValidationFailure groups stored responses that fail the same rule
+type ValidationFailure struct {
+	Rule      string               `json:"rule"`
+	Count     int64                `json:"count"`
+	Responses []primitive.ObjectID `json:"sample_responses"`
+}
+
+// GetValidationReport re-validates every completed response against the
+// form's current fields; saved-for-later partials would fail required checks
+// they were never meant to pass, so they're skipped. Responses are streamed, and each one is reported under the
+// first rule it fails, matching the error a new submission would get.
+func (rc *ResponseController) GetValidationReport(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = rc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	ctx := context.Background()
+	cursor, err := rc.responseCollection.Find(ctx,
+		bson.M{"form_id": objectID, "incomplete": completeResponses},
+		options.Find().
+			SetProjection(bson.M{"responses": 1}).
+			SetSort(bson.M{"_id": 1}).
+			SetBatchSize(500),
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch responses"})
+	}
+	defer cursor.Close(ctx)
+
+	var checked, invalid int64
+	failures := make(map[string]*ValidationFailure)
+	for cursor.Next(ctx) {
+		var response models.FormResponse
+		if err := cursor.Decode(&response); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to decode responses"})
+		}
+		checked++
+
+		if response.Responses == nil {
+			response.Responses = map[string]interface{}{}
+		}
+		decryptResponses(response.Responses)
+
+		err := rc.validateResponse(response.Responses, form)
+		if err == nil {
+			continue
+		}
+		invalid++
+
+		rule := err.Error()
+		failure, ok := failures[rule]
+		if !ok {
+			failure = &ValidationFailure{Rule: rule, Responses: []primitive.ObjectID{}}
+			failures[rule] = failure
+		}
+		failure.Count++
+		if len(failure.Responses) < validationReportSamples {
+			failure.Responses = append(failure.Responses, response.ID)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch responses"})
+	}
+
+	report := make([]*ValidationFailure, 0, len(failures))
+	for _, failure := range failures {
+		report = append(report, failure)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Rule < report[j].Rule
+	})
+
+	return c.JSON(fiber.Map{
+		"form_id":           objectID,
+		"checked_responses": checked,
+		"invalid_responses": invalid,
+		"failures":          report,
+	})
+}
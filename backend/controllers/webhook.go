@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+	"form-builder-api/webhook"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// webhookAttempts bounds automatic retries of a single delivery
+	webhookAttempts = 3
+	// webhookAttemptTimeout bounds a single delivery attempt
+	webhookAttemptTimeout = 15 * time.Second
+)
+
+// WebhookController handles webhook deliveries and tooling for integrators
+type WebhookController struct {
+	formCollection     *mongo.Collection
+	deliveryCollection *mongo.Collection
+}
+
+// NewWebhookController creates a new webhook controller
+func NewWebhookController() *WebhookController {
+	return &WebhookController{
+		formCollection:     database.GetCollection("forms"),
+		deliveryCollection: database.GetCollection("webhook_deliveries"),
+	}
+}
+
+// attemptWebhook makes one delivery attempt and records it in the delivery log.
+// Form owners choose the URL and can read the reply back from the log, so
+// only public addresses are reached.
+func attemptWebhook(form models.Form, delivery models.WebhookDelivery) models.WebhookDelivery {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookAttemptTimeout)
+	result, err := webhook.DeliverPublic(ctx, delivery.URL, delivery.Event, []byte(delivery.Payload), form.WebhookSecret)
+	cancel()
+
+	delivery.ID = primitive.NewObjectID()
+	delivery.StatusCode = result.StatusCode
+	delivery.ResponseBody = result.Body
+	delivery.DurationMs = result.Duration.Milliseconds()
+	delivery.Success = err == nil && result.Success()
+	delivery.CreatedAt = time.Now()
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	logCtx, logCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer logCancel()
+	if _, err := database.GetCollection("webhook_deliveries").InsertOne(logCtx, delivery); err != nil {
+		log.Printf("Failed to log webhook delivery for form %s: %v", form.ID.Hex(), err)
+	}
+
+	return delivery
+}
+
+// dispatchWebhook delivers an event to the form's webhook in the background,
+// retrying with backoff. Every attempt lands in the delivery log so failed
+// deliveries can be inspected and redelivered. Forms with ordered delivery
+// queue the event instead, see enqueueWebhook.
+func dispatchWebhook(form models.Form, event string, responseID *primitive.ObjectID, data fiber.Map) {
+	if form.WebhookURL == "" {
+		return
+	}
+
+	body := fiber.Map{"event": event, "form_id": form.ID.Hex()}
+	for key, value := range data {
+		body[key] = value
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("Failed to build %s webhook payload for form %s: %v", event, form.ID.Hex(), err)
+		return
+	}
+	if form.WebhookTransform != nil {
+		payload, err = transformWebhookPayload(payload, form.WebhookTransform)
+		if err != nil {
+			log.Printf("Failed to transform %s webhook payload for form %s: %v", event, form.ID.Hex(), err)
+			return
+		}
+	}
+
+	if form.WebhookOrdered {
+		enqueueWebhook(form, event, responseID, payload)
+		return
+	}
+
+	go deliverWithRetries(form, event, responseID, string(payload))
+}
+
+// deliverWithRetries delivers a built payload to the form's webhook, making
+// up to webhookAttempts attempts with backoff
+func deliverWithRetries(form models.Form, event string, responseID *primitive.ObjectID, payload string) {
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		delivery := attemptWebhook(form, models.WebhookDelivery{
+			FormID:     form.ID,
+			ResponseID: responseID,
+			Event:      event,
+			URL:        form.WebhookURL,
+			Payload:    payload,
+			Attempt:    attempt,
+		})
+		if delivery.Success {
+			return
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt*attempt) * time.Second)
+		}
+	}
+}
+
+// GetDeliveries lists a form's webhook delivery attempts, newest first.
+// ?success=false narrows the log to failures.
+func (wc *WebhookController) GetDeliveries(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	filter := bson.M{"form_id": objectID}
+	if success := c.Query("success"); success != "" {
+		value, err := strconv.ParseBool(success)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid success parameter"})
+		}
+		filter["success"] = value
+	}
+
+	total, err := wc.deliveryCollection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to count deliveries"})
+	}
+
+	cursor, err := wc.deliveryCollection.Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)).
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}),
+	)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch deliveries"})
+	}
+	defer cursor.Close(context.Background())
+
+	var deliveries []models.WebhookDelivery
+	if err := cursor.All(context.Background(), &deliveries); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to decode deliveries"})
+	}
+
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	return c.JSON(fiber.Map{
+		"deliveries": deliveries,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total":      total,
+			"totalPages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// Redeliver resends a logged delivery's payload to the form's current webhook
+// URL and returns the new attempt
+func (wc *WebhookController) Redeliver(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+	deliveryID, err := primitive.ObjectIDFromHex(c.Params("deliveryId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid delivery ID"})
+	}
+
+	var form models.Form
+	err = wc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+	if form.WebhookURL == "" {
+		return c.Status(409).JSON(fiber.Map{"error": "Form has no webhook configured"})
+	}
+
+	var original models.WebhookDelivery
+	err = wc.deliveryCollection.FindOne(context.Background(), bson.M{
+		"_id":     deliveryID,
+		"form_id": objectID,
+	}).Decode(&original)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Delivery not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch delivery"})
+	}
+
+	// The URL may have been fixed since the original attempt, so use the current one
+	delivery := attemptWebhook(form, models.WebhookDelivery{
+		FormID:       objectID,
+		ResponseID:   original.ResponseID,
+		Event:        original.Event,
+		URL:          form.WebhookURL,
+		Payload:      original.Payload,
+		Attempt:      1,
+		RedeliveryOf: &original.ID,
+	})
+
+	recordAudit(c, "webhook_redelivered", objectID, original.ResponseID, nil)
+
+	return c.JSON(delivery)
+}
+
+// isHTTPURL reports whether target is an absolute http(s) URL
+func isHTTPURL(target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isPublicURL reports whether target's host resolves only to public
+// addresses, see webhook.CheckPublicHost. Delivery checks the address it
+// dials again, so this only turns a bad target away when it is saved.
+func isPublicURL(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return webhook.CheckPublicHost(ctx, u.Hostname()) == nil
+}
+
+// TestWebhook sends a signed sample payload to the given URL so integrators
+// can check their signature verification against a real delivery. Only
+// public addresses can be targeted and only the receiver's status code is
+// reported back, so the endpoint can't be used to probe internal services.
+func (wc *WebhookController) TestWebhook(c *fiber.Ctx) error {
+	var req models.TestWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	target, err := url.Parse(req.URL)
+	if err != nil || !isHTTPURL(req.URL) {
+		return c.Status(400).JSON(fiber.Map{"error": "url must be an absolute http(s) URL"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := webhook.CheckPublicHost(ctx, target.Hostname()); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "url must point to a public address"})
+	}
+
+	payload, err := json.Marshal(fiber.Map{
+		"event":   "webhook_test",
+		"form_id": primitive.NewObjectID().Hex(),
+		"response": fiber.Map{
+			"id":         primitive.NewObjectID().Hex(),
+			"responses":  fiber.Map{"example_field": "example answer"},
+			"created_at": time.Now(),
+		},
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to build test payload"})
+	}
+
+	result, err := webhook.DeliverPublic(ctx, req.URL, "webhook_test", payload, req.Secret)
+	delivery := fiber.Map{
+		"payload":          string(payload),
+		"signature_header": webhook.SignatureHeader,
+		"signature":        webhook.Sign(payload, req.Secret),
+		"verification":     "Compute HMAC-SHA256 of the raw request body with your secret, hex-encode it, prefix with 'sha256=' and compare it to the " + webhook.SignatureHeader + " header using a constant-time comparison.",
+		"status_code":      result.StatusCode,
+		"success":          err == nil && result.Success(),
+	}
+	if err != nil {
+		delivery["error"] = "Delivery failed"
+	}
+
+	return c.JSON(delivery)
+}
@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"form-builder-api/database"
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// webhookQueueMaxBackoff caps the wait between attempts at the head of an
+	// ordered queue; the event is retried until it is delivered
+	webhookQueueMaxBackoff = 10 * time.Minute
+	// webhookQueueErrorDelay is how long a queue waits after a database error
+	webhookQueueErrorDelay = 30 * time.Second
+	// webhookQueueLease is how long an instance holds the head of a queue
+	// while delivering it; long enough for one attempt and its log entry
+	webhookQueueLease = 2 * webhookAttemptTimeout
+)
+
+// webhookQueue drains ordered webhook queues, one goroutine per form with
+// queued events. The queue itself lives in the webhook_queue collection.
+type webhookQueue struct {
+	mu       sync.Mutex
+	draining map[primitive.ObjectID]bool
+	// pending marks forms that got new events while being drained, so the
+	// drain looks again before it stops
+	pending map[primitive.ObjectID]bool
+}
+
+var orderedWebhooks = &webhookQueue{
+	draining: make(map[primitive.ObjectID]bool),
+	pending:  make(map[primitive.ObjectID]bool),
+}
+
+// enqueueWebhook adds an event to the form's ordered queue and makes sure the
+// queue is being drained
+func enqueueWebhook(form models.Form, event string, responseID *primitive.ObjectID, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := database.GetCollection("webhook_queue").InsertOne(ctx, models.QueuedWebhook{
+		FormID:     form.ID,
+		ResponseID: responseID,
+		Event:      event,
+		Payload:    string(payload),
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to queue %s webhook for form %s: %v", event, form.ID.Hex(), err)
+		return
+	}
+	orderedWebhooks.kick(form.ID)
+}
+
+// ResumeOrderedWebhooks restarts draining every form's ordered webhook queue,
+// e.g. after a restart
+func ResumeOrderedWebhooks() {
+	formIDs, err := database.GetCollection("webhook_queue").Distinct(context.Background(), "form_id", bson.M{})
+	if err != nil {
+		log.Printf("Failed to resume ordered webhooks: %v", err)
+		return
+	}
+	for _, id := range formIDs {
+		if formID, ok := id.(primitive.ObjectID); ok {
+			orderedWebhooks.kick(formID)
+		}
+	}
+}
+
+// kick starts draining the form's queue unless it already is
+func (q *webhookQueue) kick(formID primitive.ObjectID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.draining[formID] {
+		q.pending[formID] = true
+		return
+	}
+	q.draining[formID] = true
+	go q.drain(formID)
+}
+
+// finish stops draining the form's queue, unless events arrived meanwhile
+func (q *webhookQueue) finish(formID primitive.ObjectID, force bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending[formID] && !force {
+		delete(q.pending, formID)
+		return false
+	}
+	delete(q.pending, formID)
+	delete(q.draining, formID)
+	return true
+}
+
+// drain delivers the form's queued events oldest first. The head of the
+// queue is retried with backoff until it succeeds, holding back the events
+// behind it, so the receiver never sees them out of order. Instances take a
+// lease on the head before delivering it, so only one delivers at a time.
+func (q *webhookQueue) drain(formID primitive.ObjectID) {
+	ctx := context.Background()
+	queue := database.GetCollection("webhook_queue")
+
+	for {
+		var head models.QueuedWebhook
+		err := queue.FindOne(ctx, bson.M{"form_id": formID},
+			options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}})).Decode(&head)
+		if err == mongo.ErrNoDocuments {
+			if q.finish(formID, false) {
+				return
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to read webhook queue of form %s: %v", formID.Hex(), err)
+			time.Sleep(webhookQueueErrorDelay)
+			continue
+		}
+
+		// Deliver with the form's current URL and secret
+		var form models.Form
+		err = database.GetCollection("forms").FindOne(ctx, bson.M{"_id": formID}).Decode(&form)
+		if err == mongo.ErrNoDocuments {
+			if _, err := queue.DeleteMany(ctx, bson.M{"form_id": formID}); err != nil {
+				log.Printf("Failed to drop webhook queue of deleted form %s: %v", formID.Hex(), err)
+			}
+			q.finish(formID, true)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to load form %s for its webhook queue: %v", formID.Hex(), err)
+			time.Sleep(webhookQueueErrorDelay)
+			continue
+		}
+		if form.WebhookURL == "" {
+			// Kept until a webhook is configured again and the queue resumes
+			q.finish(formID, true)
+			return
+		}
+		if !form.WebhookOrdered {
+			// Ordering was switched off: what is left goes out like any other event
+			if err := releaseWebhookQueue(ctx, queue, form); err != nil {
+				log.Printf("Failed to release webhook queue of form %s: %v", formID.Hex(), err)
+				time.Sleep(webhookQueueErrorDelay)
+				continue
+			}
+			// An event another instance had leased is released once its lease is up
+			if head.LockedUntil != nil && time.Now().Before(*head.LockedUntil) {
+				time.Sleep(leaseWait(head))
+			}
+			continue
+		}
+
+		item, err := leaseQueuedWebhook(ctx, queue, head.ID, time.Now().Add(webhookQueueLease))
+		if err == mongo.ErrNoDocuments {
+			// Another instance holds the head; look again once its lease is up
+			time.Sleep(leaseWait(head))
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to lease queued webhook %s: %v", head.ID.Hex(), err)
+			time.Sleep(webhookQueueErrorDelay)
+			continue
+		}
+
+		delivery := attemptWebhook(form, models.WebhookDelivery{
+			FormID:     formID,
+			ResponseID: item.ResponseID,
+			Event:      item.Event,
+			URL:        form.WebhookURL,
+			Payload:    item.Payload,
+			Attempt:    item.Attempts + 1,
+		})
+		if delivery.Success {
+			if _, err := queue.DeleteOne(ctx, bson.M{"_id": item.ID}); err != nil {
+				log.Printf("Failed to dequeue delivered webhook %s: %v", item.ID.Hex(), err)
+				time.Sleep(webhookQueueErrorDelay)
+			}
+			continue
+		}
+
+		// Keep the lease through the backoff so no other instance retries sooner
+		backoff := time.Duration((item.Attempts+1)*(item.Attempts+1)) * time.Second
+		if backoff > webhookQueueMaxBackoff {
+			backoff = webhookQueueMaxBackoff
+		}
+		_, err = queue.UpdateOne(ctx, bson.M{"_id": item.ID}, bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"locked_until": time.Now().Add(backoff)},
+		})
+		if err != nil {
+			log.Printf("Failed to record webhook attempt %s: %v", item.ID.Hex(), err)
+		}
+		time.Sleep(backoff)
+	}
+}
+
+// leaseQueuedWebhook takes the lease on a queued event until the given time,
+// unless another instance holds it. Returns mongo.ErrNoDocuments if the event
+// is leased or gone.
+func leaseQueuedWebhook(ctx context.Context, queue *mongo.Collection, id primitive.ObjectID, until time.Time) (models.QueuedWebhook, error) {
+	var item models.QueuedWebhook
+	err := queue.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "$or": leaseAvailable(time.Now())},
+		bson.M{"$set": bson.M{"locked_until": until}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&item)
+	return item, err
+}
+
+// leaseWait is how long to wait for the lease on a queued event to run out:
+// at least a second and at most webhookQueueMaxBackoff
+func leaseWait(item models.QueuedWebhook) time.Duration {
+	wait := time.Second
+	if item.LockedUntil != nil && time.Until(*item.LockedUntil) > wait {
+		wait = time.Until(*item.LockedUntil)
+	}
+	if wait > webhookQueueMaxBackoff {
+		wait = webhookQueueMaxBackoff
+	}
+	return wait
+}
+
+// leaseAvailable matches queued events nobody holds a lease on at now
+func leaseAvailable(now time.Time) bson.A {
+	return bson.A{
+		bson.M{"locked_until": bson.M{"$exists": false}},
+		bson.M{"locked_until": bson.M{"$lte": now}},
+	}
+}
+
+// releaseWebhookQueue hands the queued events of a form that no longer wants
+// ordered delivery to regular delivery, oldest first. Events leased by an
+// instance still delivering them in order are left to it.
+func releaseWebhookQueue(ctx context.Context, queue *mongo.Collection, form models.Form) error {
+	for {
+		var item models.QueuedWebhook
+		err := queue.FindOneAndDelete(ctx,
+			bson.M{"form_id": form.ID, "$or": leaseAvailable(time.Now())},
+			options.FindOneAndDelete().SetSort(bson.D{{Key: "_id", Value: 1}}),
+		).Decode(&item)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		go deliverWithRetries(form, item.Event, item.ResponseID, item.Payload)
+	}
+}
+
+// GetWebhookQueue reports a form's ordered webhook queue: how many events
+// are waiting and the one at its head, which blocks the rest until delivered
+func (wc *WebhookController) GetWebhookQueue(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+
+	var form models.Form
+	err = wc.formCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&form)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Form not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch form"})
+	}
+
+	queue := database.GetCollection("webhook_queue")
+	depth, err := queue.CountDocuments(context.Background(), bson.M{"form_id": objectID})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to count queued webhooks"})
+	}
+
+	result := fiber.Map{
+		"ordered": form.WebhookOrdered,
+		"depth":   depth,
+	}
+	var head models.QueuedWebhook
+	err = queue.FindOne(context.Background(), bson.M{"form_id": objectID},
+		options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}})).Decode(&head)
+	if err == nil {
+		result["head"] = head
+	} else if err != mongo.ErrNoDocuments {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch queued webhooks"})
+	}
+
+	return c.JSON(result)
+}
+
+// SkipQueuedWebhook drops an event from a form's ordered webhook queue, e.g. a
+// head the receiver will never accept, so the events behind it go out. An
+// event that is being delivered at that moment may still arrive.
+func (wc *WebhookController) SkipQueuedWebhook(c *fiber.Ctx) error {
+	id := c.Params("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid form ID"})
+	}
+	eventID, err := primitive.ObjectIDFromHex(c.Params("eventId"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+
+	var item models.QueuedWebhook
+	err = database.GetCollection("webhook_queue").FindOneAndDelete(context.Background(), bson.M{
+		"_id":     eventID,
+		"form_id": objectID,
+	}).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return c.Status(404).JSON(fiber.Map{"error": "Queued webhook not found"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to skip queued webhook"})
+	}
+
+	recordAudit(c, "webhook_skipped", objectID, item.ResponseID, []string{item.Event})
+	orderedWebhooks.kick(objectID)
+
+	return c.JSON(fiber.Map{"message": "Queued webhook skipped", "skipped": item})
+}
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"form-builder-api/models"
+)
+
+func TestLeaseWait(t *testing.T) {
+	at := func(d time.Duration) *time.Time {
+		when := time.Now().Add(d)
+		return &when
+	}
+	tests := []struct {
+		name          string
+		lockedUntil   *time.Time
+		atLeast, most time.Duration
+	}{
+		{"not leased", nil, time.Second, time.Second},
+		{"lease ran out", at(-time.Minute), time.Second, time.Second},
+		{"leased", at(30 * time.Second), 29 * time.Second, 30 * time.Second},
+		{"leased for long", at(time.Hour), webhookQueueMaxBackoff, webhookQueueMaxBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := leaseWait(models.QueuedWebhook{LockedUntil: tt.lockedUntil})
+			if got < tt.atLeast || got > tt.most {
+				t.Errorf("leaseWait() = %v, want between %v and %v", got, tt.atLeast, tt.most)
+			}
+		})
+	}
+}
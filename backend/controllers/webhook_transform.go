@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"form-builder-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Bounds on the size of a webhook transform: keys in its mapping and in its
+// static values, and the static values' encoded size in bytes
+const (
+	maxWebhookMappings    = 100
+	maxWebhookStaticBytes = 16 * 1024
+)
+
+// validateWebhookTransform checks that a transform's output keys are usable
+// and don't collide (e.g. "contact" and "contact.email")
+func validateWebhookTransform(transform *models.WebhookTransform) error {
+	if transform == nil {
+		return nil
+	}
+	if len(transform.Mapping) > maxWebhookMappings {
+		return fiber.NewError(400, "Webhook transform has too many mappings")
+	}
+	if len(transform.Static) > maxWebhookMappings {
+		return fiber.NewError(400, "Webhook transform has too many static values")
+	}
+	if len(transform.Static) > 0 {
+		encoded, err := json.Marshal(transform.Static)
+		if err != nil {
+			return fiber.NewError(400, "Webhook transform static values must be JSON")
+		}
+		if len(encoded) > maxWebhookStaticBytes {
+			return fiber.NewError(400, "Webhook transform static values must be at most 16 KB")
+		}
+	}
+
+	keys := make([]string, 0, len(transform.Mapping)+len(transform.Static))
+	for key, path := range transform.Mapping {
+		if strings.TrimSpace(path) == "" {
+			return fiber.NewError(400, "Webhook transform key '"+key+"' has no source path")
+		}
+		keys = append(keys, key)
+	}
+	for key := range transform.Static {
+		if _, ok := transform.Mapping[key]; ok {
+			return fiber.NewError(400, "Webhook transform key '"+key+"' is both mapped and static")
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	emitted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+			return fiber.NewError(400, "Invalid webhook transform key '"+key+"'")
+		}
+		emitted[key] = true
+	}
+
+	// A key conflicts with any other key that is one of its parent paths,
+	// wherever the two sort (e.g. "a" and "a.b" around "a-b")
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+		for n := 1; n < len(parts); n++ {
+			if parent := strings.Join(parts[:n], "."); emitted[parent] {
+				return fiber.NewError(400, "Webhook transform keys '"+parent+"' and '"+key+"' conflict")
+			}
+		}
+	}
+	return nil
+}
+
+// transformWebhookPayload reshapes a payload according to transform. Paths
+// that don't resolve produce null so receivers always see every key.
+func transformWebhookPayload(payload []byte, transform *models.WebhookTransform) ([]byte, error) {
+	var source map[string]interface{}
+	if err := json.Unmarshal(payload, &source); err != nil {
+		return nil, err
+	}
+
+	output := make(map[string]interface{})
+	for key, value := range transform.Static {
+		setPath(output, key, value)
+	}
+	for key, path := range transform.Mapping {
+		value := lookupPath(source, path)
+		if transform.Flatten {
+			if items, ok := value.([]interface{}); ok {
+				values, _ := models.AsStringSlice(items)
+				value = strings.Join(values, ", ")
+			}
+		}
+		setPath(output, key, value)
+	}
+
+	return json.Marshal(output)
+}
+
+// lookupPath resolves a dotted path in a decoded JSON document
+func lookupPath(document map[string]interface{}, path string) interface{} {
+	var current interface{} = document
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+	return current
+}
+
+// setPath stores value under a dotted key, creating nested objects as needed
+func setPath(document map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := document
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"form-builder-api/models"
+)
+
+func TestValidateWebhookTransform(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping map[string]string
+		static  map[string]interface{}
+		wantErr bool
+	}{
+		{"flat keys", map[string]string{"name": "responses.name", "email": "responses.email"}, nil, false},
+		{"nested keys", map[string]string{"contact.name": "responses.name", "contact.email": "responses.email"}, nil, false},
+		{"similar keys", map[string]string{"a": "x", "a-b": "y", "ab.c": "z"}, nil, false},
+		{"adjacent parent", map[string]string{"contact": "x", "contact.email": "y"}, nil, true},
+		{"parent sorted apart", map[string]string{"a": "x", "a-b": "y", "a.b": "z"}, nil, true},
+		{"grandparent sorted apart", map[string]string{"a": "x", "a-b": "y", "a.b.c": "z"}, nil, true},
+		{"static parent", map[string]string{"meta.source": "x"}, map[string]interface{}{"meta": "web"}, true},
+		{"mapped and static", map[string]string{"source": "x"}, map[string]interface{}{"source": "web"}, true},
+		{"empty path segment", map[string]string{"a..b": "x"}, nil, true},
+		{"no source path", map[string]string{"a": " "}, nil, true},
+		{"static at the key limit", nil, staticValues(maxWebhookMappings, "x"), false},
+		{"too many static keys", nil, staticValues(maxWebhookMappings+1, "x"), true},
+		{"oversized static values", nil, staticValues(2, strings.Repeat("x", maxWebhookStaticBytes)), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWebhookTransform(&models.WebhookTransform{Mapping: tt.mapping, Static: tt.static})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateWebhookTransform() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// staticValues builds n static values holding value
+func staticValues(n int, value string) map[string]interface{} {
+	static := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		static[fmt.Sprintf("key%d", i)] = value
+	}
+	return static
+}
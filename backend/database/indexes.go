@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the application relies on
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Per-IP submission counters expire on their own once their day is over
+	_, err := GetCollection("ip_submission_counters").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		log.Println("Error creating ip_submission_counters TTL index:", err)
+	}
+
+	// Form slugs are unique per owner; forms created before slugs existed are skipped
+	_, err = GetCollection("forms").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "owner_slug", Value: 1}, {Key: "slug", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("Error creating forms slug index:", err)
+	}
+
+	// Audit entries are listed per form, newest first
+	_, err = GetCollection("audit_log").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		log.Println("Error creating audit_log index:", err)
+	}
+
+	// Receipt codes are unique within a form
+	_, err = GetCollection("responses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "receipt_code", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"receipt_code": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("Error creating responses receipt_code index:", err)
+	}
+
+	// One submission stats document per form; concurrent upserts rely on this
+	_, err = GetCollection("form_stats").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "form_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("Error creating form_stats index:", err)
+	}
+
+	// Cached analytics are replaced per form
+	_, err = GetCollection("analytics").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "form_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("Error creating analytics index:", err)
+	}
+
+	// Export workers claim the oldest pending job
+	_, err = GetCollection("export_jobs").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		log.Println("Error creating export_jobs index:", err)
+	}
+
+	// Webhook deliveries are listed per form, newest first
+	_, err = GetCollection("webhook_deliveries").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		log.Println("Error creating webhook_deliveries index:", err)
+	}
+
+	// Ordered webhook queues are drained per form, oldest first
+	_, err = GetCollection("webhook_queue").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		log.Println("Error creating webhook_queue index:", err)
+	}
+
+	// One timing sample document per form field
+	_, err = GetCollection("field_timings").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "form_id", Value: 1}, {Key: "field_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("Error creating field_timings index:", err)
+	}
+
+	// Invite tokens are looked up by hash
+	_, err = GetCollection("invite_tokens").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Println("Error creating invite_tokens index:", err)
+	}
+
+	// Embedded analytics look forms up by their analytics token
+	_, err = GetCollection("forms").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "analytics_token_hash", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"analytics_token_hash": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("Error creating forms analytics token index:", err)
+	}
+
+	// Reviewers list responses by completion
+	_, err = GetCollection("responses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "completion_percent", Value: -1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		log.Println("Error creating responses completion index:", err)
+	}
+
+	// Duplicate detection matches responses by answer fingerprint
+	_, err = GetCollection("responses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "form_id", Value: 1}, {Key: "fingerprint", Value: 1}, {Key: "created_at", Value: -1}},
+		Options: options.Index().
+			SetPartialFilterExpression(bson.M{"fingerprint": bson.M{"$type": "string"}}),
+	})
+	if err != nil {
+		log.Println("Error creating responses fingerprint index:", err)
+	}
+
+	// Upload cleanup looks responses up by the files they reference
+	_, err = GetCollection("responses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "files", Value: 1}},
+	})
+	if err != nil {
+		log.Println("Error creating responses files index:", err)
+	}
+
+	ensureLocationIndexes(ctx)
+	ensureResponseTTL(ctx)
+}
+
+// geoIndex names the 2dsphere index over the points of location answers
+const geoIndex = "geo_point"
+
+// ensureLocationIndexes keeps one 2dsphere index over the geo points of all
+// responses. Points live under geo rather than responses.<field id>, since
+// field IDs are only unique within a form and other forms' answers under the
+// same ID need not be points. Per-field indexes from earlier versions are
+// dropped, and responses stored before geo existed get their points copied.
+func ensureLocationIndexes(ctx context.Context) {
+	responses := GetCollection("responses")
+
+	cursor, err := responses.Indexes().List(ctx)
+	if err != nil {
+		log.Println("Error listing responses indexes:", err)
+		return
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		log.Println("Error listing responses indexes:", err)
+		return
+	}
+	for _, index := range indexes {
+		if name, _ := index["name"].(string); strings.HasPrefix(name, "location_") {
+			if _, err := responses.Indexes().DropOne(ctx, name); err != nil {
+				log.Println("Error dropping responses location index:", err)
+			}
+		}
+	}
+
+	_, err = responses.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "geo.point", Value: "2dsphere"}},
+		Options: options.Index().SetName(geoIndex),
+	})
+	if err != nil {
+		log.Println("Error creating responses geo index:", err)
+	}
+
+	backfillGeoPoints(ctx)
+}
+
+// backfillGeoPoints copies the points of unencrypted location answers that
+// have none under geo yet
+func backfillGeoPoints(ctx context.Context) {
+	cursor, err := GetCollection("forms").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"fields.type": "location"}},
+		{"$unwind": "$fields"},
+		{"$match": bson.M{"fields.type": "location", "fields.encrypted": bson.M{"$ne": true}}},
+		{"$project": bson.M{"field_id": "$fields.id"}},
+	})
+	if err != nil {
+		log.Println("Error listing location fields:", err)
+		return
+	}
+	var fields []struct {
+		FormID  primitive.ObjectID `bson:"_id"`
+		FieldID string             `bson:"field_id"`
+	}
+	if err := cursor.All(ctx, &fields); err != nil {
+		log.Println("Error listing location fields:", err)
+		return
+	}
+
+	for _, field := range fields {
+		answer := "$responses." + field.FieldID
+		_, err := GetCollection("responses").UpdateMany(ctx, bson.M{
+			"form_id":                              field.FormID,
+			"responses." + field.FieldID + ".type": "Point",
+			"geo.field_id":                         bson.M{"$ne": field.FieldID},
+		}, bson.A{bson.M{"$set": bson.M{"geo": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$geo", bson.A{}}},
+			bson.A{bson.M{
+				"field_id": field.FieldID,
+				"point":    bson.M{"type": "Point", "coordinates": answer + ".coordinates"},
+			}},
+		}}}}})
+		if err != nil {
+			log.Println("Error backfilling response geo points:", err)
+		}
+	}
+}
+
+// responseTTLIndex names the TTL index managed by ensureResponseTTL
+const responseTTLIndex = "created_at_ttl"
+
+// maxRetentionDays is the longest RESPONSE_RETENTION_DAYS whose period in
+// seconds fits a TTL index (about 68 years)
+const maxRetentionDays = math.MaxInt32 / (24 * 60 * 60)
+
+// ensureResponseTTL keeps the responses TTL index in line with
+// RESPONSE_RETENTION_DAYS. A changed value is applied in place with collMod;
+// an unset or zero value drops the index so responses are kept forever.
+//
+// The TTL index applies to the whole collection: every form's responses
+// expire after the same period, and Mongo removes them in the background
+// without audit entries or webhooks. Deployments that need different
+// retention per form should leave this unset and delete responses with
+// their own job instead.
+func ensureResponseTTL(ctx context.Context) {
+	days, _ := strconv.Atoi(os.Getenv("RESPONSE_RETENTION_DAYS"))
+	// Mongo stores expireAfterSeconds as a 32-bit integer
+	if days > maxRetentionDays {
+		log.Printf("Error: RESPONSE_RETENTION_DAYS=%d exceeds the maximum of %d days; responses TTL index left unchanged", days, maxRetentionDays)
+		return
+	}
+	expireAfter := int64(days) * 24 * 60 * 60
+
+	collection := GetCollection("responses")
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		log.Println("Error listing responses indexes:", err)
+		return
+	}
+	var indexes []bson.M
+	if err := cursor.All(ctx, &indexes); err != nil {
+		log.Println("Error listing responses indexes:", err)
+		return
+	}
+
+	var current bson.M
+	for _, index := range indexes {
+		if index["name"] == responseTTLIndex {
+			current = index
+			break
+		}
+	}
+
+	switch {
+	case current == nil && expireAfter <= 0:
+		return
+	case current == nil:
+		_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().
+				SetName(responseTTLIndex).
+				SetExpireAfterSeconds(int32(expireAfter)),
+		})
+		if err != nil {
+			log.Println("Error creating responses TTL index:", err)
+			return
+		}
+		log.Printf("Responses expire after %d days", days)
+	case expireAfter <= 0:
+		if _, err := collection.Indexes().DropOne(ctx, responseTTLIndex); err != nil {
+			log.Println("Error dropping responses TTL index:", err)
+			return
+		}
+		log.Println("Response expiry disabled")
+	case indexSeconds(current["expireAfterSeconds"]) != expireAfter:
+		err = DB.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: "responses"},
+			{Key: "index", Value: bson.M{"name": responseTTLIndex, "expireAfterSeconds": expireAfter}},
+		}).Err()
+		if err != nil {
+			log.Println("Error updating responses TTL index:", err)
+			return
+		}
+		log.Printf("Responses now expire after %d days", days)
+	}
+}
+
+// indexSeconds reads expireAfterSeconds, which the server may report as any
+// numeric BSON type
+func indexSeconds(value interface{}) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return -1
+}
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"form-builder-api/auth"
+	"form-builder-api/controllers"
 	"form-builder-api/database"
+	"form-builder-api/proxy"
 	"form-builder-api/routes"
 	"form-builder-api/websocket"
 
@@ -20,11 +27,17 @@ func main() {
 		log.Println("No .env file found")
 	}
 
+	// Refuse to start with a JWT_SECRET anyone could sign tokens with
+	if err := auth.CheckSecret(); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize database
 	database.ConnectDB()
+	database.EnsureIndexes()
 
 	// Create Fiber app
-	app := fiber.New(fiber.Config{
+	config := fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -34,9 +47,18 @@ func main() {
 				"error": err.Error(),
 			})
 		},
-	})
+		BodyLimit: bodyLimit(),
+	}
+	forwarded, err := trustedProxies()
+	if err != nil {
+		log.Fatal(err)
+	}
+	app := fiber.New(config)
 
 	// Middleware
+	if forwarded != nil {
+		app.Use(forwarded)
+	}
 	app.Use(logger.New())
 	origins := os.Getenv("ALLOWED_ORIGINS")
 	if origins == "" {
@@ -56,6 +78,29 @@ func main() {
 	// Setup routes
 	routes.SetupRoutes(app, hub)
 
+	// Schedule orphaned response cleanup (e.g. ORPHAN_CLEANUP_INTERVAL=24h)
+	if interval, err := time.ParseDuration(os.Getenv("ORPHAN_CLEANUP_INTERVAL")); err == nil && interval > 0 {
+		controllers.NewMaintenanceController().StartOrphanCleanup(interval)
+	}
+
+	// Delete uploads no response references any more (UPLOAD_CLEANUP_INTERVAL,
+	// default 1h; 0 disables)
+	uploadCleanup := time.Hour
+	if value := os.Getenv("UPLOAD_CLEANUP_INTERVAL"); value != "" {
+		if interval, err := time.ParseDuration(value); err == nil {
+			uploadCleanup = interval
+		}
+	}
+	if uploadCleanup > 0 {
+		controllers.NewMaintenanceController().StartUploadCleanup(uploadCleanup)
+	}
+
+	// Pick up ordered webhook deliveries left queued by the previous run
+	go controllers.ResumeOrderedWebhooks()
+
+	// Process queued export jobs (EXPORT_WORKERS caps how many run at once)
+	controllers.NewExportController().StartExportWorkers(controllers.ExportWorkers())
+
 	// Get port from environment or default to 8080
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -65,3 +110,41 @@ func main() {
 	log.Printf("Server starting on port %s", port)
 	log.Fatal(app.Listen(":" + port))
 }
+
+// bodyLimit is the largest request body accepted, in bytes: MAX_BODY_SIZE,
+// or Fiber's default of 4 MB. File fields can't take uploads larger than it.
+func bodyLimit() int {
+	limit, err := strconv.Atoi(os.Getenv("MAX_BODY_SIZE"))
+	if err != nil || limit <= 0 {
+		return fiber.DefaultBodyLimit
+	}
+	return limit
+}
+
+// trustedProxies returns middleware that makes c.IP() return the client
+// address a proxy forwards in PROXY_HEADER (X-Forwarded-For by default), but
+// only for requests coming from TRUSTED_PROXIES, a comma-separated list of
+// IPs and CIDR ranges. Anyone else could put any address in the header, which
+// would defeat per-IP limits and duplicate checks. See proxy.ClientIP for how
+// the header is read. Returns nil when no proxies are trusted.
+func trustedProxies() (fiber.Handler, error) {
+	header := strings.TrimSpace(os.Getenv("PROXY_HEADER"))
+	list := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if list == "" {
+		if header != "" {
+			return nil, fmt.Errorf("PROXY_HEADER is set but TRUSTED_PROXIES is empty; any client could spoof its IP address")
+		}
+		return nil, nil
+	}
+
+	trusted, err := proxy.ParseTrusted(list)
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	if header == "" {
+		header = fiber.HeaderXForwardedFor
+	}
+
+	log.Printf("Trusting %s from %d proxy address(es)", header, len(trusted))
+	return proxy.New(trusted, header), nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import "strings"
+
+// ConditionOperator is the comparison a Condition applies to an answer
+type ConditionOperator string
+
+const (
+	ConditionEquals      ConditionOperator = "equals"
+	ConditionNotEquals   ConditionOperator = "not_equals"
+	ConditionContains    ConditionOperator = "contains"
+	ConditionIn          ConditionOperator = "in"
+	ConditionGreaterThan ConditionOperator = "greater_than"
+	ConditionLessThan    ConditionOperator = "less_than"
+	ConditionIsEmpty     ConditionOperator = "is_empty"
+	ConditionIsNotEmpty  ConditionOperator = "is_not_empty"
+	// Count operators compare how many options of a checkbox field are selected
+	ConditionSelectedAtLeast ConditionOperator = "selected_at_least"
+	ConditionSelectedAtMost  ConditionOperator = "selected_at_most"
+)
+
+// KnownConditionOperator reports whether op is a supported operator
+func KnownConditionOperator(op ConditionOperator) bool {
+	switch op {
+	case ConditionEquals, ConditionNotEquals, ConditionContains, ConditionIn,
+		ConditionGreaterThan, ConditionLessThan, ConditionIsEmpty, ConditionIsNotEmpty,
+		ConditionSelectedAtLeast, ConditionSelectedAtMost:
+		return true
+	}
+	return false
+}
+
+// CountsSelections reports whether op compares a selection count
+func CountsSelections(op ConditionOperator) bool {
+	return op == ConditionSelectedAtLeast || op == ConditionSelectedAtMost
+}
+
+// Condition tests the answer to a single field. It is the building block for
+// answer-dependent behavior such as notification routing.
+type Condition struct {
+	FieldID  string            `json:"field_id" bson:"field_id"`
+	Operator ConditionOperator `json:"operator" bson:"operator"`
+	// Value is compared against the answer; "in" expects a list
+	Value interface{} `json:"value,omitempty" bson:"value,omitempty"`
+}
+
+// Evaluate reports whether the condition holds for a set of answers. Answers
+// that are lists (checkboxes) match equals/contains when any item does.
+func (c Condition) Evaluate(responses map[string]interface{}) bool {
+	answer := responses[c.FieldID]
+
+	switch c.Operator {
+	case ConditionIsEmpty:
+		return isEmptyValue(answer)
+	case ConditionIsNotEmpty:
+		return !isEmptyValue(answer)
+	case ConditionEquals:
+		return anyAnswer(answer, func(item string) bool { return valuesEqual(item, c.Value) })
+	case ConditionNotEquals:
+		return !anyAnswer(answer, func(item string) bool { return valuesEqual(item, c.Value) })
+	case ConditionContains:
+		needle, ok := AsString(c.Value)
+		if !ok {
+			return false
+		}
+		needle = strings.ToLower(needle)
+		return anyAnswer(answer, func(item string) bool { return strings.Contains(strings.ToLower(item), needle) })
+	case ConditionIn:
+		options, ok := AsStringSlice(c.Value)
+		if !ok {
+			return false
+		}
+		return anyAnswer(answer, func(item string) bool {
+			for _, option := range options {
+				if valuesEqual(item, option) {
+					return true
+				}
+			}
+			return false
+		})
+	case ConditionGreaterThan, ConditionLessThan:
+		got, ok := AsFloat(answer)
+		if !ok {
+			return false
+		}
+		want, ok := AsFloat(c.Value)
+		if !ok {
+			return false
+		}
+		if c.Operator == ConditionGreaterThan {
+			return got > want
+		}
+		return got < want
+	case ConditionSelectedAtLeast, ConditionSelectedAtMost:
+		want, ok := AsFloat(c.Value)
+		if !ok {
+			return false
+		}
+		got := float64(selectionCount(answer))
+		if c.Operator == ConditionSelectedAtLeast {
+			return got >= want
+		}
+		return got <= want
+	}
+	return false
+}
+
+// MatchAll reports whether every condition holds; an empty list always matches
+func MatchAll(conditions []Condition, responses map[string]interface{}) bool {
+	for _, condition := range conditions {
+		if !condition.Evaluate(responses) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyAnswer reports whether match holds for the answer or any of its items
+func anyAnswer(answer interface{}, match func(string) bool) bool {
+	items, ok := AsStringSlice(answer)
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if match(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual compares an answer with a condition value, numerically when
+// both are numbers so 5 and "5.0" are equal
+func valuesEqual(answer string, value interface{}) bool {
+	if got, ok := AsFloat(answer); ok {
+		if want, ok := AsFloat(value); ok {
+			return got == want
+		}
+	}
+	want, ok := AsString(value)
+	return ok && answer == want
+}
+
+// selectionCount is the number of non-empty items selected in an answer; a
+// single value counts as one selection
+func selectionCount(answer interface{}) int {
+	items, ok := AsStringSlice(answer)
+	if !ok {
+		return 0
+	}
+	count := 0
+	for _, item := range items {
+		if strings.TrimSpace(item) != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// isEmptyValue treats missing answers, empty strings and empty lists as empty
+func isEmptyValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if str, ok := value.(string); ok {
+		return strings.TrimSpace(str) == ""
+	}
+	if items, ok := AsStringSlice(value); ok {
+		return len(items) == 0
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAnswerFingerprintFiles(t *testing.T) {
+	form := Form{Fields: []FormField{
+		{ID: "name", Type: FieldTypeText},
+		{ID: "cv", Type: FieldTypeFile},
+	}}
+	fingerprint := func(file interface{}) string {
+		return form.AnswerFingerprint(map[string]interface{}{"name": "Ada", "cv": file})
+	}
+
+	tests := []struct {
+		name  string
+		a, b  interface{}
+		equal bool
+	}{
+		{"different contents", Upload{SHA256: "aa"}, Upload{SHA256: "bb"}, false},
+		{"same contents, other name", Upload{SHA256: "aa"}, FileAnswer{Key: "k", Name: "other.pdf", SHA256: "aa"}, true},
+		{"stored without digest", FileAnswer{Key: "k1", Name: "cv.pdf", Size: 10}, FileAnswer{Key: "k2", Name: "cv.pdf", Size: 11}, false},
+		{"upload versus none", Upload{SHA256: "aa"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(tt.a) == fingerprint(tt.b); got != tt.equal {
+				t.Errorf("fingerprints equal = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestAnswerFingerprintAnswerTypes(t *testing.T) {
+	form := Form{Fields: []FormField{{ID: "answer", Type: FieldTypeText}}}
+	fingerprint := func(answer interface{}) string {
+		return form.AnswerFingerprint(map[string]interface{}{"answer": answer})
+	}
+	when := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		a, b  interface{}
+		equal bool
+	}{
+		{"different documents", map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}, false},
+		{"documents with other keys", map[string]interface{}{"a": 1}, map[string]interface{}{"b": 1}, false},
+		{"same document, decoded", map[string]interface{}{"a": 1, "b": "x"}, primitive.D{{Key: "b", Value: "x"}, {Key: "a", Value: int32(1)}}, true},
+		{"document versus none", map[string]interface{}{"a": 1}, nil, false},
+		{"different times", when, when.Add(time.Hour), false},
+		{"same time, decoded", when, primitive.NewDateTimeFromTime(when), true},
+		{"different nested lists", primitive.A{primitive.A{"a", "b"}}, primitive.A{primitive.A{"b", "a"}}, false},
+		{"lists of documents in any order", primitive.A{primitive.M{"a": 1}, primitive.M{"b": 2}}, primitive.A{primitive.M{"b": 2}, primitive.M{"a": 1}}, true},
+		{"different lists of documents", primitive.A{primitive.M{"a": 1}}, primitive.A{primitive.M{"a": 2}}, false},
+		{"numbers by value", int32(5), "5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fingerprint(tt.a) == fingerprint(tt.b); got != tt.equal {
+				t.Errorf("fingerprints equal = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestAnswerFingerprintEncrypted(t *testing.T) {
+	form := Form{Fields: []FormField{
+		{ID: "name", Type: FieldTypeText},
+		{ID: "ssn", Type: FieldTypeText, Encrypted: true},
+	}}
+	withSSN := form.AnswerFingerprint(map[string]interface{}{"name": "Ada", "ssn": "123-45-6789"})
+	otherSSN := form.AnswerFingerprint(map[string]interface{}{"name": "Ada", "ssn": "987-65-4321"})
+	withoutSSN := form.AnswerFingerprint(map[string]interface{}{"name": "Ada"})
+
+	if withSSN != otherSSN || withSSN != withoutSSN {
+		t.Errorf("fingerprints differ by encrypted answer: %s, %s, %s", withSSN, otherSSN, withoutSSN)
+	}
+}
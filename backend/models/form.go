@@ -1,6 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,24 +16,76 @@ import (
 type FieldType string
 
 const (
-	FieldTypeText         FieldType = "text"
-	FieldTypeTextarea     FieldType = "textarea"
-	FieldTypeEmail        FieldType = "email"
-	FieldTypeNumber       FieldType = "number"
+	FieldTypeText           FieldType = "text"
+	FieldTypeTextarea       FieldType = "textarea"
+	FieldTypeEmail          FieldType = "email"
+	FieldTypeNumber         FieldType = "number"
 	FieldTypeMultipleChoice FieldType = "multiple_choice"
-	FieldTypeCheckbox     FieldType = "checkbox"
-	FieldTypeRating       FieldType = "rating"
-	FieldTypeDate         FieldType = "date"
+	FieldTypeCheckbox       FieldType = "checkbox"
+	FieldTypeRating         FieldType = "rating"
+	FieldTypeDate           FieldType = "date"
+	FieldTypeHidden         FieldType = "hidden" // value supplied from URL parameters, never rendered
+	FieldTypeConsent        FieldType = "consent"
+	FieldTypeLocation       FieldType = "location" // a point picked on a map, stored as a Location
+	FieldTypeFile           FieldType = "file"     // an uploaded file, stored as a FileAnswer
 )
 
 // ValidationRule represents validation rules for a field
 type ValidationRule struct {
-	Required bool   `json:"required" bson:"required"`
-	MinLength int   `json:"min_length,omitempty" bson:"min_length,omitempty"`
-	MaxLength int   `json:"max_length,omitempty" bson:"max_length,omitempty"`
-	Pattern   string `json:"pattern,omitempty" bson:"pattern,omitempty"`
+	Required  bool    `json:"required" bson:"required"`
+	MinLength int     `json:"min_length,omitempty" bson:"min_length,omitempty"`
+	MaxLength int     `json:"max_length,omitempty" bson:"max_length,omitempty"`
+	Pattern   string  `json:"pattern,omitempty" bson:"pattern,omitempty"`
 	Min       float64 `json:"min,omitempty" bson:"min,omitempty"`
 	Max       float64 `json:"max,omitempty" bson:"max,omitempty"`
+	// Email fields only: when AllowedEmailDomains is set the address must be
+	// in one of them; BlockDisposableEmails rejects known throwaway providers.
+	// Subdomains match their parent domain.
+	AllowedEmailDomains   []string `json:"allowed_email_domains,omitempty" bson:"allowed_email_domains,omitempty"`
+	BlockedEmailDomains   []string `json:"blocked_email_domains,omitempty" bson:"blocked_email_domains,omitempty"`
+	BlockDisposableEmails bool     `json:"block_disposable_emails,omitempty" bson:"block_disposable_emails,omitempty"`
+	// File fields only: AllowedMimeTypes lists the accepted types, either
+	// exact ("application/pdf") or by prefix ("image/*"), and accepts any
+	// type when empty. MaxFileSize is in bytes; 0 uses DefaultMaxFileSize.
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty" bson:"allowed_mime_types,omitempty"`
+	MaxFileSize      int64    `json:"max_file_size,omitempty" bson:"max_file_size,omitempty"`
+}
+
+// Default maximum answer lengths (in characters) for fields without an
+// explicit MaxLength. The text and textarea defaults are only suggested to
+// clients through display hints; the hidden default is enforced.
+const (
+	DefaultTextMaxLength     = 1000
+	DefaultTextareaMaxLength = 10000
+	DefaultHiddenMaxLength   = 500
+)
+
+// DefaultMaxFileSize is the largest upload (in bytes) a file field accepts
+// without an explicit MaxFileSize
+const DefaultMaxFileSize = 4 << 20
+
+// EffectiveMaxFileSize returns the largest upload the field accepts, in bytes
+func (f FormField) EffectiveMaxFileSize() int64 {
+	if f.Validation.MaxFileSize > 0 {
+		return f.Validation.MaxFileSize
+	}
+	return DefaultMaxFileSize
+}
+
+// AllowsMimeType reports whether a file field accepts uploads of the given
+// media type
+func (f FormField) AllowsMimeType(mimeType string) bool {
+	if len(f.Validation.AllowedMimeTypes) == 0 {
+		return true
+	}
+	mimeType = strings.ToLower(mimeType)
+	for _, allowed := range f.Validation.AllowedMimeTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == mimeType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*"))) {
+			return true
+		}
+	}
+	return false
 }
 
 // FieldOption represents an option for multiple choice or checkbox fields
@@ -35,6 +93,35 @@ type FieldOption struct {
 	ID    string `json:"id" bson:"id"`
 	Label string `json:"label" bson:"label"`
 	Value string `json:"value" bson:"value"`
+	// Score weights the option for scored assessments; a field either scores
+	// all of its options or none
+	Score *float64 `json:"score,omitempty" bson:"score,omitempty"`
+}
+
+// Scored reports whether the field's options carry scores
+func (f FormField) Scored() bool {
+	for _, option := range f.Options {
+		if option.Score != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// AnswerScore sums the scores of the options selected by an answer, which
+// is a single value or a list of values
+func (f FormField) AnswerScore(answer interface{}) float64 {
+	selected, _ := AsStringSlice(answer)
+
+	var score float64
+	for _, value := range selected {
+		for _, option := range f.Options {
+			if option.Value == value && option.Score != nil {
+				score += *option.Score
+			}
+		}
+	}
+	return score
 }
 
 // FormField represents a single field in a form
@@ -48,6 +135,166 @@ type FormField struct {
 	Options     []FieldOption  `json:"options,omitempty" bson:"options,omitempty"`
 	Validation  ValidationRule `json:"validation" bson:"validation"`
 	Order       int            `json:"order" bson:"order"`
+	ConsentText string         `json:"consent_text,omitempty" bson:"consent_text,omitempty"`
+	ConsentURL  string         `json:"consent_url,omitempty" bson:"consent_url,omitempty"`
+	// Encrypted answers are stored as AES-GCM ciphertext and excluded from analytics
+	Encrypted bool `json:"encrypted,omitempty" bson:"encrypted,omitempty"`
+	// DefaultValue pre-populates the field. ReadOnly fields are shown but can't
+	// be changed, Disabled fields aren't collected; either way a submitted value
+	// must match the default.
+	DefaultValue interface{} `json:"default_value,omitempty" bson:"default_value,omitempty"`
+	ReadOnly     bool        `json:"read_only,omitempty" bson:"read_only,omitempty"`
+	Disabled     bool        `json:"disabled,omitempty" bson:"disabled,omitempty"`
+	// SectionID places the field in one of the form's sections; fields
+	// without one belong to the default section
+	SectionID string `json:"section_id,omitempty" bson:"section_id,omitempty"`
+	// RequiredIf makes the field required when all of its conditions hold,
+	// e.g. once enough options of a checkbox field are selected. Completion
+	// metrics only count fields that are always required.
+	RequiredIf []Condition `json:"required_if,omitempty" bson:"required_if,omitempty"`
+	// RequiredUnless makes the field required except when all of its
+	// conditions hold, e.g. an explanation unless the rating is high. It
+	// exempts the field from Required and RequiredIf alike; on its own it
+	// requires the field whenever its conditions don't hold.
+	RequiredUnless []Condition `json:"required_unless,omitempty" bson:"required_unless,omitempty"`
+	// Transforms normalize text answers, in the listed order, before they are
+	// validated and stored
+	Transforms []AnswerTransform `json:"transforms,omitempty" bson:"transforms,omitempty"`
+}
+
+// IsRequired reports whether the field must be answered in a submission with
+// the given answers
+func (f FormField) IsRequired(responses map[string]interface{}) bool {
+	if len(f.RequiredUnless) > 0 && MatchAll(f.RequiredUnless, responses) {
+		return false
+	}
+	if len(f.RequiredIf) > 0 {
+		return f.Required || MatchAll(f.RequiredIf, responses)
+	}
+	return f.Required || len(f.RequiredUnless) > 0
+}
+
+// AlwaysRequired reports whether the field is required in every submission,
+// whatever the other answers
+func (f FormField) AlwaysRequired() bool {
+	return f.Required && len(f.RequiredUnless) == 0
+}
+
+// FormSection groups fields under a heading. Sections are shown in the order
+// they are listed on the form.
+type FormSection struct {
+	ID    string `json:"id" bson:"id"`
+	Title string `json:"title" bson:"title"`
+}
+
+// AtLeastOneGroup is a set of fields of which at least one must be answered
+type AtLeastOneGroup struct {
+	Name     string   `json:"name,omitempty" bson:"name,omitempty"`
+	FieldIDs []string `json:"field_ids" bson:"field_ids"`
+}
+
+// NotificationTargetType is the channel a notification is sent through
+type NotificationTargetType string
+
+const (
+	NotificationEmail   NotificationTargetType = "email"
+	NotificationWebhook NotificationTargetType = "webhook"
+	NotificationSlack   NotificationTargetType = "slack"
+)
+
+// NotificationTarget is a recipient of submission notifications. Address is
+// an email address, a webhook URL or a Slack incoming-webhook URL.
+type NotificationTarget struct {
+	Type    NotificationTargetType `json:"type" bson:"type"`
+	Address string                 `json:"address" bson:"address"`
+}
+
+// NotificationRule notifies its targets about submissions matching all of its conditions
+type NotificationRule struct {
+	Name       string               `json:"name,omitempty" bson:"name,omitempty"`
+	Conditions []Condition          `json:"conditions" bson:"conditions"`
+	Targets    []NotificationTarget `json:"targets" bson:"targets"`
+}
+
+// WebhookTransform reshapes the webhook payload before delivery. Mapping maps
+// output keys to dotted paths into the default payload (for example
+// "response.responses.<fieldId>" or "response.receipt_code"); dotted output
+// keys such as "contact.email" build nested objects. Static values are added
+// as-is, and Flatten joins list answers into comma-separated strings.
+type WebhookTransform struct {
+	Mapping map[string]string      `json:"mapping" bson:"mapping"`
+	Static  map[string]interface{} `json:"static,omitempty" bson:"static,omitempty"`
+	Flatten bool                   `json:"flatten,omitempty" bson:"flatten,omitempty"`
+}
+
+// MetadataType is the expected JSON type of a submission metadata value
+type MetadataType string
+
+const (
+	MetadataString  MetadataType = "string"
+	MetadataNumber  MetadataType = "number"
+	MetadataBoolean MetadataType = "boolean"
+)
+
+// MetadataKey describes one key allowed in submission metadata
+type MetadataKey struct {
+	Key      string       `json:"key" bson:"key"`
+	Type     MetadataType `json:"type" bson:"type"`
+	Required bool         `json:"required,omitempty" bson:"required,omitempty"`
+}
+
+// EffectiveMaxLength returns the maximum answer length enforced for the
+// field: its MaxLength, or DefaultHiddenMaxLength for hidden fields without
+// one; 0 means no limit applies
+func (f FormField) EffectiveMaxLength() int {
+	if f.Validation.MaxLength > 0 {
+		return f.Validation.MaxLength
+	}
+	if f.Type == FieldTypeHidden {
+		return DefaultHiddenMaxLength
+	}
+	return 0
+}
+
+// SuggestedMaxLength returns the maximum answer length display hints show
+// for the field. Text fields without a MaxLength get a per-type default for
+// character counters to count against; it isn't enforced, so answers to
+// existing forms that run longer are still accepted.
+func (f FormField) SuggestedMaxLength() int {
+	if maxLength := f.EffectiveMaxLength(); maxLength > 0 {
+		return maxLength
+	}
+	switch f.Type {
+	case FieldTypeText:
+		return DefaultTextMaxLength
+	case FieldTypeTextarea:
+		return DefaultTextareaMaxLength
+	}
+	return 0
+}
+
+// FieldDisplayHint carries server-derived rendering hints so clients don't
+// have to reimplement server rules
+type FieldDisplayHint struct {
+	Hidden      bool `json:"hidden,omitempty"`
+	MinLength   int  `json:"min_length,omitempty"`
+	MaxLength   int  `json:"max_length,omitempty"`
+	ShowCounter bool `json:"show_counter,omitempty"`
+}
+
+// DisplayHints computes the display hints for every field, keyed by field ID
+func (f Form) DisplayHints() map[string]FieldDisplayHint {
+	hints := make(map[string]FieldDisplayHint, len(f.Fields))
+	for _, field := range f.Fields {
+		hint := FieldDisplayHint{Hidden: field.Type == FieldTypeHidden}
+		if field.Type == FieldTypeText || field.Type == FieldTypeTextarea {
+			hint.MinLength = field.Validation.MinLength
+			hint.MaxLength = field.SuggestedMaxLength()
+			hint.ShowCounter = true
+		}
+		hints[field.ID] = hint
+	}
+	return hints
 }
 
 // Form represents a form document
@@ -56,39 +303,619 @@ type Form struct {
 	Title       string             `json:"title" bson:"title"`
 	Description string             `json:"description,omitempty" bson:"description,omitempty"`
 	Fields      []FormField        `json:"fields" bson:"fields"`
+	Sections    []FormSection      `json:"sections,omitempty" bson:"sections,omitempty"`
 	IsPublished bool               `json:"is_published" bson:"is_published"`
 	ShareToken  string             `json:"share_token" bson:"share_token"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+	// AcceptingResponses pauses submissions while the form stays published
+	// and viewable when false; forms stored before it existed have it unset,
+	// see IsAcceptingResponses
+	AcceptingResponses *bool `json:"accepting_responses,omitempty" bson:"accepting_responses,omitempty"`
+	// OwnerSlug and Slug give the form a readable URL (/u/:ownerSlug/forms/:slug);
+	// slugs are unique per owner
+	OwnerSlug string `json:"owner_slug,omitempty" bson:"owner_slug,omitempty"`
+	Slug      string `json:"slug,omitempty" bson:"slug,omitempty"`
+	// RequireAtLeastOne lists groups of fields where at least one field of
+	// each group must be answered, even if every field is individually optional
+	RequireAtLeastOne []AtLeastOneGroup `json:"require_at_least_one,omitempty" bson:"require_at_least_one,omitempty"`
+	// EditWindowMinutes lets respondents edit their response for this long
+	// after submitting; 0 disables editing
+	EditWindowMinutes int `json:"edit_window_minutes,omitempty" bson:"edit_window_minutes,omitempty"`
+	// DuplicateWindowMinutes rejects a submission whose answers match one sent
+	// from the same IP address within this many minutes; 0 accepts duplicates
+	DuplicateWindowMinutes int `json:"duplicate_window_minutes,omitempty" bson:"duplicate_window_minutes,omitempty"`
+	// DuplicateMatchUserAgent also requires the User-Agent to match before a
+	// submission counts as a duplicate, so respondents sharing an IP address
+	// (an office or campus network) don't block each other
+	DuplicateMatchUserAgent bool `json:"duplicate_match_user_agent,omitempty" bson:"duplicate_match_user_agent,omitempty"`
+	// IPStorage sets how much of a respondent's IP address is stored with
+	// their response; unset uses the server default (IP_STORAGE_MODE)
+	IPStorage IPStorageMode `json:"ip_storage,omitempty" bson:"ip_storage,omitempty"`
+	// WebhookURL receives a signed POST for every submission; the secret is
+	// write-only and never returned
+	WebhookURL       string            `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+	WebhookSecret    string            `json:"-" bson:"webhook_secret,omitempty"`
+	WebhookTransform *WebhookTransform `json:"webhook_transform,omitempty" bson:"webhook_transform,omitempty"`
+	// WebhookOrdered delivers the form's webhook events one at a time in the
+	// order they happened, each waiting until the previous one succeeded,
+	// from a queue that survives restarts
+	WebhookOrdered bool `json:"webhook_ordered,omitempty" bson:"webhook_ordered,omitempty"`
+	// NotificationRules route submissions to the targets of every matching
+	// rule; DefaultNotificationTargets are notified when no rule matches
+	NotificationRules          []NotificationRule   `json:"notification_rules,omitempty" bson:"notification_rules,omitempty"`
+	DefaultNotificationTargets []NotificationTarget `json:"default_notification_targets,omitempty" bson:"default_notification_targets,omitempty"`
+	// MilestoneRules alert once when analytics cross a threshold;
+	// FiredMilestones lists the ones that already fired
+	MilestoneRules  []MilestoneRule  `json:"milestone_rules,omitempty" bson:"milestone_rules,omitempty"`
+	FiredMilestones []FiredMilestone `json:"fired_milestones,omitempty" bson:"fired_milestones,omitempty"`
+	// MetadataSchema, when set, restricts submission metadata to these keys
+	// and types; without it any metadata is accepted
+	MetadataSchema []MetadataKey `json:"metadata_schema,omitempty" bson:"metadata_schema,omitempty"`
+	// RequireAuth only accepts submissions from signed-in respondents; the
+	// form can still be viewed through its share link
+	RequireAuth bool `json:"require_auth,omitempty" bson:"require_auth,omitempty"`
+	// RequireInvite only accepts submissions carrying an unused invite token
+	RequireInvite bool `json:"require_invite,omitempty" bson:"require_invite,omitempty"`
+	// RequireApproval holds submissions as pending until a moderator approves
+	// or rejects them; only approved responses count in analytics
+	RequireApproval bool `json:"require_approval,omitempty" bson:"require_approval,omitempty"`
+	// AnalyticsIncludeIncomplete decides whether responses missing required
+	// answers count in analytics totals, trends and distributions; nil uses
+	// the server default. The completion rate always counts every response.
+	AnalyticsIncludeIncomplete *bool `json:"analytics_include_incomplete,omitempty" bson:"analytics_include_incomplete,omitempty"`
+	// RedirectURL and ThankYouMessage decide what respondents see after
+	// submitting; see Confirmation for which one wins
+	RedirectURL     string `json:"redirect_url,omitempty" bson:"redirect_url,omitempty"`
+	ThankYouMessage string `json:"thank_you_message,omitempty" bson:"thank_you_message,omitempty"`
+	// IntroContent is shown before the first field and OutroContent after
+	// submitting; neither takes answers
+	IntroContent *ContentBlock `json:"intro_content,omitempty" bson:"intro_content,omitempty"`
+	OutroContent *ContentBlock `json:"outro_content,omitempty" bson:"outro_content,omitempty"`
+	// AnalyticsTokenHash authorizes read-only access to the form's analytics
+	// for embeds; the token itself is only shown when it is issued
+	AnalyticsTokenHash      string     `json:"-" bson:"analytics_token_hash,omitempty"`
+	AnalyticsTokenCreatedAt *time.Time `json:"analytics_token_created_at,omitempty" bson:"analytics_token_created_at,omitempty"`
+	CreatedAt               time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt               time.Time  `json:"updated_at" bson:"updated_at"`
+
+	// Hints is populated on fetch (see DisplayHints) and never stored
+	Hints map[string]FieldDisplayHint `json:"display_hints,omitempty" bson:"-"`
+}
+
+// IPStorageMode is how much of a respondent's IP address is stored
+type IPStorageMode string
+
+const (
+	IPStorageFull      IPStorageMode = "full"
+	IPStorageTruncated IPStorageMode = "truncated"
+	IPStorageNone      IPStorageMode = "none"
+)
+
+// IsAcceptingResponses reports whether submissions are currently accepted;
+// forms without the setting accept them
+func (f Form) IsAcceptingResponses() bool {
+	return f.AcceptingResponses == nil || *f.AcceptingResponses
+}
+
+// ResponseScore totals the option scores of the answers to scored fields, or
+// returns nil when the form has no scored fields
+func (f Form) ResponseScore(responses map[string]interface{}) *float64 {
+	var total float64
+	scored := false
+	for _, field := range f.Fields {
+		if !field.Scored() {
+			continue
+		}
+		scored = true
+		total += field.AnswerScore(responses[field.ID])
+	}
+	if !scored {
+		return nil
+	}
+	return &total
+}
+
+// AnswerFingerprint hashes a submission's answers so identical submissions
+// get the same fingerprint. Answers are normalized first: text is compared
+// case-insensitively with whitespace collapsed, numbers by value, list items
+// in any order, files by their contents and any other answer by its
+// canonical form, see canonicalAnswer. Hidden fields are left out since they
+// carry tracking parameters that differ between otherwise identical
+// submissions, as are empty answers and answers to fields not on the form.
+// Encrypted fields are left out too: the hash is unsalted, so a low-entropy
+// answer could be recovered from it by trying every value.
+func (f Form) AnswerFingerprint(responses map[string]interface{}) string {
+	fieldIDs := make([]string, 0, len(f.Fields))
+	for _, field := range f.Fields {
+		if field.Type != FieldTypeHidden && !field.Encrypted && !isEmptyValue(responses[field.ID]) {
+			fieldIDs = append(fieldIDs, field.ID)
+		}
+	}
+	sort.Strings(fieldIDs)
+
+	hash := sha256.New()
+	for _, fieldID := range fieldIDs {
+		items := answerItems(responses[fieldID])
+		normalized := make([]string, 0, len(items))
+		for _, item := range items {
+			normalized = append(normalized, strings.ToLower(strings.Join(strings.Fields(item), " ")))
+		}
+		sort.Strings(normalized)
+
+		hash.Write([]byte(fieldID))
+		hash.Write([]byte{0x1f})
+		hash.Write([]byte(strings.Join(normalized, "\x1f")))
+		hash.Write([]byte{0x1e})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// answerItems returns the items AnswerFingerprint hashes an answer by: the
+// identity of a file, the coordinates of a location, each item of a list, or
+// the answer itself
+func answerItems(value interface{}) []string {
+	if file, ok := fileIdentity(value); ok {
+		return []string{file}
+	}
+	if location, ok := AsLocation(value); ok {
+		return []string{strconv.FormatFloat(location.Lat(), 'f', -1, 64) + "," + strconv.FormatFloat(location.Lng(), 'f', -1, 64)}
+	}
+	if items, ok := AsStringSlice(value); ok {
+		return items
+	}
+
+	var list []interface{}
+	switch v := value.(type) {
+	case primitive.A:
+		list = v
+	case []interface{}:
+		list = v
+	default:
+		return []string{canonicalAnswer(value)}
+	}
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, canonicalAnswer(item))
+	}
+	return items
+}
+
+// canonicalAnswer renders any answer as a string that is equal for equal
+// answers: scalars as AsString formats them, times in UTC, documents with
+// their keys sorted and nested lists in order, each tagged with its kind
+func canonicalAnswer(value interface{}) string {
+	if str, ok := AsString(value); ok {
+		return str
+	}
+	if t, ok := value.(time.Time); ok {
+		return "time:" + t.UTC().Format(time.RFC3339Nano)
+	}
+	if t, ok := value.(primitive.DateTime); ok {
+		return "time:" + t.Time().UTC().Format(time.RFC3339Nano)
+	}
+
+	var doc map[string]interface{}
+	switch v := value.(type) {
+	case nil:
+		return "null:"
+	case map[string]interface{}:
+		doc = v
+	case primitive.M:
+		doc = v
+	case primitive.D:
+		doc = v.Map()
+	case primitive.A:
+		return canonicalList(v)
+	case []interface{}:
+		return canonicalList(v)
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return canonicalList(list)
+	default:
+		return fmt.Sprintf("%T:%v", value, value)
+	}
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, strconv.Quote(key)+":"+strconv.Quote(canonicalAnswer(doc[key])))
+	}
+	return "doc:{" + strings.Join(entries, ",") + "}"
+}
+
+// canonicalList renders a nested list for canonicalAnswer
+func canonicalList(list []interface{}) string {
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, strconv.Quote(canonicalAnswer(item)))
+	}
+	return "list:[" + strings.Join(items, ",") + "]"
+}
+
+// CompletionPercent is the percentage of required fields with a non-empty
+// answer (nil, "" and empty lists count as unanswered, see isUnanswered). A
+// form without required fields is always 100% complete. It must stay in line
+// with the analytics completion rate, which counts a response as complete
+// when every required field is answered.
+func (f Form) CompletionPercent(responses map[string]interface{}) float64 {
+	required, answered := 0, 0
+	for _, field := range f.Fields {
+		if !field.AlwaysRequired() {
+			continue
+		}
+		required++
+		if !isUnanswered(responses[field.ID]) {
+			answered++
+		}
+	}
+	if required == 0 {
+		return 100
+	}
+	return float64(answered) / float64(required) * 100
+}
+
+// isUnanswered reports whether a stored answer leaves its field unanswered:
+// nil, "" or a list with nothing selected. Unlike isEmptyValue, whitespace
+// still counts as an answer, as it does in the analytics queries.
+func isUnanswered(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case primitive.A:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
+
+// ConfirmationType tells the client how to confirm a submission
+type ConfirmationType string
+
+const (
+	ConfirmationRedirect ConfirmationType = "redirect"
+	ConfirmationMessage  ConfirmationType = "message"
+	ConfirmationDefault  ConfirmationType = "default"
+)
+
+// ContentBlock is a screen of explanatory content around a form's fields.
+// Body is markdown; HTML in it is stripped when the form is saved.
+type ContentBlock struct {
+	Title string `json:"title,omitempty" bson:"title,omitempty" validate:"max=200"`
+	Body  string `json:"body,omitempty" bson:"body,omitempty" validate:"max=10000"`
+}
+
+// IsEmpty reports whether the block has nothing to show
+func (b *ContentBlock) IsEmpty() bool {
+	return b == nil || (b.Title == "" && b.Body == "")
+}
+
+// DefaultThankYouMessage is shown when a form defines neither a redirect nor a message
+const DefaultThankYouMessage = "Thank you! Your response has been recorded."
+
+// SubmissionConfirmation is always part of a successful submission's payload.
+// Message is always set, so a client that can't redirect still has something
+// to show; RedirectURL is only set for redirect confirmations.
+type SubmissionConfirmation struct {
+	Type        ConfirmationType `json:"type"`
+	Message     string           `json:"message"`
+	RedirectURL string           `json:"redirect_url,omitempty"`
+}
+
+// Confirmation resolves what respondents see after submitting: a redirect
+// wins over a custom thank-you message, which wins over the default message.
+// Webhooks never affect it.
+func (f Form) Confirmation() SubmissionConfirmation {
+	message := f.ThankYouMessage
+	if message == "" {
+		message = DefaultThankYouMessage
+	}
+
+	switch {
+	case f.RedirectURL != "":
+		return SubmissionConfirmation{Type: ConfirmationRedirect, Message: message, RedirectURL: f.RedirectURL}
+	case f.ThankYouMessage != "":
+		return SubmissionConfirmation{Type: ConfirmationMessage, Message: message}
+	default:
+		return SubmissionConfirmation{Type: ConfirmationDefault, Message: message}
+	}
+}
+
+// NotificationTargetsFor returns the distinct targets to notify about a
+// submission: those of every matching rule, or the defaults if none match
+func (f Form) NotificationTargetsFor(responses map[string]interface{}) []NotificationTarget {
+	var targets []NotificationTarget
+	seen := make(map[NotificationTarget]bool)
+	for _, rule := range f.NotificationRules {
+		if !MatchAll(rule.Conditions, responses) {
+			continue
+		}
+		for _, target := range rule.Targets {
+			if !seen[target] {
+				seen[target] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return f.DefaultNotificationTargets
+	}
+	return targets
 }
 
 // FormResponse represents a response to a form
 type FormResponse struct {
-	ID        primitive.ObjectID            `json:"id" bson:"_id,omitempty"`
-	FormID    primitive.ObjectID            `json:"form_id" bson:"form_id"`
-	Responses map[string]interface{}        `json:"responses" bson:"responses"`
-	Metadata  map[string]interface{}        `json:"metadata,omitempty" bson:"metadata,omitempty"`
-	IPAddress string                        `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
-	UserAgent string                        `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
-	CreatedAt time.Time                     `json:"created_at" bson:"created_at"`
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	FormID    primitive.ObjectID     `json:"form_id" bson:"form_id"`
+	Responses map[string]interface{} `json:"responses" bson:"responses"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	IPAddress string                 `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	// Source is the channel the submission came through (e.g. "email",
+	// "in-app"); "direct" when none was given
+	Source    string               `json:"source,omitempty" bson:"source,omitempty"`
+	UserAgent string               `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	Consents  map[string]time.Time `json:"consents,omitempty" bson:"consents,omitempty"`
+	// ReceiptCode is a short confirmation code, unique within the form
+	ReceiptCode string `json:"receipt_code,omitempty" bson:"receipt_code,omitempty"`
+	// Fingerprint hashes the normalized answers so identical submissions can
+	// be matched; responses stored before it existed have none. It is never
+	// sent to clients.
+	Fingerprint string `json:"-" bson:"fingerprint,omitempty"`
+	// CompletionPercent is the share of required fields answered when the
+	// response was stored; responses stored before it existed have none
+	CompletionPercent *float64 `json:"completion_percent,omitempty" bson:"completion_percent,omitempty"`
+	// Score totals the option scores of scored fields; unset when the form has none
+	Score *float64 `json:"score,omitempty" bson:"score,omitempty"`
+	// Geo copies the points of unencrypted location answers to the one path
+	// the responses geo index covers, see Form.GeoPoints
+	Geo []GeoPoint `json:"-" bson:"geo,omitempty"`
+	// Files lists the upload keys the response references, so its files can
+	// be deleted with it. Always written, even empty, so responses stored
+	// before it existed can be told apart.
+	Files []string `json:"-" bson:"files"`
+	// SubmittedBy is the signed-in respondent, recorded for forms that require sign-in
+	SubmittedBy *AuthenticatedUser `json:"submitted_by,omitempty" bson:"submitted_by,omitempty"`
+	// EditTokenHash is the SHA-256 of the token handed to the respondent for editing
+	EditTokenHash string `json:"-" bson:"edit_token_hash,omitempty"`
+	// Status and Tags are set by reviewers during triage; a response without
+	// a status is new
+	Status ResponseStatus `json:"status,omitempty" bson:"status,omitempty"`
+	Tags   []string       `json:"tags,omitempty" bson:"tags,omitempty"`
+	// Approval is the moderation state of responses to forms that require
+	// approval; responses to other forms leave it unset and always count
+	Approval          ApprovalState `json:"approval,omitempty" bson:"approval,omitempty"`
+	ApprovalReason    string        `json:"approval_reason,omitempty" bson:"approval_reason,omitempty"`
+	ApprovalDecidedAt *time.Time    `json:"approval_decided_at,omitempty" bson:"approval_decided_at,omitempty"`
+	// Notes are internal reviewer notes. They are only served by the notes
+	// endpoint so they can't leak into anything shown to respondents.
+	Notes []ResponseNote `json:"-" bson:"notes,omitempty"`
+	// EditCount is how many times the respondent has edited the response;
+	// History keeps the answers as they were before recent edits
+	EditCount int               `json:"edit_count,omitempty" bson:"edit_count,omitempty"`
+	History   []ResponseVersion `json:"-" bson:"history,omitempty"`
+	// OriginalAnswers keeps, per field the respondent has changed, the answer
+	// as first submitted. Unlike History it isn't capped, and it holds at
+	// most one entry per field.
+	OriginalAnswers map[string]OriginalAnswer `json:"-" bson:"original_answers,omitempty"`
+	// Incomplete responses were saved to finish later (save-and-continue);
+	// they skip required-field checks and are left out of analytics. Only
+	// the hash of their resume token is stored.
+	Incomplete      bool       `json:"incomplete,omitempty" bson:"incomplete,omitempty"`
+	ResumeTokenHash string     `json:"-" bson:"resume_token_hash,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// InviteToken is a single-use submission token for an invite-only form.
+// Only the hash of the token is stored.
+type InviteToken struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FormID     primitive.ObjectID  `json:"form_id" bson:"form_id"`
+	TokenHash  string              `json:"-" bson:"token_hash"`
+	ExpiresAt  *time.Time          `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	UsedAt     *time.Time          `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	ResponseID *primitive.ObjectID `json:"response_id,omitempty" bson:"response_id,omitempty"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// AuthenticatedUser identifies the user a session token was issued to
+type AuthenticatedUser struct {
+	ID    string `json:"id" bson:"id"`
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+}
+
+// ResponseVersion is a snapshot of a response's answers before an edit.
+// Answers to encrypted fields stay encrypted in the snapshot.
+type ResponseVersion struct {
+	Version    int                    `json:"version" bson:"version"`
+	Responses  map[string]interface{} `json:"responses" bson:"responses"`
+	Consents   map[string]time.Time   `json:"consents,omitempty" bson:"consents,omitempty"`
+	ValidFrom  time.Time              `json:"valid_from" bson:"valid_from"`
+	ReplacedAt time.Time              `json:"replaced_at" bson:"replaced_at"`
+}
+
+// OriginalAnswer is a field's answer before its first edit. Answers to
+// encrypted fields stay encrypted.
+type OriginalAnswer struct {
+	Value          interface{} `json:"value" bson:"value"`
+	FirstChangedAt time.Time   `json:"first_changed_at" bson:"first_changed_at"`
+}
+
+// ApprovalState is the moderation state of a response
+type ApprovalState string
+
+const (
+	ApprovalPending  ApprovalState = "pending"
+	ApprovalApproved ApprovalState = "approved"
+	ApprovalRejected ApprovalState = "rejected"
+)
+
+// ApprovalRequest approves or rejects a pending response
+type ApprovalRequest struct {
+	Decision string `json:"decision" validate:"required,oneof=approve reject"`
+	Reason   string `json:"reason,omitempty" validate:"max=1000"`
+}
+
+// TestWebhookRequest asks for a signed sample delivery to URL
+type TestWebhookRequest struct {
+	URL    string `json:"url" validate:"required,max=2000"`
+	Secret string `json:"secret" validate:"required,max=200"`
+}
+
+// ResponseStatus is the triage state of a response
+type ResponseStatus string
+
+const (
+	ResponseStatusNew      ResponseStatus = "new"
+	ResponseStatusInReview ResponseStatus = "in_review"
+	ResponseStatusResolved ResponseStatus = "resolved"
+	ResponseStatusArchived ResponseStatus = "archived"
+)
+
+// ResponseNote is an internal note left on a response by a reviewer
+type ResponseNote struct {
+	ID        primitive.ObjectID `json:"id" bson:"id"`
+	Author    string             `json:"author" bson:"author"`
+	Text      string             `json:"text" bson:"text"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 }
 
 // FormAnalytics represents analytics data for a form
 type FormAnalytics struct {
-	FormID             primitive.ObjectID `json:"form_id" bson:"form_id"`
-	TotalResponses     int64              `json:"total_responses" bson:"total_responses"`
-	ResponsesLast24h   int64              `json:"responses_last_24h" bson:"responses_last_24h"`
-	ResponsesLastWeek  int64              `json:"responses_last_week" bson:"responses_last_week"`
-	ResponsesLastMonth int64              `json:"responses_last_month" bson:"responses_last_month"`
+	FormID             primitive.ObjectID     `json:"form_id" bson:"form_id"`
+	TotalResponses     int64                  `json:"total_responses" bson:"total_responses"`
+	ResponsesLast24h   int64                  `json:"responses_last_24h" bson:"responses_last_24h"`
+	ResponsesLastWeek  int64                  `json:"responses_last_week" bson:"responses_last_week"`
+	ResponsesLastMonth int64                  `json:"responses_last_month" bson:"responses_last_month"`
 	FieldAnalytics     map[string]interface{} `json:"field_analytics" bson:"field_analytics"`
-	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
+	UpdatedAt          time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+// SubmissionStats counts submission attempts by outcome, including the ones
+// that never became responses
+type SubmissionStats struct {
+	FormID           primitive.ObjectID `json:"form_id" bson:"form_id"`
+	Succeeded        int64              `json:"succeeded" bson:"succeeded"`
+	ValidationFailed int64              `json:"validation_failed" bson:"validation_failed"`
+	RateLimited      int64              `json:"rate_limited" bson:"rate_limited"`
+	ServerErrors     int64              `json:"server_errors" bson:"server_errors"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// FieldTiming holds the most recent time-spent samples reported for a field
+type FieldTiming struct {
+	FormID    primitive.ObjectID `json:"form_id" bson:"form_id"`
+	FieldID   string             `json:"field_id" bson:"field_id"`
+	Samples   []int64            `json:"samples" bson:"samples"`
+	Count     int64              `json:"count" bson:"count"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ExportJobStatus is the lifecycle state of an export job
+type ExportJobStatus string
+
+const (
+	ExportJobPending ExportJobStatus = "pending"
+	ExportJobRunning ExportJobStatus = "running"
+	ExportJobDone    ExportJobStatus = "done"
+	ExportJobFailed  ExportJobStatus = "failed"
+)
+
+// ExportJob tracks an export of a form's responses written to storage
+type ExportJob struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	FormID      primitive.ObjectID `json:"form_id" bson:"form_id"`
+	Format      string             `json:"format" bson:"format"`
+	Filters     map[string]string  `json:"filters,omitempty" bson:"filters,omitempty"`
+	From        string             `json:"from,omitempty" bson:"from,omitempty"`
+	To          string             `json:"to,omitempty" bson:"to,omitempty"`
+	Status      ExportJobStatus    `json:"status" bson:"status"`
+	StorageKey  string             `json:"-" bson:"storage_key,omitempty"`
+	Rows        int64              `json:"rows" bson:"rows"`
+	Size        int64              `json:"size" bson:"size"`
+	Error       string             `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	// HeartbeatAt is refreshed while a job runs, so jobs whose instance died
+	// can be told from jobs that are merely slow
+	HeartbeatAt *time.Time `json:"-" bson:"heartbeat_at,omitempty"`
+
+	// DownloadURL is presigned on fetch for finished jobs and never stored
+	DownloadURL string     `json:"download_url,omitempty" bson:"-"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" bson:"-"`
+}
+
+// CreateExportRequest represents the request to enqueue an export job
+type CreateExportRequest struct {
+	Format  string            `json:"format,omitempty" validate:"omitempty,oneof=csv ndjson"`
+	Filters map[string]string `json:"filters,omitempty"`
+	From    string            `json:"from,omitempty"`
+	To      string            `json:"to,omitempty"`
+}
+
+// BatchGetFormsRequest represents the request to fetch several forms by ID
+type BatchGetFormsRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1,max=100"`
+}
+
+// MergedExportRequest represents the request to export several forms' responses as one file
+type MergedExportRequest struct {
+	FormIDs []string `json:"form_ids" validate:"required,min=1,max=50"`
+	Format  string   `json:"format,omitempty" validate:"omitempty,oneof=csv ndjson"`
+	// Columns aligns equivalent fields across the forms; without it fields
+	// are matched by label
+	Columns []MergedExportColumn `json:"columns,omitempty" validate:"max=500,dive"`
+}
+
+// MergedExportColumn is one column of a merged export. Fields maps form IDs
+// to the field that fills the column for that form's responses.
+type MergedExportColumn struct {
+	Name   string            `json:"name" validate:"required,max=200"`
+	Fields map[string]string `json:"fields"`
 }
 
 // CreateFormRequest represents the request to create a new form
 type CreateFormRequest struct {
-	Title       string      `json:"title" validate:"required,min=1,max=200"`
-	Description string      `json:"description,omitempty" validate:"max=1000"`
-	Fields      []FormField `json:"fields" validate:"required,dive"`
+	Title       string        `json:"title" validate:"required,min=1,max=200"`
+	Description string        `json:"description,omitempty" validate:"max=1000"`
+	Fields      []FormField   `json:"fields" validate:"required,dive"`
+	Sections    []FormSection `json:"sections,omitempty"`
+	OwnerSlug   string        `json:"owner_slug,omitempty" validate:"max=60"`
+	Slug        string        `json:"slug,omitempty" validate:"max=80"`
+
+	RequireAtLeastOne       []AtLeastOneGroup `json:"require_at_least_one,omitempty"`
+	EditWindowMinutes       int               `json:"edit_window_minutes,omitempty" validate:"min=0,max=525600"`
+	DuplicateWindowMinutes  int               `json:"duplicate_window_minutes,omitempty" validate:"min=0,max=10080"`
+	IPStorage               IPStorageMode     `json:"ip_storage,omitempty" validate:"omitempty,oneof=full truncated none"`
+	DuplicateMatchUserAgent bool              `json:"duplicate_match_user_agent,omitempty"`
+	WebhookURL              string            `json:"webhook_url,omitempty" validate:"max=2000"`
+	WebhookSecret           string            `json:"webhook_secret,omitempty" validate:"max=200"`
+	WebhookTransform        *WebhookTransform `json:"webhook_transform,omitempty"`
+	WebhookOrdered          bool              `json:"webhook_ordered,omitempty"`
+
+	NotificationRules          []NotificationRule   `json:"notification_rules,omitempty"`
+	DefaultNotificationTargets []NotificationTarget `json:"default_notification_targets,omitempty"`
+	MilestoneRules             []MilestoneRule      `json:"milestone_rules,omitempty" validate:"dive"`
+	MetadataSchema             []MetadataKey        `json:"metadata_schema,omitempty"`
+	RequireAuth                bool                 `json:"require_auth,omitempty"`
+	RequireInvite              bool                 `json:"require_invite,omitempty"`
+	RequireApproval            bool                 `json:"require_approval,omitempty"`
+	AnalyticsIncludeIncomplete *bool                `json:"analytics_include_incomplete,omitempty"`
+	RedirectURL                string               `json:"redirect_url,omitempty" validate:"max=2000"`
+	ThankYouMessage            string               `json:"thank_you_message,omitempty" validate:"max=2000"`
+	IntroContent               *ContentBlock        `json:"intro_content,omitempty"`
+	OutroContent               *ContentBlock        `json:"outro_content,omitempty"`
 }
 
 // UpdateFormRequest represents the request to update a form
@@ -96,11 +923,165 @@ type UpdateFormRequest struct {
 	Title       string      `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
 	Description string      `json:"description,omitempty" validate:"max=1000"`
 	Fields      []FormField `json:"fields,omitempty" validate:"omitempty,dive"`
-	IsPublished *bool       `json:"is_published,omitempty"`
+	// Sections replaces the sections; an empty list removes them
+	Sections    []FormSection `json:"sections,omitempty"`
+	IsPublished *bool         `json:"is_published,omitempty"`
+	Slug        string        `json:"slug,omitempty" validate:"max=80"`
+
+	RequireAtLeastOne       []AtLeastOneGroup `json:"require_at_least_one,omitempty"`
+	EditWindowMinutes       *int              `json:"edit_window_minutes,omitempty" validate:"omitempty,min=0,max=525600"`
+	DuplicateWindowMinutes  *int              `json:"duplicate_window_minutes,omitempty" validate:"omitempty,min=0,max=10080"`
+	IPStorage               *IPStorageMode    `json:"ip_storage,omitempty" validate:"omitempty,oneof=full truncated none"`
+	DuplicateMatchUserAgent *bool             `json:"duplicate_match_user_agent,omitempty"`
+	// WebhookURL and WebhookSecret are cleared by sending an empty string
+	WebhookURL    *string `json:"webhook_url,omitempty" validate:"omitempty,max=2000"`
+	WebhookSecret *string `json:"webhook_secret,omitempty" validate:"omitempty,max=200"`
+	// WebhookTransform replaces the transform; an empty mapping removes it
+	WebhookTransform *WebhookTransform `json:"webhook_transform,omitempty"`
+	WebhookOrdered   *bool             `json:"webhook_ordered,omitempty"`
+
+	NotificationRules          []NotificationRule   `json:"notification_rules,omitempty"`
+	DefaultNotificationTargets []NotificationTarget `json:"default_notification_targets,omitempty"`
+	// MilestoneRules replaces the rules; an empty list removes them. Rules
+	// keep their fired state as long as they keep their id.
+	MilestoneRules []MilestoneRule `json:"milestone_rules,omitempty" validate:"dive"`
+	// MetadataSchema replaces the schema; an empty list removes it
+	MetadataSchema []MetadataKey `json:"metadata_schema,omitempty"`
+	RequireAuth    *bool         `json:"require_auth,omitempty"`
+	RequireInvite  *bool         `json:"require_invite,omitempty"`
+	// RequireApproval only affects later submissions
+	RequireApproval *bool `json:"require_approval,omitempty"`
+	// AnalyticsIncludeIncomplete takes effect at the next analytics recompute
+	AnalyticsIncludeIncomplete *bool `json:"analytics_include_incomplete,omitempty"`
+	// RedirectURL and ThankYouMessage are cleared by sending an empty string
+	RedirectURL     *string `json:"redirect_url,omitempty" validate:"omitempty,max=2000"`
+	ThankYouMessage *string `json:"thank_you_message,omitempty" validate:"omitempty,max=2000"`
+	// IntroContent and OutroContent replace the blocks; an empty block removes them
+	IntroContent *ContentBlock `json:"intro_content,omitempty"`
+	OutroContent *ContentBlock `json:"outro_content,omitempty"`
 }
 
 // SubmitResponseRequest represents the request to submit a form response
 type SubmitResponseRequest struct {
 	Responses map[string]interface{} `json:"responses" validate:"required"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	// InviteToken is required by forms with RequireInvite and is used up by
+	// the submission
+	InviteToken string `json:"invite_token,omitempty"`
+	// Source attributes the submission to a channel; see FormResponse.Source
+	Source string `json:"source,omitempty"`
+	// Partial saves an incomplete response to finish later; ResumeToken
+	// continues one, completing it unless Partial is set again
+	Partial     bool   `json:"partial,omitempty"`
+	ResumeToken string `json:"resume_token,omitempty" validate:"max=100"`
+}
+
+// CreateInvitesRequest represents the request to mint one-time invite tokens
+type CreateInvitesRequest struct {
+	Count          int `json:"count" validate:"required,min=1,max=1000"`
+	ExpiresInHours int `json:"expires_in_hours,omitempty" validate:"min=0,max=87600"`
+}
+
+// AddNoteRequest represents the request to add a reviewer note to a response
+type AddNoteRequest struct {
+	Author string `json:"author" validate:"required,min=1,max=100"`
+	Text   string `json:"text" validate:"required,min=1,max=5000"`
+}
+
+// BulkUpdateResponsesRequest represents the request to update the triage
+// fields of every response matching a filter
+type BulkUpdateResponsesRequest struct {
+	Filter struct {
+		Answers map[string]string `json:"answers,omitempty"`
+		Status  ResponseStatus    `json:"status,omitempty" validate:"omitempty,oneof=new in_review resolved archived"`
+		From    string            `json:"from,omitempty"`
+		To      string            `json:"to,omitempty"`
+	} `json:"filter"`
+	Update struct {
+		Status     ResponseStatus `json:"status,omitempty" validate:"omitempty,oneof=new in_review resolved archived"`
+		AddTags    []string       `json:"add_tags,omitempty" validate:"max=20,dive,min=1,max=50"`
+		RemoveTags []string       `json:"remove_tags,omitempty" validate:"max=20,dive,min=1,max=50"`
+	} `json:"update"`
+}
+
+// ReorderFieldsRequest represents the request to reorder a form's fields
+type ReorderFieldsRequest struct {
+	FieldIDs []string `json:"field_ids" validate:"required,min=1"`
+}
+
+// FormTemplate is a reusable form definition that new forms can be created from
+type FormTemplate struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name        string             `json:"name" bson:"name"`
+	Category    string             `json:"category,omitempty" bson:"category,omitempty"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	// Title, FormDescription, Fields and Sections are copied into forms created from the template
+	Title             string              `json:"title" bson:"title"`
+	FormDescription   string              `json:"form_description,omitempty" bson:"form_description,omitempty"`
+	Fields            []FormField         `json:"fields" bson:"fields"`
+	Sections          []FormSection       `json:"sections,omitempty" bson:"sections,omitempty"`
+	RequireAtLeastOne []AtLeastOneGroup   `json:"require_at_least_one,omitempty" bson:"require_at_least_one,omitempty"`
+	SourceFormID      *primitive.ObjectID `json:"source_form_id,omitempty" bson:"source_form_id,omitempty"`
+	CreatedAt         time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// SaveAsTemplateRequest represents the request to save a form as a template
+type SaveAsTemplateRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=200"`
+	Category    string `json:"category,omitempty" validate:"max=60"`
+	Description string `json:"description,omitempty" validate:"max=1000"`
+}
+
+// InstantiateTemplateRequest represents the request to create a form from a template
+type InstantiateTemplateRequest struct {
+	Title     string `json:"title,omitempty" validate:"max=200"`
+	OwnerSlug string `json:"owner_slug,omitempty" validate:"max=60"`
+}
+
+// WebhookDelivery records a single attempt to deliver a webhook
+type WebhookDelivery struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FormID       primitive.ObjectID  `json:"form_id" bson:"form_id"`
+	ResponseID   *primitive.ObjectID `json:"response_id,omitempty" bson:"response_id,omitempty"`
+	Event        string              `json:"event" bson:"event"`
+	URL          string              `json:"url" bson:"url"`
+	Payload      string              `json:"payload" bson:"payload"`
+	Attempt      int                 `json:"attempt" bson:"attempt"`
+	StatusCode   int                 `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	ResponseBody string              `json:"response_body,omitempty" bson:"response_body,omitempty"`
+	Error        string              `json:"error,omitempty" bson:"error,omitempty"`
+	Success      bool                `json:"success" bson:"success"`
+	DurationMs   int64               `json:"duration_ms" bson:"duration_ms"`
+	// RedeliveryOf points at the delivery that was manually retriggered
+	RedeliveryOf *primitive.ObjectID `json:"redelivery_of,omitempty" bson:"redelivery_of,omitempty"`
+	CreatedAt    time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// QueuedWebhook is an event waiting for ordered delivery to a form's
+// webhook. Events are delivered in _id order, one at a time.
+type QueuedWebhook struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FormID     primitive.ObjectID  `json:"form_id" bson:"form_id"`
+	ResponseID *primitive.ObjectID `json:"response_id,omitempty" bson:"response_id,omitempty"`
+	Event      string              `json:"event" bson:"event"`
+	Payload    string              `json:"payload" bson:"payload"`
+	// Attempts counts failed deliveries so far
+	Attempts int `json:"attempts" bson:"attempts"`
+	// LockedUntil is when the lease of the instance delivering the event, or
+	// waiting to retry it, runs out
+	LockedUntil *time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+}
+
+// AuditEntry records a single mutation of a form or one of its responses
+type AuditEntry struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FormID     primitive.ObjectID  `json:"form_id" bson:"form_id"`
+	ResponseID *primitive.ObjectID `json:"response_id,omitempty" bson:"response_id,omitempty"`
+	Action     string              `json:"action" bson:"action"`
+	// Actor is filled in once requests carry an authenticated user
+	Actor     string    `json:"actor,omitempty" bson:"actor,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty" bson:"ip_address,omitempty"`
+	Changes   []string  `json:"changes,omitempty" bson:"changes,omitempty"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
@@ -0,0 +1,122 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMaxLengths(t *testing.T) {
+	tests := []struct {
+		name                string
+		field               FormField
+		enforced, suggested int
+	}{
+		{"text", FormField{Type: FieldTypeText}, 0, DefaultTextMaxLength},
+		{"textarea", FormField{Type: FieldTypeTextarea}, 0, DefaultTextareaMaxLength},
+		{"hidden", FormField{Type: FieldTypeHidden}, DefaultHiddenMaxLength, DefaultHiddenMaxLength},
+		{"text with max", FormField{Type: FieldTypeText, Validation: ValidationRule{MaxLength: 5000}}, 5000, 5000},
+		{"email", FormField{Type: FieldTypeEmail}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.EffectiveMaxLength(); got != tt.enforced {
+				t.Errorf("EffectiveMaxLength() = %d, want %d", got, tt.enforced)
+			}
+			if got := tt.field.SuggestedMaxLength(); got != tt.suggested {
+				t.Errorf("SuggestedMaxLength() = %d, want %d", got, tt.suggested)
+			}
+		})
+	}
+}
+
+func TestConfirmation(t *testing.T) {
+	const (
+		redirect = "https://example.com/thanks"
+		message  = "Thanks for applying!"
+		webhook  = "https://hooks.example.com/forms"
+	)
+	tests := []struct {
+		name string
+		form Form
+		want string
+	}{
+		{"nothing configured", Form{}, `{"type":"default","message":"` + DefaultThankYouMessage + `"}`},
+		{"webhook only", Form{WebhookURL: webhook}, `{"type":"default","message":"` + DefaultThankYouMessage + `"}`},
+		{"message", Form{ThankYouMessage: message}, `{"type":"message","message":"` + message + `"}`},
+		{"message and webhook", Form{ThankYouMessage: message, WebhookURL: webhook}, `{"type":"message","message":"` + message + `"}`},
+		{"redirect", Form{RedirectURL: redirect}, `{"type":"redirect","message":"` + DefaultThankYouMessage + `","redirect_url":"` + redirect + `"}`},
+		{"redirect and message", Form{RedirectURL: redirect, ThankYouMessage: message}, `{"type":"redirect","message":"` + message + `","redirect_url":"` + redirect + `"}`},
+		{"redirect, message and webhook", Form{RedirectURL: redirect, ThankYouMessage: message, WebhookURL: webhook}, `{"type":"redirect","message":"` + message + `","redirect_url":"` + redirect + `"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.form.Confirmation())
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Confirmation() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRequired(t *testing.T) {
+	// "Explain your rating" is required unless the rating is above 3
+	highRating := []Condition{{FieldID: "rating", Operator: ConditionGreaterThan, Value: 3}}
+	needsDetail := []Condition{{FieldID: "detail", Operator: ConditionEquals, Value: "yes"}}
+
+	tests := []struct {
+		name      string
+		field     FormField
+		responses map[string]interface{}
+		want      bool
+	}{
+		{"unless met", FormField{RequiredUnless: highRating}, map[string]interface{}{"rating": 5.0}, false},
+		{"unless not met", FormField{RequiredUnless: highRating}, map[string]interface{}{"rating": 2.0}, true},
+		{"unless field unanswered", FormField{RequiredUnless: highRating}, map[string]interface{}{}, true},
+		{"required, unless met", FormField{Required: true, RequiredUnless: highRating}, map[string]interface{}{"rating": 5.0}, false},
+		{"required, unless not met", FormField{Required: true, RequiredUnless: highRating}, map[string]interface{}{"rating": 2.0}, true},
+		{"if met, unless not met", FormField{RequiredIf: needsDetail, RequiredUnless: highRating}, map[string]interface{}{"detail": "yes", "rating": 2.0}, true},
+		{"if not met, unless not met", FormField{RequiredIf: needsDetail, RequiredUnless: highRating}, map[string]interface{}{"detail": "no", "rating": 2.0}, false},
+		{"if met, unless met", FormField{RequiredIf: needsDetail, RequiredUnless: highRating}, map[string]interface{}{"detail": "yes", "rating": 5.0}, false},
+		{"optional", FormField{}, map[string]interface{}{"rating": 2.0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.IsRequired(tt.responses); got != tt.want {
+				t.Errorf("IsRequired(%v) = %v, want %v", tt.responses, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompletionPercent(t *testing.T) {
+	form := Form{Fields: []FormField{
+		{ID: "name", Type: FieldTypeText, Required: true},
+		{ID: "topics", Type: FieldTypeCheckbox, Required: true},
+		{ID: "notes", Type: FieldTypeTextarea},
+	}}
+	tests := []struct {
+		name      string
+		responses map[string]interface{}
+		want      float64
+	}{
+		{"all answered", map[string]interface{}{"name": "Ada", "topics": []interface{}{"go"}}, 100},
+		{"decoded list", map[string]interface{}{"name": "Ada", "topics": primitive.A{"go"}}, 100},
+		{"empty list", map[string]interface{}{"name": "Ada", "topics": []interface{}{}}, 50},
+		{"empty decoded list", map[string]interface{}{"name": "Ada", "topics": primitive.A{}}, 50},
+		{"empty string list", map[string]interface{}{"name": "Ada", "topics": []string{}}, 50},
+		{"empty string", map[string]interface{}{"name": "", "topics": []interface{}{"go"}}, 50},
+		{"nothing", map[string]interface{}{"notes": "Hi"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := form.CompletionPercent(tt.responses); got != tt.want {
+				t.Errorf("CompletionPercent(%v) = %v, want %v", tt.responses, got, tt.want)
+			}
+		})
+	}
+}
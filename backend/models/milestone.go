@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// MilestoneMetric is the analytics figure a milestone rule watches
+type MilestoneMetric string
+
+const (
+	MilestoneTotalResponses   MilestoneMetric = "total_responses"
+	MilestoneResponsesLast24h MilestoneMetric = "responses_last_24h"
+	// MilestoneCompletionRate is the percentage of responses answering every required field
+	MilestoneCompletionRate MilestoneMetric = "completion_rate"
+)
+
+// KnownMilestoneMetric reports whether m is a supported metric
+func KnownMilestoneMetric(m MilestoneMetric) bool {
+	switch m {
+	case MilestoneTotalResponses, MilestoneResponsesLast24h, MilestoneCompletionRate:
+		return true
+	}
+	return false
+}
+
+// MilestoneDirection says which side of the threshold reaches a milestone
+type MilestoneDirection string
+
+const (
+	// MilestoneAbove is reached once the metric is at or above the threshold
+	MilestoneAbove MilestoneDirection = "above"
+	// MilestoneBelow is reached once the metric drops below the threshold
+	MilestoneBelow MilestoneDirection = "below"
+)
+
+// MilestoneRule alerts the form's owner the first time an analytics metric
+// crosses a threshold, e.g. 1000 responses or a completion rate under 50%.
+// Rules are checked whenever the form's analytics are recomputed and fire at
+// most once; see Form.FiredMilestones.
+type MilestoneRule struct {
+	ID        string             `json:"id" bson:"id"`
+	Name      string             `json:"name,omitempty" bson:"name,omitempty" validate:"max=100"`
+	Metric    MilestoneMetric    `json:"metric" bson:"metric"`
+	Direction MilestoneDirection `json:"direction" bson:"direction"`
+	Threshold float64            `json:"threshold" bson:"threshold"`
+	// MinResponses holds the rule back until the form has this many responses,
+	// so rates aren't judged on the first few submissions
+	MinResponses int64 `json:"min_responses,omitempty" bson:"min_responses,omitempty"`
+	// Targets are notified besides the milestone_reached WebSocket event
+	Targets []NotificationTarget `json:"targets,omitempty" bson:"targets,omitempty"`
+}
+
+// Reached reports whether value reaches the milestone on a form with total responses
+func (r MilestoneRule) Reached(value float64, total int64) bool {
+	if total < r.MinResponses {
+		return false
+	}
+	if r.Direction == MilestoneBelow {
+		return value < r.Threshold
+	}
+	return value >= r.Threshold
+}
+
+// FiredMilestone records that a milestone rule fired and the value that
+// reached it
+type FiredMilestone struct {
+	RuleID  string    `json:"rule_id" bson:"rule_id"`
+	Value   float64   `json:"value" bson:"value"`
+	FiredAt time.Time `json:"fired_at" bson:"fired_at"`
+}
@@ -0,0 +1,279 @@
+package models
+
+import (
+	"mime/multipart"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetString returns the answer for a field as a string
+func (r *FormResponse) GetString(fieldID string) (string, bool) {
+	return AsString(r.Responses[fieldID])
+}
+
+// GetFloat returns the answer for a field as a number
+func (r *FormResponse) GetFloat(fieldID string) (float64, bool) {
+	return AsFloat(r.Responses[fieldID])
+}
+
+// GetStringSlice returns the answer for a field as a list of strings
+func (r *FormResponse) GetStringSlice(fieldID string) ([]string, bool) {
+	return AsStringSlice(r.Responses[fieldID])
+}
+
+// GetTime returns the answer for a field as a time
+func (r *FormResponse) GetTime(fieldID string) (time.Time, bool) {
+	return AsTime(r.Responses[fieldID])
+}
+
+// AsString coerces a stored answer to a string. Numbers and booleans are
+// formatted; other types are rejected.
+func AsString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "", false
+	}
+	if num, ok := AsFloat(value); ok {
+		return strconv.FormatFloat(num, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// AsFloat coerces a stored answer to a float64. Values decoded from Mongo may
+// be int32, int64 or float64, and clients sometimes send numbers as strings.
+func AsFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		num, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, false
+		}
+		return num, true
+	}
+	return 0, false
+}
+
+// AsStringSlice coerces a stored answer to a list of strings. A single scalar
+// is returned as a one-element list.
+func AsStringSlice(value interface{}) ([]string, bool) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case primitive.A:
+		items = v
+	case []interface{}:
+		items = v
+	default:
+		str, ok := AsString(value)
+		if !ok {
+			return nil, false
+		}
+		return []string{str}, true
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		str, ok := AsString(item)
+		if !ok {
+			return nil, false
+		}
+		result = append(result, str)
+	}
+	return result, true
+}
+
+// AsTime coerces a stored answer to a time. Strings may be RFC 3339 timestamps
+// or plain dates as sent by date fields.
+func AsTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case primitive.DateTime:
+		return v.Time(), true
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse("2006-01-02", v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FileAnswer is the stored answer to a file field: a reference to the
+// uploaded file, which is kept in upload storage under Key
+type FileAnswer struct {
+	Key         string `json:"key" bson:"key"`
+	Name        string `json:"name" bson:"name"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	Size        int64  `json:"size" bson:"size"`
+	// SHA256 is the hex digest of the contents; files stored before it was
+	// recorded have none
+	SHA256 string `json:"sha256,omitempty" bson:"sha256,omitempty"`
+}
+
+// Upload is a validated answer to a file field that hasn't been stored yet.
+// Once stored it is replaced with its FileAnswer.
+type Upload struct {
+	Header      *multipart.FileHeader
+	ContentType string
+	SHA256      string
+}
+
+// fileIdentity describes a file answer for fingerprints: its content digest,
+// or its name and size for files stored without one
+func fileIdentity(value interface{}) (string, bool) {
+	if upload, ok := value.(Upload); ok {
+		return "sha256:" + upload.SHA256, true
+	}
+	answer, ok := AsFileAnswer(value)
+	if !ok {
+		return "", false
+	}
+	if answer.SHA256 != "" {
+		return "sha256:" + answer.SHA256, true
+	}
+	return "name:" + answer.Name + ":" + strconv.FormatInt(answer.Size, 10), true
+}
+
+// AsFileAnswer coerces a stored answer to a FileAnswer
+func AsFileAnswer(value interface{}) (FileAnswer, bool) {
+	var doc map[string]interface{}
+	switch v := value.(type) {
+	case FileAnswer:
+		return v, v.Key != ""
+	case map[string]interface{}:
+		doc = v
+	case primitive.M:
+		doc = v
+	default:
+		return FileAnswer{}, false
+	}
+
+	answer := FileAnswer{}
+	answer.Key, _ = doc["key"].(string)
+	answer.Name, _ = doc["name"].(string)
+	answer.ContentType, _ = doc["content_type"].(string)
+	answer.SHA256, _ = doc["sha256"].(string)
+	switch size := doc["size"].(type) {
+	case int64:
+		answer.Size = size
+	case int32:
+		answer.Size = int64(size)
+	case float64:
+		answer.Size = int64(size)
+	}
+	return answer, answer.Key != ""
+}
+
+// Location is the stored answer to a location field. It is a GeoJSON Point,
+// with coordinates in [longitude, latitude] order, so the answers of a field
+// can back a 2dsphere index. Address optionally describes the point.
+type Location struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+	Address     string    `json:"address,omitempty" bson:"address,omitempty"`
+}
+
+// NewLocation builds a Location from a latitude and longitude
+func NewLocation(lat, lng float64, address string) Location {
+	return Location{Type: "Point", Coordinates: []float64{lng, lat}, Address: address}
+}
+
+// GeoPoint is a location answer as kept for geo queries: the point, without
+// its address, and the field it answers
+type GeoPoint struct {
+	FieldID string   `bson:"field_id"`
+	Point   Location `bson:"point"`
+}
+
+// GeoPoints returns the points of the form's location answers for geo
+// queries. Encrypted fields are left out: their answers are ciphertext.
+func (f Form) GeoPoints(responses map[string]interface{}) []GeoPoint {
+	var points []GeoPoint
+	for _, field := range f.Fields {
+		if field.Type != FieldTypeLocation || field.Encrypted {
+			continue
+		}
+		if location, ok := AsLocation(responses[field.ID]); ok {
+			points = append(points, GeoPoint{FieldID: field.ID, Point: Location{Type: "Point", Coordinates: location.Coordinates}})
+		}
+	}
+	return points
+}
+
+// Lat returns the location's latitude
+func (l Location) Lat() float64 {
+	return l.Coordinates[1]
+}
+
+// Lng returns the location's longitude
+func (l Location) Lng() float64 {
+	return l.Coordinates[0]
+}
+
+// AsLocation coerces an answer to a Location. Submissions send
+// {"lat", "lng", "address"}; stored answers are GeoJSON Points, which are
+// accepted as input too. Coordinate ranges are not checked here.
+func AsLocation(value interface{}) (Location, bool) {
+	var doc map[string]interface{}
+	switch v := value.(type) {
+	case Location:
+		return v, len(v.Coordinates) == 2
+	case map[string]interface{}:
+		doc = v
+	case primitive.M:
+		doc = v
+	default:
+		return Location{}, false
+	}
+
+	address, _ := doc["address"].(string)
+
+	if doc["type"] == "Point" {
+		var coordinates []interface{}
+		switch c := doc["coordinates"].(type) {
+		case []interface{}:
+			coordinates = c
+		case primitive.A:
+			coordinates = c
+		}
+		if len(coordinates) != 2 {
+			return Location{}, false
+		}
+		lng, lngOK := asCoordinate(coordinates[0])
+		lat, latOK := asCoordinate(coordinates[1])
+		return NewLocation(lat, lng, address), latOK && lngOK
+	}
+
+	lat, latOK := asCoordinate(doc["lat"])
+	lng, lngOK := asCoordinate(doc["lng"])
+	return NewLocation(lat, lng, address), latOK && lngOK
+}
+
+// asCoordinate reads a coordinate, which must be a number rather than a
+// numeric string
+func asCoordinate(value interface{}) (float64, bool) {
+	if _, ok := value.(string); ok {
+		return 0, false
+	}
+	return AsFloat(value)
+}
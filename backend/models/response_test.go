@@ -0,0 +1,129 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAsFloat(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		want   float64
+		wantOK bool
+	}{
+		{float64(1.5), 1.5, true},
+		{float32(2), 2, true},
+		{int(3), 3, true},
+		{int32(4), 4, true},
+		{int64(5), 5, true},
+		{"6.25", 6.25, true},
+		{"six", 0, false},
+		{true, 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := AsFloat(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("AsFloat(%#v) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestAsString(t *testing.T) {
+	tests := []struct {
+		value  interface{}
+		want   string
+		wantOK bool
+	}{
+		{"text", "text", true},
+		{true, "true", true},
+		{int32(7), "7", true},
+		{2.5, "2.5", true},
+		{nil, "", false},
+		{[]string{"a"}, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := AsString(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("AsString(%#v) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestAsStringSlice(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   []string
+		wantOK bool
+	}{
+		{"strings", []string{"a", "b"}, []string{"a", "b"}, true},
+		{"decoded array", primitive.A{"a", int32(1)}, []string{"a", "1"}, true},
+		{"interface slice", []interface{}{"a", true}, []string{"a", "true"}, true},
+		{"empty array", primitive.A{}, []string{}, true},
+		{"scalar", "a", []string{"a"}, true},
+		{"nested array", primitive.A{primitive.A{"a"}}, nil, false},
+		{"nil", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AsStringSlice(tt.value)
+			if !reflect.DeepEqual(got, tt.want) || ok != tt.wantOK {
+				t.Errorf("AsStringSlice(%#v) = %#v, %v, want %#v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAsTime(t *testing.T) {
+	when := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   time.Time
+		wantOK bool
+	}{
+		{"time", when, when, true},
+		{"decoded date", primitive.NewDateTimeFromTime(when), when, true},
+		{"RFC 3339", "2024-03-01T10:30:00Z", when, true},
+		{"plain date", "2024-03-01", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"other string", "March 1st", time.Time{}, false},
+		{"number", 1709289000, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AsTime(tt.value)
+			if !got.Equal(tt.want) || ok != tt.wantOK {
+				t.Errorf("AsTime(%#v) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormResponseGetters(t *testing.T) {
+	response := FormResponse{Responses: map[string]interface{}{
+		"age":    int64(42),
+		"name":   "Ada",
+		"colors": primitive.A{"red", "blue"},
+		"born":   "1815-12-10",
+	}}
+
+	if got, ok := response.GetFloat("age"); got != 42 || !ok {
+		t.Errorf("GetFloat(%q) = %v, %v, want 42, true", "age", got, ok)
+	}
+	if got, ok := response.GetString("name"); got != "Ada" || !ok {
+		t.Errorf("GetString(%q) = %q, %v, want %q, true", "name", got, ok, "Ada")
+	}
+	if got, ok := response.GetStringSlice("colors"); !reflect.DeepEqual(got, []string{"red", "blue"}) || !ok {
+		t.Errorf("GetStringSlice(%q) = %v, %v, want [red blue], true", "colors", got, ok)
+	}
+	if got, ok := response.GetTime("born"); got.Year() != 1815 || !ok {
+		t.Errorf("GetTime(%q) = %v, %v, want 1815-12-10, true", "born", got, ok)
+	}
+	if _, ok := response.GetString("missing"); ok {
+		t.Errorf("GetString(%q) ok = true, want false", "missing")
+	}
+}
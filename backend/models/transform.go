@@ -0,0 +1,55 @@
+package models
+
+import "strings"
+
+// AnswerTransform normalizes a text answer before it is validated and stored
+type AnswerTransform string
+
+const (
+	TransformTrim                AnswerTransform = "trim"
+	TransformLowercase           AnswerTransform = "lowercase"
+	TransformUppercase           AnswerTransform = "uppercase"
+	TransformNormalizeWhitespace AnswerTransform = "normalize_whitespace"
+)
+
+// KnownAnswerTransform reports whether t is a supported transform
+func KnownAnswerTransform(t AnswerTransform) bool {
+	switch t {
+	case TransformTrim, TransformLowercase, TransformUppercase, TransformNormalizeWhitespace:
+		return true
+	}
+	return false
+}
+
+// TransformsText reports whether answers to fields of this type can be
+// transformed; choice answers must keep matching their option values
+func TransformsText(t FieldType) bool {
+	switch t {
+	case FieldTypeText, FieldTypeTextarea, FieldTypeEmail, FieldTypeHidden:
+		return true
+	}
+	return false
+}
+
+// ApplyTransforms runs the field's transforms over a string answer in the
+// order they are listed. Other values are returned unchanged.
+func (f FormField) ApplyTransforms(value interface{}) interface{} {
+	str, ok := value.(string)
+	if !ok {
+		return value
+	}
+	for _, transform := range f.Transforms {
+		switch transform {
+		case TransformTrim:
+			str = strings.TrimSpace(str)
+		case TransformLowercase:
+			str = strings.ToLower(str)
+		case TransformUppercase:
+			str = strings.ToUpper(str)
+		case TransformNormalizeWhitespace:
+			// Runs of whitespace, newlines included, become one space
+			str = strings.Join(strings.Fields(str), " ")
+		}
+	}
+	return str
+}
@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestApplyTransforms(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []AnswerTransform
+		value      interface{}
+		want       interface{}
+	}{
+		{"none", nil, "  Mixed Case  ", "  Mixed Case  "},
+		{"trim", []AnswerTransform{TransformTrim}, "  Ada \n", "Ada"},
+		{"lowercase", []AnswerTransform{TransformLowercase}, "Ada@Example.COM", "ada@example.com"},
+		{"uppercase", []AnswerTransform{TransformUppercase}, "ab12cd", "AB12CD"},
+		{"normalize whitespace", []AnswerTransform{TransformNormalizeWhitespace}, " two\n\nlines\t here ", "two lines here"},
+		{"trim then uppercase", []AnswerTransform{TransformTrim, TransformUppercase}, " ab12 ", "AB12"},
+		{"all in order", []AnswerTransform{TransformNormalizeWhitespace, TransformLowercase, TransformTrim}, "  Hello   WORLD ", "hello world"},
+		{"non-string", []AnswerTransform{TransformTrim}, 42.0, 42.0},
+		{"list", []AnswerTransform{TransformUppercase}, []interface{}{"a"}, []interface{}{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := FormField{Transforms: tt.transforms}
+			got := field.ApplyTransforms(tt.value)
+			if list, ok := tt.want.([]interface{}); ok {
+				if gotList, ok := got.([]interface{}); !ok || len(gotList) != len(list) || gotList[0] != list[0] {
+					t.Errorf("ApplyTransforms(%#v) = %#v, want %#v", tt.value, got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ApplyTransforms(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
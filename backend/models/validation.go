@@ -0,0 +1,83 @@
+package models
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// validationIntKeys and validationFloatKeys are the numeric limits of a
+// ValidationRule, which older clients sometimes stored as fractional doubles
+// or strings
+var (
+	validationIntKeys   = []string{"min_length", "max_length"}
+	validationFloatKeys = []string{"min", "max"}
+)
+
+// UnmarshalBSONValue decodes a validation block, tolerating legacy
+// documents. A missing, null or non-document block decodes to no validation.
+// Numeric limits stored as doubles or numeric strings are converted, and
+// limits that can't be read are dropped rather than failing the whole form.
+func (v *ValidationRule) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	type plain ValidationRule
+
+	*v = ValidationRule{}
+	if t != bsontype.EmbeddedDocument {
+		return nil
+	}
+	if err := bson.Unmarshal(data, (*plain)(v)); err == nil {
+		return nil
+	}
+
+	var doc bson.M
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for _, key := range validationIntKeys {
+		if value, ok := doc[key]; ok {
+			if n, ok := lenientNumber(value); ok {
+				doc[key] = int64(math.Trunc(n))
+			} else {
+				delete(doc, key)
+			}
+		}
+	}
+	for _, key := range validationFloatKeys {
+		if value, ok := doc[key]; ok {
+			if n, ok := lenientNumber(value); ok {
+				doc[key] = n
+			} else {
+				delete(doc, key)
+			}
+		}
+	}
+	if _, ok := doc["required"].(bool); !ok {
+		delete(doc, "required")
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	*v = ValidationRule{}
+	return bson.Unmarshal(raw, (*plain)(v))
+}
+
+// lenientNumber reads a number stored as any numeric BSON type or as a string
+func lenientNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
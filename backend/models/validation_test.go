@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestValidationRuleLegacyDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation interface{}
+		want       ValidationRule
+	}{
+		{"missing", nil, ValidationRule{}},
+		{"null", bson.D{{Key: "validation", Value: nil}}, ValidationRule{}},
+		{"not a document", bson.D{{Key: "validation", Value: "required"}}, ValidationRule{}},
+		{"empty", bson.D{{Key: "validation", Value: bson.D{}}}, ValidationRule{}},
+		{"partial", bson.D{{Key: "validation", Value: bson.D{{Key: "max_length", Value: int32(20)}}}}, ValidationRule{MaxLength: 20}},
+		{"fractional limits", bson.D{{Key: "validation", Value: bson.D{{Key: "min_length", Value: 2.7}, {Key: "max", Value: int32(5)}}}}, ValidationRule{MinLength: 2, Max: 5}},
+		{"string limits", bson.D{{Key: "validation", Value: bson.D{{Key: "max_length", Value: " 30 "}, {Key: "min", Value: "1.5"}}}}, ValidationRule{MaxLength: 30, Min: 1.5}},
+		{"unreadable limits", bson.D{{Key: "validation", Value: bson.D{{Key: "max_length", Value: "lots"}, {Key: "required", Value: "yes"}, {Key: "pattern", Value: "^a"}}}}, ValidationRule{Pattern: "^a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := bson.D{{Key: "id", Value: "name"}, {Key: "type", Value: "text"}, {Key: "label", Value: "Name"}}
+			if doc, ok := tt.validation.(bson.D); ok {
+				field = append(field, doc...)
+			}
+			// A minimal legacy form: no fields added since, just its fields
+			raw, err := bson.Marshal(bson.D{{Key: "title", Value: "Legacy"}, {Key: "fields", Value: bson.A{field}}})
+			if err != nil {
+				t.Fatalf("bson.Marshal() error = %v", err)
+			}
+
+			var form Form
+			if err := bson.Unmarshal(raw, &form); err != nil {
+				t.Fatalf("bson.Unmarshal() error = %v", err)
+			}
+			if len(form.Fields) != 1 || form.Fields[0].ID != "name" {
+				t.Fatalf("bson.Unmarshal() fields = %+v, want the name field", form.Fields)
+			}
+			if got := form.Fields[0].Validation; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validation = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package proxy resolves the client address of requests that reach the API
+// through reverse proxies or load balancers
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Trusted is a set of proxy addresses whose forwarding headers are believed
+type Trusted []*net.IPNet
+
+// ParseTrusted reads a comma-separated list of IPs and CIDR ranges
+func ParseTrusted(list string) (Trusted, error) {
+	var trusted Trusted
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			trusted = append(trusted, network)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return trusted, nil
+}
+
+// Contains reports whether ip belongs to a trusted proxy
+func (t Trusted) Contains(ip net.IP) bool {
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client behind remote, the peer the
+// request came from. Only trusted proxies' headers are believed.
+//
+// X-Forwarded-For is a list every proxy appends its peer to, so everything
+// left of the last trusted hop may have been written by the client. It is
+// read from the right, skipping trusted proxies, and the first other address
+// is the client. Any other header is taken to hold a single address that the
+// proxy sets itself (e.g. X-Real-IP), overwriting what the client sent.
+func ClientIP(remote net.IP, header string, values []string, trusted Trusted) net.IP {
+	if !trusted.Contains(remote) {
+		return remote
+	}
+
+	if !strings.EqualFold(header, fiber.HeaderXForwardedFor) {
+		if len(values) != 1 {
+			return remote
+		}
+		if ip := parseHop(values[0]); ip != nil {
+			return ip
+		}
+		return remote
+	}
+
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0 && trusted.Contains(client); i-- {
+		ip := parseHop(hops[i])
+		if ip == nil {
+			// Whoever wrote a malformed entry can't be trusted any further
+			break
+		}
+		client = ip
+	}
+	return client
+}
+
+// parseHop reads one forwarded address, which may carry a port
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		hop = host
+	}
+	return net.ParseIP(strings.Trim(hop, "[]"))
+}
+
+// New returns middleware that makes c.IP() report the client address
+// resolved by ClientIP. It must run before anything that reads c.IP().
+func New(trusted Trusted, header string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		remote := c.Context().RemoteIP()
+		var values []string
+		for _, value := range c.Request().Header.PeekAll(header) {
+			values = append(values, string(value))
+		}
+		if client := ClientIP(remote, header, values, trusted); !client.Equal(remote) {
+			c.Context().SetRemoteAddr(&net.TCPAddr{IP: client})
+		}
+		return c.Next()
+	}
+}
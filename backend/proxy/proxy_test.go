@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrusted("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		values []string
+		want   string
+	}{
+		{"untrusted peer ignores header", "203.0.113.9", fiber.HeaderXForwardedFor, []string{"1.2.3.4"}, "203.0.113.9"},
+		{"no header keeps peer", "10.0.0.1", fiber.HeaderXForwardedFor, nil, "10.0.0.1"},
+		{"single hop", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"198.51.100.7"}, "198.51.100.7"},
+		{"spoofed entry left of the real client", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"1.2.3.4, 198.51.100.7"}, "198.51.100.7"},
+		{"trusted hops are skipped", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"1.2.3.4, 198.51.100.7, 192.0.2.1, 10.1.1.1"}, "198.51.100.7"},
+		{"repeated headers are one list", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"1.2.3.4", "198.51.100.7"}, "198.51.100.7"},
+		{"malformed entry stops the walk", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"198.51.100.7, bogus, 10.2.2.2"}, "10.2.2.2"},
+		{"ports are stripped", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"198.51.100.7:4711"}, "198.51.100.7"},
+		{"ipv6 hop", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"[2001:db8::1]:443"}, "2001:db8::1"},
+		{"all hops trusted", "10.0.0.1", fiber.HeaderXForwardedFor, []string{"10.3.3.3"}, "10.3.3.3"},
+		{"single-value header", "10.0.0.1", "X-Real-IP", []string{"198.51.100.7"}, "198.51.100.7"},
+		{"single-value header with a list", "10.0.0.1", "X-Real-IP", []string{"1.2.3.4, 198.51.100.7"}, "10.0.0.1"},
+		{"single-value header sent twice", "10.0.0.1", "X-Real-IP", []string{"1.2.3.4", "198.51.100.7"}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClientIP(net.ParseIP(tt.remote), tt.header, tt.values, trusted)
+			if !got.Equal(net.ParseIP(tt.want)) {
+				t.Errorf("ClientIP() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedRejectsGarbage(t *testing.T) {
+	if _, err := ParseTrusted("10.0.0.0/8, proxy.internal"); err == nil {
+		t.Error("expected an error for a host name")
+	}
+}
+
+func TestMiddlewareSetsClientIP(t *testing.T) {
+	// app.Test requests come from 0.0.0.0
+	trusted, _ := ParseTrusted("0.0.0.0")
+	app := fiber.New()
+	app.Use(New(trusted, fiber.HeaderXForwardedFor))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString(c.IP()) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderXForwardedFor, "1.2.3.4, 198.51.100.7")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := make([]byte, 64)
+	n, _ := resp.Body.Read(body)
+	if got := string(body[:n]); got != "198.51.100.7" {
+		t.Errorf("c.IP() = %q, want 198.51.100.7", got)
+	}
+}
@@ -1,6 +1,10 @@
 package routes
 
 import (
+	"sort"
+	"strings"
+
+	"form-builder-api/auth"
 	"form-builder-api/controllers"
 	"form-builder-api/websocket"
 
@@ -13,6 +17,16 @@ func SetupRoutes(app *fiber.App, hub *websocket.Hub) {
 	// Initialize controllers
 	formController := controllers.NewFormController(hub)
 	responseController := controllers.NewResponseController(hub)
+	maintenanceController := controllers.NewMaintenanceController()
+	auditController := controllers.NewAuditController()
+	webhookController := controllers.NewWebhookController()
+	exportController := controllers.NewExportController()
+	templateController := controllers.NewTemplateController(hub)
+
+	// Dashboard clients load the form list over the socket with sync_forms
+	hub.FormsSync = formController.FormsSync
+	// Respondent clients report time spent per field with field_timing
+	hub.FieldTiming = responseController.RecordFieldTiming
 
 	// API v1 group
 	api := app.Group("/api/v1")
@@ -21,25 +35,88 @@ func SetupRoutes(app *fiber.App, hub *websocket.Hub) {
 	forms := api.Group("/forms")
 	forms.Post("/", formController.CreateForm)
 	forms.Get("/", formController.GetForms)
+	forms.Post("/batch-get", formController.BatchGetForms)
+	forms.Get("/analytics/summary", responseController.GetAnalyticsSummary)
+	forms.Get("/export-all", exportController.ExportAllForms)
 	forms.Get("/:id", formController.GetForm)
 	forms.Put("/:id", formController.UpdateForm)
 	forms.Delete("/:id", formController.DeleteForm)
 	forms.Post("/:id/publish", formController.PublishForm)
+	forms.Post("/:id/accepting-responses", formController.SetAcceptingResponses)
 	forms.Post("/:id/duplicate", formController.DuplicateForm)
+	forms.Put("/:id/fields/order", formController.ReorderFields)
+	forms.Get("/:id/schema", formController.GetFormSchema)
+	forms.Get("/:id/fields/:fieldId/values", responseController.GetFieldValues)
+	forms.Get("/:id/audit", auditController.GetAuditLog)
+	forms.Post("/:id/save-as-template", templateController.SaveAsTemplate)
+
+	// Template routes
+	templates := api.Group("/templates")
+	templates.Get("/", templateController.GetTemplates)
+	templates.Post("/:id/instantiate", templateController.InstantiateTemplate)
 
 	// Public form access by token
 	api.Get("/forms/public/:token", formController.GetFormByToken)
+	api.Get("/forms/public/:token/og", formController.GetFormOpenGraph)
+	api.Get("/forms/public/:token/og.png", formController.GetFormOpenGraphImage)
+
+	// Read-only analytics for embeds, authorized by the form's analytics token
+	api.Get("/analytics/embed/:token", responseController.GetEmbeddedAnalytics)
+
+	// Public form access by owner-scoped slug
+	api.Get("/u/:ownerSlug/forms/:slug", formController.GetFormBySlug)
 
 	// Response routes
 	forms.Post("/:id/responses", responseController.SubmitResponse)
+	forms.Post("/:id/responses/preview", responseController.PreviewResponse)
 	forms.Get("/:id/responses", responseController.GetResponses)
+	forms.Get("/:id/responses/count", responseController.CountResponses)
+	forms.Get("/:id/responses/export", exportController.ExportResponses)
+	forms.Post("/:id/responses/bulk-update", responseController.BulkUpdateResponses)
+	forms.Get("/:id/responses/validate-report", responseController.GetValidationReport)
+	forms.Get("/:id/responses/duplicates", responseController.GetDuplicateResponses)
+	forms.Get("/:id/responses/near", responseController.GetResponsesNear)
+	forms.Get("/:id/responses/stream", authenticateStream, responseController.StreamResponses)
+	forms.Get("/:id/responses/receipt/:code", responseController.GetResponseByReceipt)
+	forms.Put("/:id/responses/:responseId", responseController.EditResponse)
+	forms.Post("/:id/responses/:responseId/approve", responseController.ApproveResponse)
+	forms.Get("/:id/responses/:responseId/history", responseController.GetResponseHistory)
+	forms.Get("/:id/responses/:responseId/files/:fieldId", responseController.DownloadFile)
+	forms.Get("/:id/responses/:responseId/notes", responseController.GetNotes)
+	forms.Post("/:id/responses/:responseId/notes", responseController.AddNote)
 	forms.Get("/:id/analytics", responseController.GetAnalytics)
+	forms.Get("/:id/overview", responseController.GetFormOverview)
+	forms.Get("/:id/stats", responseController.GetSubmissionStats)
+	forms.Post("/:id/analytics-token", formController.RotateAnalyticsToken)
+	forms.Delete("/:id/analytics-token", formController.RevokeAnalyticsToken)
+	forms.Post("/:id/invites", responseController.CreateInvites)
+
+	// Combined export of several forms
+	api.Post("/responses/export", exportController.ExportMergedResponses)
+
+	// Export jobs and presigned downloads
+	forms.Post("/:id/exports", exportController.CreateExport)
+	forms.Get("/:id/exports/:jobId", exportController.GetExportJob)
+	api.Get("/downloads/:key", exportController.DownloadExport)
+
+	// Webhook tooling and delivery log
+	api.Post("/webhooks/test", webhookController.TestWebhook)
+	forms.Get("/:id/webhooks/deliveries", webhookController.GetDeliveries)
+	forms.Get("/:id/webhooks/queue", webhookController.GetWebhookQueue)
+	forms.Delete("/:id/webhooks/queue/:eventId", webhookController.SkipQueuedWebhook)
+	forms.Post("/:id/webhooks/:deliveryId/redeliver", webhookController.Redeliver)
+
+	// Maintenance routes
+	maintenance := api.Group("/maintenance")
+	maintenance.Get("/orphaned-responses", maintenanceController.GetOrphanedResponses)
+	maintenance.Delete("/orphaned-responses", maintenanceController.DeleteOrphanedResponses)
+	maintenance.Post("/disposable-domains/reload", maintenanceController.ReloadDisposableDomains)
 
 	// WebSocket endpoint
 	app.Use("/ws", func(c *fiber.Ctx) error {
 		if websocketFiber.IsWebSocketUpgrade(c) {
 			c.Locals("allowed", true)
-			return c.Next()
+			return authenticateStream(c)
 		}
 		return fiber.ErrUpgradeRequired
 	})
@@ -51,15 +128,55 @@ func SetupRoutes(app *fiber.App, hub *websocket.Hub) {
 	// Health check
 	api.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
-			"status": "ok",
+			"status":  "ok",
 			"message": "Form Builder API is running",
 		})
 	})
 
-	// Catch all for undefined routes
+	// Catch all for undefined routes; known paths hit with the wrong method get a 405
 	app.Use("*", func(c *fiber.Ctx) error {
+		if allowed := allowedMethods(app, c.Path()); len(allowed) > 0 {
+			c.Set(fiber.HeaderAllow, strings.Join(allowed, ", "))
+			return c.Status(405).JSON(fiber.Map{
+				"error": "Method not allowed",
+			})
+		}
+
 		return c.Status(404).JSON(fiber.Map{
 			"error": "Route not found",
 		})
 	})
 }
+
+// allowedMethods lists the methods registered for routes matching path
+func allowedMethods(app *fiber.App, path string) []string {
+	seen := make(map[string]bool)
+	methods := make([]string, 0)
+	for _, route := range app.GetRoutes(true) {
+		if seen[route.Method] || !fiber.RoutePatternMatch(path, route.Path) {
+			continue
+		}
+		seen[route.Method] = true
+		methods = append(methods, route.Method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// authenticateStream authenticates real-time connections (WebSocket and
+// Server-Sent Events) and stores the signed-in user, if any, in the "user"
+// local. Browsers can't set headers on these requests, so the session token
+// may also come as ?token=.
+func authenticateStream(c *fiber.Ctx) error {
+	if token := c.Query("token"); token != "" {
+		c.Request().Header.Set("Authorization", "Bearer "+token)
+	}
+	user, err := auth.FromRequest(c)
+	if err != nil {
+		return fiber.ErrUnauthorized
+	}
+	if user != nil {
+		c.Locals("user", user)
+	}
+	return c.Next()
+}
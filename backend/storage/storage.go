@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidKey is returned for keys that could escape the storage root
+	ErrInvalidKey = errors.New("invalid storage key")
+	// ErrLinkExpired is returned when a presigned link is past its expiry
+	ErrLinkExpired = errors.New("download link has expired")
+	// ErrBadSignature is returned when a presigned link has been tampered with
+	ErrBadSignature = errors.New("invalid download link signature")
+)
+
+// Storage stores generated files (exports) and hands out time-limited
+// download links for them
+type Storage interface {
+	// Put stores the contents of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns a reader for the object stored under key
+	Open(key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; a missing object is not an
+	// error
+	Delete(key string) error
+	// PresignURL returns a download URL for key that stops working after ttl
+	PresignURL(key string, ttl time.Duration) (string, time.Time, error)
+}
+
+// LocalStorage keeps objects on the local filesystem and serves them through
+// the API's download route using HMAC-signed links
+type LocalStorage struct {
+	dir     string
+	baseURL string
+	secret  []byte
+}
+
+// NewLocalStorage creates a filesystem-backed storage rooted at dir. Links
+// point at baseURL and are signed with secret.
+func NewLocalStorage(dir, baseURL string, secret []byte) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{
+		dir:     dir,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+	}, nil
+}
+
+// validKey keeps keys to a single flat path segment
+func validKey(key string) bool {
+	return key != "" && key != "." && key != ".." && !strings.ContainsAny(key, `/\`)
+}
+
+// Put writes to a temporary file first so readers never see a partial object
+func (s *LocalStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	if !validKey(key) {
+		return 0, ErrInvalidKey
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return written, os.Rename(tmp.Name(), filepath.Join(s.dir, key))
+}
+
+// Open returns the stored object for key
+func (s *LocalStorage) Open(key string) (io.ReadCloser, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	return os.Open(filepath.Join(s.dir, key))
+}
+
+// Delete removes the stored object for key
+func (s *LocalStorage) Delete(key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	if err := os.Remove(filepath.Join(s.dir, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List calls fn with the key and modification time of every stored object.
+// Temporary files of writes still in progress are left out.
+func (s *LocalStorage) List(fn func(key string, modTime time.Time)) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		fn(entry.Name(), info.ModTime())
+	}
+	return nil
+}
+
+// PresignURL returns a link to the download route signed over key and expiry
+func (s *LocalStorage) PresignURL(key string, ttl time.Duration) (string, time.Time, error) {
+	if !validKey(key) {
+		return "", time.Time{}, ErrInvalidKey
+	}
+
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, expires))
+
+	return s.baseURL + "/api/v1/downloads/" + url.PathEscape(key) + "?" + query.Encode(), expiresAt, nil
+}
+
+// Verify checks a link produced by PresignURL
+func (s *LocalStorage) Verify(key, expires, signature string) error {
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return ErrBadSignature
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if time.Now().Unix() > unix {
+		return ErrLinkExpired
+	}
+	return nil
+}
+
+func (s *LocalStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	defaultStorage     Storage
+	defaultStorageOnce sync.Once
+	uploadStorage      Storage
+	uploadStorageOnce  sync.Once
+	signingKey         []byte
+	signingKeyOnce     sync.Once
+)
+
+// Default returns the storage configured through the environment:
+// STORAGE_DIR (default "exports"), PUBLIC_BASE_URL for download links and
+// STORAGE_SIGNING_KEY to sign them. Returns nil if the storage can't be set up.
+func Default() Storage {
+	defaultStorageOnce.Do(func() {
+		defaultStorage = newLocalFromEnv("STORAGE_DIR", "exports")
+	})
+	return defaultStorage
+}
+
+// Uploads returns the storage for files uploaded to file fields, kept apart
+// from exports in UPLOAD_DIR (default "uploads"). Links are configured like
+// Default's. Returns nil if the storage can't be set up.
+func Uploads() Storage {
+	uploadStorageOnce.Do(func() {
+		uploadStorage = newLocalFromEnv("UPLOAD_DIR", "uploads")
+	})
+	return uploadStorage
+}
+
+// newLocalFromEnv sets up local storage in the directory named by dirEnv
+func newLocalFromEnv(dirEnv, defaultDir string) Storage {
+	dir := os.Getenv(dirEnv)
+	if dir == "" {
+		dir = defaultDir
+	}
+
+	local, err := NewLocalStorage(dir, publicBaseURL(), storageSigningKey())
+	if err != nil {
+		log.Println("Error initializing storage:", err)
+		return nil
+	}
+	return local
+}
+
+// publicBaseURL is where download links point: PUBLIC_BASE_URL, or the API
+// on localhost
+func publicBaseURL() string {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		port := os.Getenv("PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL = "http://localhost:" + port
+	}
+	return baseURL
+}
+
+// storageSigningKey returns STORAGE_SIGNING_KEY, or a random key shared by
+// all storages until the next restart
+func storageSigningKey() []byte {
+	signingKeyOnce.Do(func() {
+		signingKey = []byte(os.Getenv("STORAGE_SIGNING_KEY"))
+		if len(signingKey) == 0 {
+			log.Println("STORAGE_SIGNING_KEY not set; download links will not survive a restart")
+			signingKey = make([]byte, 32)
+			rand.Read(signingKey)
+		}
+	})
+	return signingKey
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for targets that resolve to addresses
+// inside the server's own network
+var ErrForbiddenAddress = errors.New("target address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), where some
+// clouds serve instance metadata
+var sharedAddressSpace = &net.IPNet{IP: net.IP{100, 64, 0, 0}, Mask: net.CIDRMask(10, 32)}
+
+// IsPublicIP reports whether ip is a publicly routable unicast address, i.e.
+// not loopback, private, link-local (which covers cloud metadata at
+// 169.254.169.254), shared, multicast or unspecified
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return !sharedAddressSpace.Contains(ip4) && !ip4.Equal(net.IPv4bcast)
+	}
+	return true
+}
+
+// CheckPublicHost resolves host and fails unless every address it resolves to
+// is public
+func CheckPublicHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// publicClient only connects to public addresses. The check runs on the
+// address actually dialed, so DNS rebinding and redirects can't reach
+// internal hosts either.
+var publicClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+					return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// DeliverPublic is Deliver restricted to public addresses, for targets given
+// by untrusted callers
+func DeliverPublic(ctx context.Context, url, event string, payload []byte, secret string) (Result, error) {
+	return deliver(ctx, publicClient, url, event, payload, secret)
+}
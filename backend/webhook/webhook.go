@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 signature of the request body
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader names the event that triggered the delivery
+	EventHeader = "X-Webhook-Event"
+
+	signaturePrefix = "sha256="
+	maxBodySnippet  = 1024
+)
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Result describes the outcome of a single delivery attempt
+type Result struct {
+	StatusCode int           `json:"status_code"`
+	Body       string        `json:"body,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Success reports whether the receiver acknowledged the delivery
+func (r Result) Success() bool {
+	return r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Sign returns the signature header value for payload: "sha256=" followed by
+// the hex-encoded HMAC-SHA256 of the payload keyed with secret
+func Sign(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is a valid signature of payload
+// for secret. Receivers should call it with the raw request body and the
+// value of the X-Webhook-Signature header.
+func VerifySignature(payload []byte, signature, secret string) bool {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(payload, secret)))
+}
+
+// Deliver POSTs a JSON payload to url, signing it when a secret is set
+func Deliver(ctx context.Context, url, event string, payload []byte, secret string) (Result, error) {
+	return deliver(ctx, client, url, event, payload, secret)
+}
+
+func deliver(ctx context.Context, client *http.Client, url, event string, payload []byte, secret string) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "form-builder-webhook/1.0")
+	if event != "" {
+		req.Header.Set(EventHeader, event)
+	}
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(payload, secret))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{Duration: time.Since(start)}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySnippet))
+
+	return Result{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		Duration:   time.Since(start),
+	}, nil
+}
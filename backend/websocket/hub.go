@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
+	"form-builder-api/models"
+
 	"github.com/gofiber/websocket/v2"
 )
 
@@ -15,8 +19,29 @@ type Client struct {
 	Send   chan []byte
 	Hub    *Hub
 	FormID string
+
+	// User is the signed-in user of the connection, if it presented a token
+	User *models.AuthenticatedUser
+
+	// timingEvents counts field_timing messages accepted on this connection
+	timingEvents int
+	// lastServerTime is when the connection last got a server_time reply
+	lastServerTime time.Time
+
+	// Inbound rate limiting: a token bucket refilled at Hub.MessageRate, and
+	// the number of messages dropped since the client last got through
+	tokens     float64
+	lastRefill time.Time
+	dropStreak int
 }
 
+// serverTimeInterval is how often a connection may ask for server_time
+const serverTimeInterval = time.Second
+
+// maxTimingEventsPerClient bounds how many field timings one connection may
+// report, so a single client can't flood the timing store
+const maxTimingEventsPerClient = 500
+
 // Hub maintains the set of active clients and broadcasts messages to the clients
 type Hub struct {
 	// Registered clients
@@ -30,6 +55,59 @@ type Hub struct {
 
 	// Unregister requests from clients
 	Unregister chan *Client
+
+	// FormsSync builds the forms_sync reply for a client's sync_forms request
+	FormsSync FormsSyncFunc
+
+	// FieldTiming records how long a respondent spent on a field. It runs
+	// off the read loops, on timings queued in timings, so a slow store never
+	// stalls a connection.
+	FieldTiming FieldTimingFunc
+	timings     chan fieldTiming
+
+	// ReadLimit is the largest inbound message accepted, in bytes
+	ReadLimit int64
+	// MessageRate is how many inbound messages per second a connection may
+	// send on average; bursts of twice that are allowed
+	MessageRate float64
+}
+
+// Defaults for the inbound limits; WS_READ_LIMIT and WS_MESSAGE_RATE override them
+const (
+	defaultReadLimit   = 512 * 1024 // 512KB
+	defaultMessageRate = 10
+)
+
+// maxDroppedMessages disconnects a client that keeps sending while throttled
+const maxDroppedMessages = 200
+
+// FormsSyncFunc returns one page of the form list after cursor, or only the
+// list version when etag is still current
+type FormsSyncFunc func(etag, cursor string) (interface{}, error)
+
+// FieldTimingFunc records a focus-to-blur duration for a field of a form
+type FieldTimingFunc func(formID, fieldID string, duration time.Duration)
+
+// fieldTiming is a field timing queued for Hub.FieldTiming
+type fieldTiming struct {
+	formID, fieldID string
+	duration        time.Duration
+}
+
+// fieldTimingQueueSize bounds the timings waiting to be recorded; more are
+// dropped while the store falls behind
+const fieldTimingQueueSize = 1000
+
+// FieldTimingEvent is the payload of a field_timing message
+type FieldTimingEvent struct {
+	FieldID    string `json:"field_id"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// SyncRequest is the payload of a sync_forms message
+type SyncRequest struct {
+	ETag   string `json:"etag"`
+	Cursor string `json:"cursor"`
 }
 
 // Message represents a WebSocket message
@@ -42,16 +120,30 @@ type Message struct {
 
 // NewHub creates a new Hub
 func NewHub() *Hub {
+	readLimit, err := strconv.ParseInt(os.Getenv("WS_READ_LIMIT"), 10, 64)
+	if err != nil || readLimit <= 0 {
+		readLimit = defaultReadLimit
+	}
+	messageRate, err := strconv.ParseFloat(os.Getenv("WS_MESSAGE_RATE"), 64)
+	if err != nil || messageRate <= 0 {
+		messageRate = defaultMessageRate
+	}
+
 	return &Hub{
-		Clients:    make(map[*Client]bool),
-		Broadcast:  make(chan []byte),
-		Register:   make(chan *Client),
-		Unregister: make(chan *Client),
+		Clients:     make(map[*Client]bool),
+		Broadcast:   make(chan []byte),
+		Register:    make(chan *Client),
+		Unregister:  make(chan *Client),
+		timings:     make(chan fieldTiming, fieldTimingQueueSize),
+		ReadLimit:   readLimit,
+		MessageRate: messageRate,
 	}
 }
 
 // Run starts the hub
 func (h *Hub) Run() {
+	go h.recordFieldTimings()
+
 	for {
 		select {
 		case client := <-h.Register:
@@ -78,6 +170,15 @@ func (h *Hub) Run() {
 	}
 }
 
+// recordFieldTimings hands queued field timings to FieldTiming one at a time
+func (h *Hub) recordFieldTimings() {
+	for timing := range h.timings {
+		if h.FieldTiming != nil {
+			h.FieldTiming(timing.formID, timing.fieldID, timing.duration)
+		}
+	}
+}
+
 // BroadcastToForm sends a message to all clients subscribed to a specific form
 func (h *Hub) BroadcastToForm(formID string, messageType string, data interface{}) {
 	message := Message{
@@ -121,6 +222,21 @@ func (h *Hub) BroadcastGeneral(messageType string, data interface{}) {
 	h.Broadcast <- jsonData
 }
 
+// Subscribe registers a client for a form's broadcasts that has no WebSocket
+// connection of its own, such as a Server-Sent Events stream. Messages
+// arrive on its Send channel, which is closed once the client is
+// unsubscribed or falls too far behind.
+func (h *Hub) Subscribe(formID string) *Client {
+	client := &Client{Send: make(chan []byte, 256), Hub: h, FormID: formID}
+	h.Register <- client
+	return client
+}
+
+// Unsubscribe removes a client added with Subscribe
+func (h *Hub) Unsubscribe(client *Client) {
+	h.Unregister <- client
+}
+
 // HandleWebSocket handles WebSocket connections
 func HandleWebSocket(c *websocket.Conn, hub *Hub) {
 	remote := "unknown"
@@ -129,6 +245,9 @@ func HandleWebSocket(c *websocket.Conn, hub *Hub) {
 	}
 	log.Printf("[WS] New connection from %s", remote)
 	client := &Client{Conn: c, Send: make(chan []byte, 256), Hub: hub}
+	if user, ok := c.Locals("user").(*models.AuthenticatedUser); ok {
+		client.User = user
+	}
 
 	client.Hub.Register <- client
 
@@ -185,7 +304,7 @@ func (c *Client) readPump() {
 		_ = c.Conn.Close()
 	}()
 
-	c.Conn.SetReadLimit(512 * 1024) // 512KB
+	c.Conn.SetReadLimit(c.Hub.ReadLimit)
 	c.Conn.SetReadDeadline(time.Now().Add(70 * time.Second))
 	c.Conn.SetPongHandler(func(string) error {
 		c.Conn.SetReadDeadline(time.Now().Add(70 * time.Second))
@@ -205,6 +324,17 @@ func (c *Client) readPump() {
 
 		c.Conn.SetReadDeadline(time.Now().Add(70 * time.Second))
 
+		if !c.allowMessage() {
+			if c.dropStreak == 1 {
+				log.Printf("[WS] Throttling %s: more than %.0f messages/s", c.remoteAddr(), c.Hub.MessageRate)
+			}
+			if c.dropStreak >= maxDroppedMessages {
+				log.Printf("[WS] Disconnecting %s after %d throttled messages", c.remoteAddr(), c.dropStreak)
+				return
+			}
+			continue
+		}
+
 		if mt != websocket.TextMessage { // ignore binary / ping / pong frames; library handles ctrl frames
 			continue
 		}
@@ -225,6 +355,12 @@ func (c *Client) readPump() {
 			} else {
 				log.Printf("[WS] subscribe_form invalid payload: %#v", msg.Data)
 			}
+		case "field_timing":
+			c.recordFieldTiming(msg.FormID, msg.Data)
+		case "server_time":
+			c.sendServerTime()
+		case "sync_forms":
+			c.syncForms(msg.Data)
 		case "ping":
 			pong := Message{Type: "pong", Data: "pong"}
 			if b, err := json.Marshal(pong); err == nil {
@@ -240,6 +376,120 @@ func (c *Client) readPump() {
 	}
 }
 
+// syncForms answers a sync_forms request with a page of the form list so the
+// dashboard can load its initial state over the socket it already has open
+func (c *Client) syncForms(data interface{}) {
+	if c.Hub.FormsSync == nil {
+		return
+	}
+
+	var req SyncRequest
+	if raw, err := json.Marshal(data); err == nil {
+		_ = json.Unmarshal(raw, &req)
+	}
+
+	payload, err := c.Hub.FormsSync(req.ETag, req.Cursor)
+	if err != nil {
+		log.Printf("[WS] forms sync failed: %v", err)
+		payload = map[string]interface{}{"error": "Failed to sync forms"}
+	}
+
+	reply := Message{Type: "forms_sync", Data: payload}
+	if b, err := json.Marshal(reply); err == nil {
+		select {
+		case c.Send <- b:
+		default:
+			log.Printf("[WS] Drop forms sync (buffer full)")
+		}
+	}
+}
+
+// sendServerTime replies with the server clock and what the connection is
+// subscribed to, so clients can correct clock drift and confirm a
+// subscribe_form took effect. Requests faster than serverTimeInterval are dropped.
+func (c *Client) sendServerTime() {
+	now := time.Now()
+	if now.Sub(c.lastServerTime) < serverTimeInterval {
+		return
+	}
+	c.lastServerTime = now
+
+	data := map[string]interface{}{
+		"server_time":     now.UTC().Format(time.RFC3339Nano),
+		"unix_ms":         now.UnixMilli(),
+		"subscribed_form": c.FormID,
+		"authenticated":   c.User != nil,
+	}
+	if c.User != nil {
+		data["user"] = c.User
+	}
+
+	reply := Message{Type: "server_time", FormID: c.FormID, Data: data}
+	if b, err := json.Marshal(reply); err == nil {
+		select {
+		case c.Send <- b:
+		default:
+			log.Printf("[WS] Drop server_time (buffer full)")
+		}
+	}
+}
+
+// recordFieldTiming accepts a timing only for the form the client is
+// subscribed to, and only up to the per-connection limit
+func (c *Client) recordFieldTiming(formID string, data interface{}) {
+	if c.Hub.FieldTiming == nil || formID == "" || formID != c.FormID {
+		return
+	}
+	if c.timingEvents >= maxTimingEventsPerClient {
+		return
+	}
+
+	var event FieldTimingEvent
+	raw, err := json.Marshal(data)
+	if err != nil || json.Unmarshal(raw, &event) != nil || event.FieldID == "" {
+		log.Printf("[WS] field_timing invalid payload: %#v", data)
+		return
+	}
+
+	c.timingEvents++
+	select {
+	case c.Hub.timings <- fieldTiming{formID, event.FieldID, time.Duration(event.DurationMs) * time.Millisecond}:
+	default:
+		log.Printf("[WS] Drop field_timing (queue full)")
+	}
+}
+
+// allowMessage takes a token from the client's bucket, reporting false (and
+// extending the drop streak) when the client is sending too fast
+func (c *Client) allowMessage() bool {
+	now := time.Now()
+	burst := 2 * c.Hub.MessageRate
+	if c.lastRefill.IsZero() {
+		c.tokens = burst
+	} else {
+		c.tokens += now.Sub(c.lastRefill).Seconds() * c.Hub.MessageRate
+		if c.tokens > burst {
+			c.tokens = burst
+		}
+	}
+	c.lastRefill = now
+
+	if c.tokens < 1 {
+		c.dropStreak++
+		return false
+	}
+	c.tokens--
+	c.dropStreak = 0
+	return true
+}
+
+func (c *Client) remoteAddr() string {
+	if c.Conn != nil && c.Conn.Conn != nil && c.Conn.RemoteAddr() != nil {
+		return c.Conn.RemoteAddr().String()
+	}
+	return "unknown"
+}
+
 func truncateForLog(b []byte, max int) string {
 	if len(b) <= max {
 		return string(b)